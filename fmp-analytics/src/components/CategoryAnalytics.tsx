@@ -42,18 +42,18 @@ export const CategoryAnalytics: React.FC = () => {
 
   const pieData = categorySummary.map(item => ({
     name: item.category_name,
-    value: item.amount,
+    value: Number(item.amount),
     color: COLORS[categorySummary.indexOf(item) % COLORS.length]
   }));
 
   const barData = categorySummary.map(item => ({
     name: item.category_name,
-    amount: item.amount,
-    limit: item.limit || 0,
+    amount: Number(item.amount),
+    limit: Number(item.limit) || 0,
     exceeded: item.is_exceeded
   }));
 
-  const totalAmount = categorySummary.reduce((sum, item) => sum + item.amount, 0);
+  const totalAmount = categorySummary.reduce((sum, item) => sum + Number(item.amount), 0);
 
   if (loading) {
     return (
@@ -176,9 +176,9 @@ export const CategoryAnalytics: React.FC = () => {
           {categorySummary.map(item => (
             <div key={item.category_id} className="table-row">
               <span className="category-name">{item.category_name}</span>
-              <span className="amount">{item.amount.toLocaleString('ru-RU')} ₽</span>
+              <span className="amount">{Number(item.amount).toLocaleString('ru-RU')} ₽</span>
               <span className="limit">
-                {item.limit ? `${item.limit.toLocaleString('ru-RU')} ₽` : 'Не установлен'}
+                {item.limit ? `${Number(item.limit).toLocaleString('ru-RU')} ₽` : 'Не установлен'}
               </span>
               <span className={`status ${item.is_exceeded ? 'exceeded' : 'normal'}`}>
                 {item.is_exceeded ? 'Превышен' : 'Норма'}
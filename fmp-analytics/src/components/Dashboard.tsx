@@ -43,14 +43,14 @@ export const Dashboard: React.FC = () => {
 
   const pieData = categorySummary.map(item => ({
     name: item.category_name,
-    value: item.amount,
+    value: Number(item.amount),
     color: COLORS[categorySummary.indexOf(item) % COLORS.length]
   }));
 
   const barData = categorySummary.map(item => ({
     name: item.category_name,
-    amount: item.amount,
-    limit: item.limit || 0,
+    amount: Number(item.amount),
+    limit: Number(item.limit) || 0,
     exceeded: item.is_exceeded
   }));
 
@@ -93,7 +93,7 @@ export const Dashboard: React.FC = () => {
           </div>
           <div className="card-content">
             <h3>Общие расходы</h3>
-            <p className="card-value">{monthlySummary?.total.toLocaleString('ru-RU')} ₽</p>
+            <p className="card-value">{monthlySummary && Number(monthlySummary.total).toLocaleString('ru-RU')} ₽</p>
           </div>
         </div>
 
@@ -172,8 +172,8 @@ export const Dashboard: React.FC = () => {
                   </span>
                 </div>
                 <div className="exceeded-amounts">
-                  <span className="limit">Лимит: {item.limit.toLocaleString('ru-RU')} ₽</span>
-                  <span className="actual">Потрачено: {item.actual.toLocaleString('ru-RU')} ₽</span>
+                  <span className="limit">Лимит: {Number(item.limit).toLocaleString('ru-RU')} ₽</span>
+                  <span className="actual">Потрачено: {Number(item.actual).toLocaleString('ru-RU')} ₽</span>
                 </div>
               </div>
             ))}
@@ -51,11 +51,11 @@ export const LimitAnalytics: React.FC = () => {
   const chartData = Object.entries(exceededByMonth).map(([month, items]) => ({
     month: new Date(selectedYear, parseInt(month) - 1).toLocaleDateString('ru-RU', { month: 'short' }),
     count: items.length,
-    totalExceeded: items.reduce((sum, item) => sum + (item.actual - item.limit), 0)
+    totalExceeded: items.reduce((sum, item) => sum + (Number(item.actual) - Number(item.limit)), 0)
   }));
 
   const totalExceeded = limitExceeded.length;
-  const totalOverLimit = limitExceeded.reduce((sum, item) => sum + (item.actual - item.limit), 0);
+  const totalOverLimit = limitExceeded.reduce((sum, item) => sum + (Number(item.actual) - Number(item.limit)), 0);
   const averageExceeded = totalExceeded > 0 ? totalOverLimit / totalExceeded : 0;
 
   if (loading) {
@@ -170,7 +170,7 @@ export const LimitAnalytics: React.FC = () => {
               <span className="month">
                 {new Date(selectedYear, limit.month - 1).toLocaleDateString('ru-RU', { month: 'long' })}
               </span>
-              <span className="limit">{limit.limit.toLocaleString('ru-RU')} ₽</span>
+              <span className="limit">{Number(limit.limit).toLocaleString('ru-RU')} ₽</span>
             </div>
           ))}
         </div>
@@ -191,16 +191,16 @@ export const LimitAnalytics: React.FC = () => {
               <div className="exceeded-details">
                 <div className="amount-detail">
                   <span className="label">Лимит:</span>
-                  <span className="limit">{item.limit.toLocaleString('ru-RU')} ₽</span>
+                  <span className="limit">{Number(item.limit).toLocaleString('ru-RU')} ₽</span>
                 </div>
                 <div className="amount-detail">
                   <span className="label">Потрачено:</span>
-                  <span className="actual">{item.actual.toLocaleString('ru-RU')} ₽</span>
+                  <span className="actual">{Number(item.actual).toLocaleString('ru-RU')} ₽</span>
                 </div>
                 <div className="amount-detail">
                   <span className="label">Превышение:</span>
                   <span className="exceeded">
-                    +{(item.actual - item.limit).toLocaleString('ru-RU')} ₽
+                    +{(Number(item.actual) - Number(item.limit)).toLocaleString('ru-RU')} ₽
                   </span>
                 </div>
               </div>
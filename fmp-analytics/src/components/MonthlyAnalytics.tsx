@@ -26,7 +26,7 @@ export const MonthlyAnalytics: React.FC = () => {
             month,
             year,
             categories: [],
-            total: 0
+            total: '0.00'
           });
         }
       }
@@ -41,14 +41,14 @@ export const MonthlyAnalytics: React.FC = () => {
 
   const chartData = monthlyData.map(item => ({
     month: new Date(year, item.month - 1).toLocaleDateString('ru-RU', { month: 'short' }),
-    amount: item.total,
+    amount: Number(item.total),
     fullMonth: new Date(year, item.month - 1).toLocaleDateString('ru-RU', { month: 'long' })
   }));
 
-  const totalYear = monthlyData.reduce((sum, item) => sum + item.total, 0);
+  const totalYear = monthlyData.reduce((sum, item) => sum + Number(item.total), 0);
   const averageMonth = totalYear / 12;
-  const maxMonth = Math.max(...monthlyData.map(item => item.total));
-  const minMonth = Math.min(...monthlyData.map(item => item.total));
+  const maxMonth = Math.max(...monthlyData.map(item => Number(item.total)));
+  const minMonth = Math.min(...monthlyData.map(item => Number(item.total)));
 
   if (loading) {
     return (
@@ -137,14 +137,14 @@ export const MonthlyAnalytics: React.FC = () => {
                 <span className="month-name">
                   {new Date(item.year, item.month - 1).toLocaleDateString('ru-RU', { month: 'long' })}
                 </span>
-                <span className="month-total">{item.total.toLocaleString('ru-RU')} ₽</span>
+                <span className="month-total">{Number(item.total).toLocaleString('ru-RU')} ₽</span>
               </div>
               {item.categories.length > 0 && (
                 <div className="categories-list">
                   {item.categories.slice(0, 3).map(category => (
                     <div key={category.category_id} className="category-item">
                       <span className="category-name">{category.category_name}</span>
-                      <span className="category-amount">{category.amount.toLocaleString('ru-RU')} ₽</span>
+                      <span className="category-amount">{Number(category.amount).toLocaleString('ru-RU')} ₽</span>
                     </div>
                   ))}
                   {item.categories.length > 3 && (
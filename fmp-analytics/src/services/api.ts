@@ -9,6 +9,55 @@ const api = axios.create({
   },
 });
 
+declare global {
+  interface Window {
+    Telegram?: {
+      WebApp: {
+        initData: string;
+      };
+    };
+  }
+}
+
+interface Session {
+  token: string;
+  expires_at: string;
+}
+
+let session: Session | null = null;
+
+// Exchanges Telegram init data for a short-lived session token. The dashboard
+// is opened as a Mini App of the bot, like the minapp, so it has init data.
+const getSessionToken = async (): Promise<string> => {
+  if (!session || new Date(session.expires_at).getTime() - Date.now() < 60_000) {
+    const response = await axios.post<Session>(`${API_BASE_URL}/auth/session`, null, {
+      headers: { 'X-Telegram-Init-Data': window.Telegram?.WebApp.initData ?? '' },
+    });
+    session = response.data;
+  }
+  return session.token;
+};
+
+// The budget to show, given as ?budget_id= in the link to the dashboard, the
+// personal budget when not set
+let currentBudgetId: string | null = new URLSearchParams(window.location.search).get('budget_id');
+
+export const setCurrentBudget = (budgetId: string | null) => {
+  currentBudgetId = budgetId;
+};
+
+api.interceptors.request.use(async (config) => {
+  config.headers.Authorization = `Bearer ${await getSessionToken()}`;
+  if (currentBudgetId) {
+    config.headers['X-Budget-ID'] = currentBudgetId;
+  }
+  return config;
+});
+
+// Money is an exact decimal amount like "12.50", convert it with Number()
+// to chart or sum it
+export type Money = string;
+
 export interface Category {
   id: string;
   name: string;
@@ -20,7 +69,7 @@ export interface Category {
 export interface Transaction {
   id: string;
   category_id: string;
-  amount: number;
+  amount: Money;
   description?: string;
   date: string;
   created_at: string;
@@ -31,21 +80,21 @@ export interface MonthlySummary {
   month: number;
   year: number;
   categories: CategorySummary[];
-  total: number;
+  total: Money;
 }
 
 export interface CategorySummary {
   category_id: string;
   category_name: string;
-  amount: number;
-  limit?: number;
+  amount: Money;
+  limit?: Money;
   is_exceeded: boolean;
 }
 
 export interface CategoryLimit {
   id: string;
   category_id: string;
-  limit: number;
+  limit: Money;
   month: number;
   year: number;
   created_at: string;
@@ -55,8 +104,8 @@ export interface CategoryLimit {
 export interface LimitExceeded {
   id: string;
   category_id: string;
-  limit: number;
-  actual: number;
+  limit: Money;
+  actual: Money;
   month: number;
   year: number;
   created_at: string;
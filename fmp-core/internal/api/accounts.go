@@ -0,0 +1,204 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Accounts handlers
+// @Summary Get all accounts
+// @Description Get all accounts
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Account
+// @Router /accounts [get]
+func getAccounts(c *gin.Context) {
+	accounts, err := services.GetAccounts(currentBudgetID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, accounts)
+}
+
+// @Summary Create a new account
+// @Description Create a new account
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param account body models.CreateAccountRequest true "Account data"
+// @Success 201 {object} models.Account
+// @Router /accounts [post]
+func createAccount(c *gin.Context) {
+	var req models.CreateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := services.CreateAccount(currentBudgetID(c), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// @Summary Get account by ID
+// @Description Get account by ID
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path string true "Account ID"
+// @Success 200 {object} models.Account
+// @Router /accounts/{id} [get]
+func getAccount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	account, err := services.GetAccount(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// @Summary Update account
+// @Description Update account
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path string true "Account ID"
+// @Param account body models.CreateAccountRequest true "Account data"
+// @Success 200 {object} models.Account
+// @Router /accounts/{id} [put]
+func updateAccount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := services.UpdateAccount(currentBudgetID(c), id, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// @Summary Delete account
+// @Description Move account to the trash with its transfers, its other transactions are kept
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path string true "Account ID"
+// @Success 204
+// @Router /accounts/{id} [delete]
+func deleteAccount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteAccount(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get account balances
+// @Description Get the balance of every account, now or at the end of a given date
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param as_of query string false "Date (YYYY-MM-DD)"
+// @Success 200 {array} models.AccountBalance
+// @Router /accounts/balances [get]
+func getAccountBalances(c *gin.Context) {
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	balances, err := services.GetAccountBalances(currentBudgetID(c), asOf)
+	if err != nil {
+		conversionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, balances)
+}
+
+// @Summary Get account balance
+// @Description Get the balance of an account, now or at the end of a given date
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path string true "Account ID"
+// @Param as_of query string false "Date (YYYY-MM-DD)"
+// @Success 200 {object} models.AccountBalance
+// @Router /accounts/{id}/balance [get]
+func getAccountBalance(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	balance, err := services.GetAccountBalance(currentBudgetID(c), id, asOf)
+	if _, ok := services.AsMissingExchangeRate(err); ok {
+		conversionError(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
+
+// parseAsOf reads the as_of query date as the end of that day, defaulting to now
+func parseAsOf(c *gin.Context) (time.Time, bool) {
+	asOf := c.Query("as_of")
+	if asOf == "" {
+		return time.Now(), true
+	}
+
+	date, err := time.Parse("2006-01-02", asOf)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as_of date"})
+		return time.Time{}, false
+	}
+
+	return date.AddDate(0, 0, 1).Add(-time.Microsecond), true
+}
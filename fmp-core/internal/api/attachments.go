@@ -0,0 +1,221 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// attachmentUpload stores an uploaded file with the transaction or planned
+// expense ownerID
+type attachmentUpload func(userID, ownerID uuid.UUID, fileName string, size int64, r io.Reader) (*models.Attachment, error)
+
+// multipartOverhead is room for the boundaries and part headers around the
+// file in a multipart upload
+const multipartOverhead = 64 << 10
+
+// uploadAttachment reads the "file" field of a multipart upload of at most
+// maxBytes and hands it to upload. The request body is capped as well, so a
+// larger upload is cut off rather than spooled to disk first.
+func uploadAttachment(c *gin.Context, maxBytes int64, upload attachmentUpload) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverhead)
+	header, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || (err == nil && header.Size > maxBytes) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File is larger than %d bytes", maxBytes)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing file"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := upload(currentBudgetID(c), id, header.Filename, header.Size, file)
+	if err != nil {
+		var notFound *services.AttachmentOwnerNotFoundError
+		var unsupported *services.UnsupportedAttachmentTypeError
+		switch {
+		case errors.As(err, &notFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.As(err, &unsupported):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// Attachments handlers
+// @Summary Get transaction attachments
+// @Description Get the attachments of a transaction
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param id path string true "Transaction ID"
+// @Success 200 {array} models.Attachment
+// @Router /transactions/{id}/attachments [get]
+func getTransactionAttachments(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	attachments, err := services.GetTransactionAttachments(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, attachments)
+}
+
+// @Summary Upload transaction attachment
+// @Description Attach a receipt photo (JPEG, PNG, GIF, WebP) or a PDF to a transaction
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Transaction ID"
+// @Param file formData file true "Attachment file"
+// @Success 201 {object} models.Attachment
+// @Router /transactions/{id}/attachments [post]
+func uploadTransactionAttachment(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uploadAttachment(c, maxBytes, services.AddTransactionAttachment)
+	}
+}
+
+// @Summary Get planned expense attachments
+// @Description Get the attachments of a planned expense
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param id path string true "Planned expense ID"
+// @Success 200 {array} models.Attachment
+// @Router /planned-expenses/{id}/attachments [get]
+func getPlannedExpenseAttachments(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	attachments, err := services.GetPlannedExpenseAttachments(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, attachments)
+}
+
+// @Summary Upload planned expense attachment
+// @Description Attach a photo (JPEG, PNG, GIF, WebP) or a PDF to a planned expense
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Planned expense ID"
+// @Param file formData file true "Attachment file"
+// @Success 201 {object} models.Attachment
+// @Router /planned-expenses/{id}/attachments [post]
+func uploadPlannedExpenseAttachment(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uploadAttachment(c, maxBytes, services.AddPlannedExpenseAttachment)
+	}
+}
+
+// @Summary Get attachment by ID
+// @Description Get attachment metadata by ID
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param id path string true "Attachment ID"
+// @Success 200 {object} models.Attachment
+// @Router /attachments/{id} [get]
+func getAttachment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	attachment, err := services.GetAttachment(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+// @Summary Download attachment
+// @Description Download the contents of an attachment
+// @Tags attachments
+// @Produce application/octet-stream
+// @Param id path string true "Attachment ID"
+// @Success 200 {file} file
+// @Router /attachments/{id}/download [get]
+func downloadAttachment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	attachment, contents, err := services.OpenAttachment(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	defer contents.Close()
+
+	disposition := mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName})
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, contents, map[string]string{
+		"Content-Disposition": disposition,
+	})
+}
+
+// @Summary Delete attachment
+// @Description Move an attachment to the trash, its contents are removed when it is purged
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param id path string true "Attachment ID"
+// @Success 204
+// @Router /attachments/{id} [delete]
+func deleteAttachment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteAttachment(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"net/http"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Budgets handlers
+// @Summary Get all budgets
+// @Description Get the budgets the user is a member of together with the user's role in each
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Budget
+// @Router /budgets [get]
+func getBudgets(c *gin.Context) {
+	budgets, err := services.GetBudgets(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, budgets)
+}
+
+// @Summary Create a new budget
+// @Description Create a shared budget owned by the user
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param budget body models.CreateBudgetRequest true "Budget data"
+// @Success 201 {object} models.Budget
+// @Router /budgets [post]
+func createBudget(c *gin.Context) {
+	var req models.CreateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budget, err := services.CreateBudget(currentUserID(c), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, budget)
+}
+
+// @Summary Update budget
+// @Description Rename a budget, only owners can do this
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param budget body models.CreateBudgetRequest true "Budget data"
+// @Success 200 {object} models.Budget
+// @Router /budgets/{id} [put]
+func updateBudget(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budget, err := services.UpdateBudget(currentUserID(c), id, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// @Summary Delete budget
+// @Description Delete a shared budget with all of its data, only owners can do this
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 204
+// @Router /budgets/{id} [delete]
+func deleteBudget(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteBudget(currentUserID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Budget members handlers
+// @Summary Get budget members
+// @Description Get the members of a budget with their roles
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {array} models.BudgetMember
+// @Router /budgets/{id}/members [get]
+func getBudgetMembers(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	members, err := services.GetBudgetMembers(currentUserID(c), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// @Summary Update budget member
+// @Description Change the role of a budget member, only owners can do this
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param userId path string true "Member user ID"
+// @Param member body models.UpdateBudgetMemberRequest true "Member data"
+// @Success 200 {object} models.BudgetMember
+// @Router /budgets/{id}/members/{userId} [put]
+func updateBudgetMember(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	memberID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req models.UpdateBudgetMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := services.UpdateBudgetMember(currentUserID(c), id, memberID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// @Summary Remove budget member
+// @Description Remove a member from a budget. Owners can remove anyone, other members can only leave.
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param userId path string true "Member user ID"
+// @Success 204
+// @Router /budgets/{id}/members/{userId} [delete]
+func removeBudgetMember(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	memberID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := services.RemoveBudgetMember(currentUserID(c), id, memberID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Budget invites handlers
+// @Summary Get budget invites
+// @Description Get the invites of a budget, only owners can do this
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {array} models.BudgetInvite
+// @Router /budgets/{id}/invites [get]
+func getBudgetInvites(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	invites, err := services.GetBudgetInvites(currentUserID(c), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invites)
+}
+
+// @Summary Create budget invite
+// @Description Create an invite to a shared budget. Its start parameter is passed to the bot's /start command.
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param invite body models.CreateBudgetInviteRequest false "Invite data"
+// @Success 201 {object} models.BudgetInvite
+// @Router /budgets/{id}/invites [post]
+func createBudgetInvite(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreateBudgetInviteRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	invite, err := services.CreateBudgetInvite(currentUserID(c), id, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invite)
+}
+
+// @Summary Delete budget invite
+// @Description Revoke a budget invite, only owners can do this
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Invite ID"
+// @Success 204
+// @Router /budget-invites/{id} [delete]
+func deleteBudgetInvite(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteBudgetInvite(currentUserID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Accept budget invite
+// @Description Join the budget of an invite, the token may be given as the join_ start parameter
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param invite body models.AcceptBudgetInviteRequest true "Invite token"
+// @Success 200 {object} models.Budget
+// @Router /budget-invites/accept [post]
+func acceptBudgetInvite(c *gin.Context) {
+	var req models.AcceptBudgetInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budget, err := services.AcceptBudgetInvite(currentUserID(c), req.Token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Exchange Rates handlers
+// @Summary Get exchange rates
+// @Description Get exchange rates with optional filters
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param base_currency query string false "Base currency"
+// @Param quote_currency query string false "Quote currency"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Success 200 {array} models.ExchangeRate
+// @Router /exchange-rates [get]
+func getExchangeRates(c *gin.Context) {
+	var filters models.ExchangeRateFilters
+
+	if baseCurrency := c.Query("base_currency"); baseCurrency != "" {
+		baseCurrency = strings.ToUpper(baseCurrency)
+		filters.BaseCurrency = &baseCurrency
+	}
+
+	if quoteCurrency := c.Query("quote_currency"); quoteCurrency != "" {
+		quoteCurrency = strings.ToUpper(quoteCurrency)
+		filters.QuoteCurrency = &quoteCurrency
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		if date, err := time.Parse("2006-01-02", startDate); err == nil {
+			filters.StartDate = &date
+		}
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		if date, err := time.Parse("2006-01-02", endDate); err == nil {
+			filters.EndDate = &date
+		}
+	}
+
+	rates, err := services.GetExchangeRates(currentBudgetID(c), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// @Summary Set an exchange rate
+// @Description Set the rate of a currency pair on a date, replacing the existing one
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param rate body models.CreateExchangeRateRequest true "Exchange rate data"
+// @Success 201 {object} models.ExchangeRate
+// @Router /exchange-rates [post]
+func createExchangeRate(c *gin.Context) {
+	var req models.CreateExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := services.CreateExchangeRate(currentBudgetID(c), req)
+	if err != nil {
+		exchangeRateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+// @Summary Import exchange rates
+// @Description Import exchange rates from a CSV file with date,base_currency,quote_currency,rate rows
+// @Tags exchange-rates
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV file"
+// @Success 200 {object} models.ExchangeRateImportResult
+// @Router /exchange-rates/import [post]
+func importExchangeRates(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing file"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	rates, err := services.ParseExchangeRatesCSV(file)
+	if err != nil {
+		exchangeRateError(c, err)
+		return
+	}
+
+	result, err := services.ImportExchangeRates(currentBudgetID(c), rates)
+	if err != nil {
+		exchangeRateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// exchangeRateError responds 400 to rates that can not be stored as given
+// and 500 to anything else
+func exchangeRateError(c *gin.Context, err error) {
+	var invalid *services.ExchangeRateValidationError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// @Summary Delete exchange rate
+// @Description Delete exchange rate
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param id path string true "Exchange rate ID"
+// @Success 204
+// @Router /exchange-rates/{id} [delete]
+func deleteExchangeRate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteExchangeRate(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseReportingCurrency reads the currency query parameter, defaulting to rubles
+func parseReportingCurrency(c *gin.Context) (string, bool) {
+	currency := strings.ToUpper(c.DefaultQuery("currency", models.DefaultCurrency))
+	if !models.IsCurrencyCode(currency) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency"})
+		return "", false
+	}
+
+	return currency, true
+}
@@ -0,0 +1,401 @@
+package api
+
+import (
+	"net/http"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Counterparties handlers
+// @Summary Get all counterparties
+// @Description Get everyone money is lent to or borrowed from
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Counterparty
+// @Router /counterparties [get]
+func getCounterparties(c *gin.Context) {
+	counterparties, err := services.GetCounterparties(currentBudgetID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, counterparties)
+}
+
+// @Summary Create a new counterparty
+// @Description Create a new counterparty
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param counterparty body models.CreateCounterpartyRequest true "Counterparty data"
+// @Success 201 {object} models.Counterparty
+// @Router /counterparties [post]
+func createCounterparty(c *gin.Context) {
+	var req models.CreateCounterpartyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	counterparty, err := services.CreateCounterparty(currentBudgetID(c), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, counterparty)
+}
+
+// @Summary Update counterparty
+// @Description Update counterparty
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param id path string true "Counterparty ID"
+// @Param counterparty body models.CreateCounterpartyRequest true "Counterparty data"
+// @Success 200 {object} models.Counterparty
+// @Router /counterparties/{id} [put]
+func updateCounterparty(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreateCounterpartyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	counterparty, err := services.UpdateCounterparty(currentBudgetID(c), id, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, counterparty)
+}
+
+// @Summary Delete counterparty
+// @Description Delete a counterparty without debts
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param id path string true "Counterparty ID"
+// @Success 204
+// @Router /counterparties/{id} [delete]
+func deleteCounterparty(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteCounterparty(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Debts handlers
+// @Summary Get all debts
+// @Description Get loans given and taken with optional filtering
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param counterparty_id query string false "Counterparty ID"
+// @Param direction query string false "lent or borrowed"
+// @Success 200 {array} models.Debt
+// @Router /debts [get]
+func getDebts(c *gin.Context) {
+	var filters models.DebtFilters
+
+	if counterpartyID := c.Query("counterparty_id"); counterpartyID != "" {
+		id, err := uuid.Parse(counterpartyID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid counterparty ID"})
+			return
+		}
+		filters.CounterpartyID = &id
+	}
+
+	if direction := models.DebtDirection(c.Query("direction")); direction != "" {
+		if direction != models.DebtDirectionLent && direction != models.DebtDirectionBorrowed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid direction"})
+			return
+		}
+		filters.Direction = &direction
+	}
+
+	debts, err := services.GetDebts(currentBudgetID(c), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, debts)
+}
+
+// @Summary Create a new debt
+// @Description Create a loan given or taken, with an optional monthly payment schedule
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param debt body models.CreateDebtRequest true "Debt data"
+// @Success 201 {object} models.Debt
+// @Router /debts [post]
+func createDebt(c *gin.Context) {
+	var req models.CreateDebtRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	debt, err := services.CreateDebt(currentBudgetID(c), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, debt)
+}
+
+// @Summary Get debt by ID
+// @Description Get debt by ID
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param id path string true "Debt ID"
+// @Success 200 {object} models.Debt
+// @Router /debts/{id} [get]
+func getDebt(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	debt, err := services.GetDebt(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, debt)
+}
+
+// @Summary Update debt
+// @Description Update debt
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param id path string true "Debt ID"
+// @Param debt body models.CreateDebtRequest true "Debt data"
+// @Success 200 {object} models.Debt
+// @Router /debts/{id} [put]
+func updateDebt(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreateDebtRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	debt, err := services.UpdateDebt(currentBudgetID(c), id, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, debt)
+}
+
+// @Summary Delete debt
+// @Description Move debt to the trash with its payments, transactions recorded for the payments are kept
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param id path string true "Debt ID"
+// @Success 204
+// @Router /debts/{id} [delete]
+func deleteDebt(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteDebt(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get debt balances
+// @Description Get the outstanding balance of every debt
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param as_of query string false "Date (YYYY-MM-DD)"
+// @Success 200 {array} models.DebtBalance
+// @Router /debts/balances [get]
+func getDebtBalances(c *gin.Context) {
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	balances, err := services.GetDebtBalances(currentBudgetID(c), asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, balances)
+}
+
+// @Summary Get debt balance
+// @Description Get what is paid off and still owed on a debt, including interest accrued since the last payment
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param id path string true "Debt ID"
+// @Param as_of query string false "Date (YYYY-MM-DD)"
+// @Success 200 {object} models.DebtBalance
+// @Router /debts/{id}/balance [get]
+func getDebtBalance(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	balance, err := services.GetDebtBalance(currentBudgetID(c), id, asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, balance)
+}
+
+// @Summary Get debt amortization
+// @Description Get the monthly payment schedule of a debt with the principal and interest of every payment
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param id path string true "Debt ID"
+// @Success 200 {object} models.Amortization
+// @Router /debts/{id}/amortization [get]
+func getDebtAmortization(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	amortization, err := services.GetDebtAmortization(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, amortization)
+}
+
+// @Summary Get debt payments
+// @Description Get the payments of a debt, newest first
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param id path string true "Debt ID"
+// @Success 200 {array} models.DebtPayment
+// @Router /debts/{id}/payments [get]
+func getDebtPayments(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	payments, err := services.GetDebtPayments(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
+// @Summary Add debt payment
+// @Description Record a payment of a debt, optionally as a transaction too
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param id path string true "Debt ID"
+// @Param payment body models.CreateDebtPaymentRequest true "Payment data"
+// @Success 201 {object} models.DebtPayment
+// @Router /debts/{id}/payments [post]
+func createDebtPayment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreateDebtPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := services.CreateDebtPayment(currentBudgetID(c), id, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// @Summary Delete debt payment
+// @Description Move a debt payment to the trash together with its transaction
+// @Tags debts
+// @Accept json
+// @Produce json
+// @Param id path string true "Payment ID"
+// @Success 204
+// @Router /debt-payments/{id} [delete]
+func deleteDebtPayment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteDebtPayment(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
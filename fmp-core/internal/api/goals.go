@@ -0,0 +1,290 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// defaultGoalPaceMonths is how many recent months the saving pace of a goal
+// is averaged over
+const defaultGoalPaceMonths = 3
+
+// Goals handlers
+// @Summary Get all goals
+// @Description Get all savings goals, nearest deadline first
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Goal
+// @Router /goals [get]
+func getGoals(c *gin.Context) {
+	goals, err := services.GetGoals(currentBudgetID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, goals)
+}
+
+// @Summary Create a new goal
+// @Description Create a savings goal, optionally linked to an account or a category
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param goal body models.CreateGoalRequest true "Goal data"
+// @Success 201 {object} models.Goal
+// @Router /goals [post]
+func createGoal(c *gin.Context) {
+	var req models.CreateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	goal, err := services.CreateGoal(currentBudgetID(c), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, goal)
+}
+
+// @Summary Get goal by ID
+// @Description Get goal by ID
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path string true "Goal ID"
+// @Success 200 {object} models.Goal
+// @Router /goals/{id} [get]
+func getGoal(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	goal, err := services.GetGoal(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, goal)
+}
+
+// @Summary Update goal
+// @Description Update goal
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path string true "Goal ID"
+// @Param goal body models.CreateGoalRequest true "Goal data"
+// @Success 200 {object} models.Goal
+// @Router /goals/{id} [put]
+func updateGoal(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreateGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	goal, err := services.UpdateGoal(currentBudgetID(c), id, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, goal)
+}
+
+// @Summary Delete goal
+// @Description Move goal to the trash with its contributions, linked transactions are kept
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path string true "Goal ID"
+// @Success 204
+// @Router /goals/{id} [delete]
+func deleteGoal(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteGoal(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get progress of all goals
+// @Description Get saved amount, required monthly contribution and projected completion date of every goal
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param as_of query string false "Date (YYYY-MM-DD)"
+// @Param months query int false "Months the saving pace is averaged over (default 3)"
+// @Success 200 {array} models.GoalProgress
+// @Router /goals/progress [get]
+func getGoalsProgress(c *gin.Context) {
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+	months, ok := parseGoalPaceMonths(c)
+	if !ok {
+		return
+	}
+
+	progress, err := services.GetGoalsProgress(currentBudgetID(c), asOf, months)
+	if err != nil {
+		conversionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// @Summary Get goal progress
+// @Description Get saved amount, required monthly contribution to make the deadline and projected completion date at the recent pace
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path string true "Goal ID"
+// @Param as_of query string false "Date (YYYY-MM-DD)"
+// @Param months query int false "Months the saving pace is averaged over (default 3)"
+// @Success 200 {object} models.GoalProgress
+// @Router /goals/{id}/progress [get]
+func getGoalProgress(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+	months, ok := parseGoalPaceMonths(c)
+	if !ok {
+		return
+	}
+
+	progress, err := services.GetGoalProgress(currentBudgetID(c), id, asOf, months)
+	if err != nil {
+		conversionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// parseGoalPaceMonths reads the months query parameter
+func parseGoalPaceMonths(c *gin.Context) (int, bool) {
+	months := c.Query("months")
+	if months == "" {
+		return defaultGoalPaceMonths, true
+	}
+
+	n, err := strconv.Atoi(months)
+	if err != nil || n < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid months"})
+		return 0, false
+	}
+	return n, true
+}
+
+// @Summary Get goal contributions
+// @Description Get the contributions made to a goal by hand, newest first
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path string true "Goal ID"
+// @Success 200 {array} models.GoalContribution
+// @Router /goals/{id}/contributions [get]
+func getGoalContributions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	contributions, err := services.GetGoalContributions(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, contributions)
+}
+
+// @Summary Add goal contribution
+// @Description Add money to a goal, a negative amount takes it back out
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path string true "Goal ID"
+// @Param contribution body models.CreateGoalContributionRequest true "Contribution data"
+// @Success 201 {object} models.GoalContribution
+// @Router /goals/{id}/contributions [post]
+func createGoalContribution(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreateGoalContributionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contribution, err := services.CreateGoalContribution(currentBudgetID(c), id, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, contribution)
+}
+
+// @Summary Delete goal contribution
+// @Description Move goal contribution to the trash
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param id path string true "Contribution ID"
+// @Success 204
+// @Router /goal-contributions/{id} [delete]
+func deleteGoalContribution(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteGoalContribution(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
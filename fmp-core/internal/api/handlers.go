@@ -1,10 +1,13 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"fmp-core/internal/config"
 	"fmp-core/internal/models"
 	"fmp-core/internal/services"
 
@@ -14,7 +17,7 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRoutes(router *gin.Engine, db interface{}) {
+func SetupRoutes(router *gin.Engine, db interface{}, cfg *config.Config) {
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -27,65 +30,214 @@ func SetupRoutes(router *gin.Engine, db interface{}) {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	api := router.Group("/api/v1")
+	api.Use(requireUser(cfg.JWTSecret))
 	{
-		// Categories
-		api.GET("/categories", getCategories)
-		api.POST("/categories", createCategory)
-		api.GET("/categories/:id", getCategory)
-		api.PUT("/categories/:id", updateCategory)
-		api.DELETE("/categories/:id", deleteCategory)
-
-		// Transactions
-		api.GET("/transactions", getTransactions)
-		api.POST("/transactions", createTransaction)
-		api.GET("/transactions/:id", getTransaction)
-		api.PUT("/transactions/:id", updateTransaction)
-		api.DELETE("/transactions/:id", deleteTransaction)
-
-		// Planned Expenses
-		api.GET("/planned-expenses", getPlannedExpenses)
-		api.POST("/planned-expenses", createPlannedExpense)
-		api.GET("/planned-expenses/:id", getPlannedExpense)
-		api.PUT("/planned-expenses/:id", updatePlannedExpense)
-		api.DELETE("/planned-expenses/:id", deletePlannedExpense)
-
-		// Planned Income
-		api.GET("/planned-income", getPlannedIncome)
-		api.POST("/planned-income", createPlannedIncome)
-		api.PUT("/planned-income/:id", updatePlannedIncome)
-		api.DELETE("/planned-income/:id", deletePlannedIncome)
-
-		// Category Limits
-		api.GET("/category-limits", getCategoryLimits)
-		api.POST("/category-limits", createCategoryLimit)
-		api.PUT("/category-limits/:id", updateCategoryLimit)
-		api.DELETE("/category-limits/:id", deleteCategoryLimit)
-
-		// Analytics
-		api.GET("/analytics/monthly-summary", getMonthlySummary)
-		api.GET("/analytics/category-summary", getCategorySummary)
-		api.GET("/analytics/limit-exceeded", getLimitExceeded)
-
-		// Notifications
-		api.GET("/notifications", getNotifications)
-		api.POST("/notifications", createNotification)
-		api.PUT("/notifications/:id/read", markNotificationAsRead)
-		api.GET("/notifications/stats", getNotificationStats)
-		api.POST("/notifications/check-daily", checkDailyReminder)
-		api.POST("/notifications/check-limits", checkLimitWarnings)
+		// Budgets
+		api.GET("/budgets", getBudgets)
+		api.POST("/budgets", createBudget)
+		api.PUT("/budgets/:id", updateBudget)
+		api.DELETE("/budgets/:id", deleteBudget)
+		api.GET("/budgets/:id/members", getBudgetMembers)
+		api.PUT("/budgets/:id/members/:userId", updateBudgetMember)
+		api.DELETE("/budgets/:id/members/:userId", removeBudgetMember)
+		api.GET("/budgets/:id/invites", getBudgetInvites)
+		api.POST("/budgets/:id/invites", createBudgetInvite)
+		api.DELETE("/budget-invites/:id", deleteBudgetInvite)
+		api.POST("/budget-invites/accept", acceptBudgetInvite)
+
+		// Everything below belongs to the budget selected by the request and
+		// names the role it needs: viewers may read, editors and owners may also
+		// write. Requests that only work something out, like a rules dry-run,
+		// are open to viewers whatever their method.
+		viewer := api.Group("", requireBudgetRole(models.BudgetRoleViewer))
+		editor := api.Group("", requireBudgetRole(models.BudgetRoleEditor))
+		{
+			// Categories
+			viewer.GET("/categories", getCategories)
+			editor.POST("/categories", createCategory)
+			viewer.GET("/categories/:id", getCategory)
+			editor.PUT("/categories/:id", updateCategory)
+			editor.DELETE("/categories/:id", deleteCategory)
+
+			// Accounts
+			viewer.GET("/accounts", getAccounts)
+			editor.POST("/accounts", createAccount)
+			viewer.GET("/accounts/balances", getAccountBalances)
+			viewer.GET("/accounts/:id", getAccount)
+			editor.PUT("/accounts/:id", updateAccount)
+			editor.DELETE("/accounts/:id", deleteAccount)
+			viewer.GET("/accounts/:id/balance", getAccountBalance)
+
+			// Transactions
+			viewer.GET("/transactions", getTransactions)
+			editor.POST("/transactions", createTransaction)
+			viewer.GET("/transactions/:id", getTransaction)
+			editor.PUT("/transactions/:id", updateTransaction)
+			editor.DELETE("/transactions/:id", deleteTransaction)
+			viewer.GET("/transactions/:id/attachments", getTransactionAttachments)
+			editor.POST("/transactions/:id/attachments", uploadTransactionAttachment(cfg.AttachmentMaxBytes))
+
+			// Transfers
+			viewer.GET("/transfers", getTransfers)
+			editor.POST("/transfers", createTransfer)
+			viewer.GET("/transfers/:id", getTransfer)
+			editor.DELETE("/transfers/:id", deleteTransfer)
+
+			// Planned Expenses
+			viewer.GET("/planned-expenses", getPlannedExpenses)
+			editor.POST("/planned-expenses", createPlannedExpense)
+			viewer.GET("/planned-expenses/:id", getPlannedExpense)
+			editor.PUT("/planned-expenses/:id", updatePlannedExpense)
+			editor.DELETE("/planned-expenses/:id", deletePlannedExpense)
+			editor.POST("/planned-expenses/:id/complete", completePlannedExpense)
+			editor.POST("/planned-expenses/:id/uncomplete", uncompletePlannedExpense)
+			viewer.GET("/planned-expenses/:id/attachments", getPlannedExpenseAttachments)
+			editor.POST("/planned-expenses/:id/attachments", uploadPlannedExpenseAttachment(cfg.AttachmentMaxBytes))
+
+			// Attachments
+			viewer.GET("/attachments/:id", getAttachment)
+			viewer.GET("/attachments/:id/download", downloadAttachment)
+			editor.DELETE("/attachments/:id", deleteAttachment)
+
+			// Recurring Expenses
+			viewer.GET("/recurring-expenses", getRecurringExpenses)
+			editor.POST("/recurring-expenses", createRecurringExpense)
+			editor.POST("/recurring-expenses/generate", generateRecurringExpenses(cfg.RecurringHorizonDays))
+			viewer.GET("/recurring-expenses/:id", getRecurringExpense)
+			editor.PUT("/recurring-expenses/:id", updateRecurringExpense)
+			editor.DELETE("/recurring-expenses/:id", deleteRecurringExpense)
+
+			// Planned Income
+			viewer.GET("/planned-income", getPlannedIncome)
+			editor.POST("/planned-income", createPlannedIncome)
+			editor.POST("/planned-income/copy", copyPlannedIncome)
+			editor.PUT("/planned-income/:id", updatePlannedIncome)
+			editor.DELETE("/planned-income/:id", deletePlannedIncome)
+
+			// Category Limits
+			viewer.GET("/category-limits", getCategoryLimits)
+			editor.POST("/category-limits", createCategoryLimit)
+			editor.POST("/category-limits/generate", generateCategoryLimits)
+			editor.PUT("/category-limits/:id", updateCategoryLimit)
+			editor.DELETE("/category-limits/:id", deleteCategoryLimit)
+
+			// Limit Templates
+			viewer.GET("/limit-templates", getLimitTemplates)
+			editor.POST("/limit-templates", createLimitTemplate)
+			editor.PUT("/limit-templates/:id", updateLimitTemplate)
+			editor.DELETE("/limit-templates/:id", deleteLimitTemplate)
+
+			// Goals
+			viewer.GET("/goals", getGoals)
+			editor.POST("/goals", createGoal)
+			viewer.GET("/goals/progress", getGoalsProgress)
+			viewer.GET("/goals/:id", getGoal)
+			editor.PUT("/goals/:id", updateGoal)
+			editor.DELETE("/goals/:id", deleteGoal)
+			viewer.GET("/goals/:id/progress", getGoalProgress)
+			viewer.GET("/goals/:id/contributions", getGoalContributions)
+			editor.POST("/goals/:id/contributions", createGoalContribution)
+			editor.DELETE("/goal-contributions/:id", deleteGoalContribution)
+
+			// Debts
+			viewer.GET("/counterparties", getCounterparties)
+			editor.POST("/counterparties", createCounterparty)
+			editor.PUT("/counterparties/:id", updateCounterparty)
+			editor.DELETE("/counterparties/:id", deleteCounterparty)
+			viewer.GET("/debts", getDebts)
+			editor.POST("/debts", createDebt)
+			viewer.GET("/debts/balances", getDebtBalances)
+			viewer.GET("/debts/:id", getDebt)
+			editor.PUT("/debts/:id", updateDebt)
+			editor.DELETE("/debts/:id", deleteDebt)
+			viewer.GET("/debts/:id/balance", getDebtBalance)
+			viewer.GET("/debts/:id/amortization", getDebtAmortization)
+			viewer.GET("/debts/:id/payments", getDebtPayments)
+			editor.POST("/debts/:id/payments", createDebtPayment)
+			editor.DELETE("/debt-payments/:id", deleteDebtPayment)
+
+			// Payees
+			viewer.GET("/payees", getPayees)
+			editor.POST("/payees", createPayee)
+			viewer.POST("/payees/match", matchPayees)
+			viewer.GET("/payees/:id", getPayee)
+			editor.PUT("/payees/:id", updatePayee)
+			editor.DELETE("/payees/:id", deletePayee)
+
+			// Rules
+			viewer.GET("/rules", getRules)
+			editor.POST("/rules", createRule)
+			viewer.POST("/rules/dry-run", dryRunRules)
+			editor.POST("/rules/apply", applyRules)
+			viewer.GET("/rules/:id", getRule)
+			editor.PUT("/rules/:id", updateRule)
+			editor.DELETE("/rules/:id", deleteRule)
+
+			// Search
+			viewer.GET("/search", search)
+
+			// Trash
+			viewer.GET("/trash", getTrash)
+			editor.POST("/trash/:kind/:id/restore", restoreTrashItem)
+			editor.DELETE("/trash/:kind/:id", purgeTrashItem)
+
+			// Exchange Rates
+			viewer.GET("/exchange-rates", getExchangeRates)
+			editor.POST("/exchange-rates", createExchangeRate)
+			editor.POST("/exchange-rates/import", importExchangeRates)
+			editor.DELETE("/exchange-rates/:id", deleteExchangeRate)
+
+			// Tags
+			viewer.GET("/tags", getTags)
+			editor.POST("/tags", createTag)
+			editor.PUT("/tags/:id", updateTag)
+			editor.DELETE("/tags/:id", deleteTag)
+
+			// Analytics
+			viewer.GET("/analytics/monthly-summary", getMonthlySummary)
+			viewer.GET("/analytics/category-summary", getCategorySummary)
+			viewer.GET("/analytics/planned-income-summary", getPlannedIncomeSummary)
+			viewer.GET("/analytics/tag-summary", getTagSummary)
+			viewer.GET("/analytics/top-payees", getTopPayees)
+			viewer.GET("/analytics/limit-exceeded", getLimitExceeded)
+
+			// Notifications
+			viewer.GET("/notifications", getNotifications)
+			editor.POST("/notifications", createNotification)
+			editor.PUT("/notifications/:id/read", markNotificationAsRead)
+			viewer.GET("/notifications/stats", getNotificationStats)
+			editor.POST("/notifications/check-daily", checkDailyReminder)
+			editor.POST("/notifications/check-limits", checkLimitWarnings)
+		}
+	}
+}
+
+// conversionError responds to an error of a service converting amounts
+// between currencies, naming the rate to add when one is missing
+func conversionError(c *gin.Context, err error) {
+	if missing, ok := services.AsMissingExchangeRate(err); ok {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": missing.Error(), "from": missing.From, "to": missing.To, "date": missing.Date})
+		return
 	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
 
 // Categories handlers
 // @Summary Get all categories
-// @Description Get all categories
+// @Description Get all categories as a tree of top-level categories with their subcategories, or as a flat list
 // @Tags categories
 // @Accept json
 // @Produce json
+// @Param flat query bool false "Return a flat list instead of a tree"
 // @Success 200 {array} models.Category
 // @Router /categories [get]
 func getCategories(c *gin.Context) {
-	categories, err := services.GetCategories()
+	getCategoryList := services.GetCategoryTree
+	if flat, _ := strconv.ParseBool(c.Query("flat")); flat {
+		getCategoryList = services.GetCategories
+	}
+
+	categories, err := getCategoryList(currentBudgetID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -108,7 +260,7 @@ func createCategory(c *gin.Context) {
 		return
 	}
 
-	category, err := services.CreateCategory(req)
+	category, err := services.CreateCategory(currentBudgetID(c), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -132,7 +284,7 @@ func getCategory(c *gin.Context) {
 		return
 	}
 
-	category, err := services.GetCategory(id)
+	category, err := services.GetCategory(currentBudgetID(c), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -163,7 +315,7 @@ func updateCategory(c *gin.Context) {
 		return
 	}
 
-	category, err := services.UpdateCategory(id, req)
+	category, err := services.UpdateCategory(currentBudgetID(c), id, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -173,11 +325,13 @@ func updateCategory(c *gin.Context) {
 }
 
 // @Summary Delete category
-// @Description Delete category
+// @Description Move category to the trash. A category still used by subcategories, transactions, planned expenses, recurring expenses, limits, limit templates, planned income, goals, debts or rules is refused with what uses it, unless reassign_to is given: all of it is then moved to that category in one transaction and the moved counts are returned, along with the limits and the limit template trashed because that category already had them.
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param id path string true "Category ID"
+// @Param reassign_to query string false "Category to move what uses the deleted one to"
+// @Success 200 {object} models.CategoryReassignment
 // @Success 204
 // @Router /categories/{id} [delete]
 func deleteCategory(c *gin.Context) {
@@ -187,12 +341,37 @@ func deleteCategory(c *gin.Context) {
 		return
 	}
 
-	if err := services.DeleteCategory(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var reassignTo *uuid.UUID
+	if target := c.Query("reassign_to"); target != "" {
+		targetID, err := uuid.Parse(target)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reassign_to"})
+			return
+		}
+		reassignTo = &targetID
+	}
+
+	reassignment, err := services.DeleteCategory(currentBudgetID(c), id, reassignTo)
+	if err != nil {
+		var inUse *services.CategoryInUseError
+		switch {
+		case errors.As(err, &inUse):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "usage": inUse.Usage})
+		case errors.Is(err, services.ErrCategoryNotFound), errors.Is(err, services.ErrReassignTargetNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrReassignToItself), errors.Is(err, services.ErrReassignToOwnSubcategory):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	if reassignment == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, reassignment)
 }
 
 // Transactions handlers
@@ -202,8 +381,16 @@ func deleteCategory(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param category_id query string false "Category ID"
+// @Param account_id query string false "Account ID"
+// @Param type query string false "Transaction type (income or expense)"
+// @Param payee_id query string false "Payee ID"
+// @Param q query string false "Full-text search, orders the results by rank"
+// @Param tag query []string false "Tag name, repeat to require several tags" collectionFormat(multi)
 // @Param start_date query string false "Start date (YYYY-MM-DD)"
 // @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param exclude_transfers query bool false "Leave out the transactions of transfers between accounts"
+// @Param sort query string false "Newest first by date (default) or by when they were recorded (created_at)"
+// @Param limit query int false "Number of transactions (default all)"
 // @Success 200 {array} models.Transaction
 // @Router /transactions [get]
 func getTransactions(c *gin.Context) {
@@ -218,6 +405,36 @@ func getTransactions(c *gin.Context) {
 		filters.CategoryID = &id
 	}
 
+	if accountID := c.Query("account_id"); accountID != "" {
+		id, err := uuid.Parse(accountID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+			return
+		}
+		filters.AccountID = &id
+	}
+
+	if transactionType := c.Query("type"); transactionType != "" {
+		t := models.TransactionType(transactionType)
+		if t != models.TransactionTypeIncome && t != models.TransactionTypeExpense {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction type"})
+			return
+		}
+		filters.Type = &t
+	}
+
+	if payeeID := c.Query("payee_id"); payeeID != "" {
+		id, err := uuid.Parse(payeeID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payee ID"})
+			return
+		}
+		filters.PayeeID = &id
+	}
+
+	filters.Query = strings.TrimSpace(c.Query("q"))
+	filters.Tags = normalizeTagParams(c.QueryArray("tag"))
+
 	if startDate := c.Query("start_date"); startDate != "" {
 		if date, err := time.Parse("2006-01-02", startDate); err == nil {
 			filters.StartDate = &date
@@ -230,7 +447,33 @@ func getTransactions(c *gin.Context) {
 		}
 	}
 
-	transactions, err := services.GetTransactions(filters)
+	if excludeTransfers := c.Query("exclude_transfers"); excludeTransfers != "" {
+		exclude, err := strconv.ParseBool(excludeTransfers)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exclude_transfers"})
+			return
+		}
+		filters.ExcludeTransfers = exclude
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		filters.Sort = models.TransactionSort(sort)
+		if filters.Sort != models.TransactionSortDate && filters.Sort != models.TransactionSortCreatedAt {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort"})
+			return
+		}
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filters.Limit = n
+	}
+
+	transactions, err := services.GetTransactions(currentBudgetID(c), filters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -259,15 +502,26 @@ func createTransaction(c *gin.Context) {
 		req.Date = time.Now()
 	}
 
-	transaction, err := services.CreateTransaction(req)
+	transaction, err := services.CreateTransaction(currentBudgetID(c), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		transactionError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, transaction)
 }
 
+// transactionError responds 400 to a transaction that can not be saved as
+// given and 500 to anything else
+func transactionError(c *gin.Context, err error) {
+	var invalid *services.TransactionValidationError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
 // @Summary Get transaction by ID
 // @Description Get transaction by ID
 // @Tags transactions
@@ -283,7 +537,7 @@ func getTransaction(c *gin.Context) {
 		return
 	}
 
-	transaction, err := services.GetTransaction(id)
+	transaction, err := services.GetTransaction(currentBudgetID(c), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -314,9 +568,9 @@ func updateTransaction(c *gin.Context) {
 		return
 	}
 
-	transaction, err := services.UpdateTransaction(id, req)
+	transaction, err := services.UpdateTransaction(currentBudgetID(c), id, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		transactionError(c, err)
 		return
 	}
 
@@ -324,7 +578,7 @@ func updateTransaction(c *gin.Context) {
 }
 
 // @Summary Delete transaction
-// @Description Delete transaction
+// @Description Move transaction to the trash
 // @Tags transactions
 // @Accept json
 // @Produce json
@@ -338,7 +592,7 @@ func deleteTransaction(c *gin.Context) {
 		return
 	}
 
-	if err := services.DeleteTransaction(id); err != nil {
+	if err := services.DeleteTransaction(currentBudgetID(c), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -353,9 +607,11 @@ func deleteTransaction(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param category_id query string false "Category ID"
+// @Param recurring_expense_id query string false "Recurring expense ID"
 // @Param start_date query string false "Start date (YYYY-MM-DD)"
 // @Param end_date query string false "End date (YYYY-MM-DD)"
 // @Param is_completed query bool false "Is completed"
+// @Param q query string false "Full-text search, orders the results by rank"
 // @Success 200 {array} models.PlannedExpense
 // @Router /planned-expenses [get]
 func getPlannedExpenses(c *gin.Context) {
@@ -370,6 +626,15 @@ func getPlannedExpenses(c *gin.Context) {
 		filters.CategoryID = &id
 	}
 
+	if recurringExpenseID := c.Query("recurring_expense_id"); recurringExpenseID != "" {
+		id, err := uuid.Parse(recurringExpenseID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recurring expense ID"})
+			return
+		}
+		filters.RecurringExpenseID = &id
+	}
+
 	if startDate := c.Query("start_date"); startDate != "" {
 		if date, err := time.Parse("2006-01-02", startDate); err == nil {
 			filters.StartDate = &date
@@ -388,7 +653,9 @@ func getPlannedExpenses(c *gin.Context) {
 		}
 	}
 
-	expenses, err := services.GetPlannedExpenses(filters)
+	filters.Query = strings.TrimSpace(c.Query("q"))
+
+	expenses, err := services.GetPlannedExpenses(currentBudgetID(c), filters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -412,7 +679,7 @@ func createPlannedExpense(c *gin.Context) {
 		return
 	}
 
-	expense, err := services.CreatePlannedExpense(req)
+	expense, err := services.CreatePlannedExpense(currentBudgetID(c), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -436,7 +703,7 @@ func getPlannedExpense(c *gin.Context) {
 		return
 	}
 
-	expense, err := services.GetPlannedExpense(id)
+	expense, err := services.GetPlannedExpense(currentBudgetID(c), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -467,7 +734,7 @@ func updatePlannedExpense(c *gin.Context) {
 		return
 	}
 
-	expense, err := services.UpdatePlannedExpense(id, req)
+	expense, err := services.UpdatePlannedExpense(currentBudgetID(c), id, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -477,7 +744,7 @@ func updatePlannedExpense(c *gin.Context) {
 }
 
 // @Summary Delete planned expense
-// @Description Delete planned expense
+// @Description Move planned expense to the trash
 // @Tags planned-expenses
 // @Accept json
 // @Produce json
@@ -491,7 +758,7 @@ func deletePlannedExpense(c *gin.Context) {
 		return
 	}
 
-	if err := services.DeletePlannedExpense(id); err != nil {
+	if err := services.DeletePlannedExpense(currentBudgetID(c), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -499,12 +766,70 @@ func deletePlannedExpense(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// @Summary Complete planned expense
+// @Description Mark a planned expense completed and create its transaction, or link an existing one. The body is optional.
+// @Tags planned-expenses
+// @Accept json
+// @Produce json
+// @Param id path string true "Planned expense ID"
+// @Param completion body models.CompletePlannedExpenseRequest false "Transaction to link, or amount and date of the new one"
+// @Success 200 {object} models.PlannedExpense
+// @Router /planned-expenses/{id}/complete [post]
+func completePlannedExpense(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CompletePlannedExpenseRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	expense, err := services.CompletePlannedExpense(currentBudgetID(c), id, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
+// @Summary Uncomplete planned expense
+// @Description Reopen a completed planned expense. The transaction created on completion is deleted, a linked one is kept.
+// @Tags planned-expenses
+// @Accept json
+// @Produce json
+// @Param id path string true "Planned expense ID"
+// @Success 200 {object} models.PlannedExpense
+// @Router /planned-expenses/{id}/uncomplete [post]
+func uncompletePlannedExpense(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	expense, err := services.UncompletePlannedExpense(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
 // Planned Income handlers
 // @Summary Get all planned income
-// @Description Get all planned income with optional filtering
+// @Description Get all planned income sources with optional filtering
 // @Tags planned-income
 // @Accept json
 // @Produce json
+// @Param category_id query string false "Income category ID"
 // @Param month query int false "Month"
 // @Param year query int false "Year"
 // @Success 200 {array} models.PlannedIncome
@@ -512,6 +837,15 @@ func deletePlannedExpense(c *gin.Context) {
 func getPlannedIncome(c *gin.Context) {
 	var filters models.PlannedIncomeFilters
 
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		id, err := uuid.Parse(categoryID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+			return
+		}
+		filters.CategoryID = &id
+	}
+
 	if month := c.Query("month"); month != "" {
 		if m, err := strconv.Atoi(month); err == nil {
 			filters.Month = &m
@@ -524,7 +858,7 @@ func getPlannedIncome(c *gin.Context) {
 		}
 	}
 
-	incomes, err := services.GetPlannedIncome(filters)
+	incomes, err := services.GetPlannedIncome(currentBudgetID(c), filters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -548,7 +882,7 @@ func createPlannedIncome(c *gin.Context) {
 		return
 	}
 
-	income, err := services.CreatePlannedIncome(req)
+	income, err := services.CreatePlannedIncome(currentBudgetID(c), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -557,6 +891,30 @@ func createPlannedIncome(c *gin.Context) {
 	c.JSON(http.StatusCreated, income)
 }
 
+// @Summary Copy planned income
+// @Description Copy a month's planned income, and optionally its planned expenses, into another month. Entries copied before are skipped.
+// @Tags planned-income
+// @Accept json
+// @Produce json
+// @Param copy body models.CopyPlannedIncomeRequest true "Source and target month"
+// @Success 200 {object} models.PlannedCopyResult
+// @Router /planned-income/copy [post]
+func copyPlannedIncome(c *gin.Context) {
+	var req models.CopyPlannedIncomeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := services.CopyPlannedIncome(currentBudgetID(c), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // @Summary Update planned income
 // @Description Update planned income
 // @Tags planned-income
@@ -579,7 +937,7 @@ func updatePlannedIncome(c *gin.Context) {
 		return
 	}
 
-	income, err := services.UpdatePlannedIncome(id, req)
+	income, err := services.UpdatePlannedIncome(currentBudgetID(c), id, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -589,7 +947,7 @@ func updatePlannedIncome(c *gin.Context) {
 }
 
 // @Summary Delete planned income
-// @Description Delete planned income
+// @Description Move planned income to the trash
 // @Tags planned-income
 // @Accept json
 // @Produce json
@@ -603,7 +961,7 @@ func deletePlannedIncome(c *gin.Context) {
 		return
 	}
 
-	if err := services.DeletePlannedIncome(id); err != nil {
+	if err := services.DeletePlannedIncome(currentBudgetID(c), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -646,7 +1004,7 @@ func getCategoryLimits(c *gin.Context) {
 		}
 	}
 
-	limits, err := services.GetCategoryLimits(filters)
+	limits, err := services.GetCategoryLimits(currentBudgetID(c), filters)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -670,7 +1028,7 @@ func createCategoryLimit(c *gin.Context) {
 		return
 	}
 
-	limit, err := services.CreateCategoryLimit(req)
+	limit, err := services.CreateCategoryLimit(currentBudgetID(c), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -701,7 +1059,7 @@ func updateCategoryLimit(c *gin.Context) {
 		return
 	}
 
-	limit, err := services.UpdateCategoryLimit(id, req)
+	limit, err := services.UpdateCategoryLimit(currentBudgetID(c), id, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -711,7 +1069,7 @@ func updateCategoryLimit(c *gin.Context) {
 }
 
 // @Summary Delete category limit
-// @Description Delete category limit
+// @Description Move category limit to the trash
 // @Tags category-limits
 // @Accept json
 // @Produce json
@@ -725,7 +1083,7 @@ func deleteCategoryLimit(c *gin.Context) {
 		return
 	}
 
-	if err := services.DeleteCategoryLimit(id); err != nil {
+	if err := services.DeleteCategoryLimit(currentBudgetID(c), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -741,6 +1099,7 @@ func deleteCategoryLimit(c *gin.Context) {
 // @Produce json
 // @Param month query int true "Month"
 // @Param year query int true "Year"
+// @Param currency query string false "Reporting currency (default RUB)"
 // @Success 200 {object} models.MonthlySummary
 // @Router /analytics/monthly-summary [get]
 func getMonthlySummary(c *gin.Context) {
@@ -756,9 +1115,51 @@ func getMonthlySummary(c *gin.Context) {
 		return
 	}
 
-	summary, err := services.GetMonthlySummary(month, year)
+	currency, ok := parseReportingCurrency(c)
+	if !ok {
+		return
+	}
+
+	summary, err := services.GetMonthlySummary(currentBudgetID(c), month, year, currency)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		conversionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// @Summary Get planned income summary
+// @Description Get the month's planned income sources with the income received in their categories
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param month query int true "Month"
+// @Param year query int true "Year"
+// @Param currency query string false "Reporting currency (default RUB)"
+// @Success 200 {object} models.PlannedIncomeSummary
+// @Router /analytics/planned-income-summary [get]
+func getPlannedIncomeSummary(c *gin.Context) {
+	month, err := strconv.Atoi(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid month"})
+		return
+	}
+
+	year, err := strconv.Atoi(c.Query("year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+		return
+	}
+
+	currency, ok := parseReportingCurrency(c)
+	if !ok {
+		return
+	}
+
+	summary, err := services.GetPlannedIncomeSummary(currentBudgetID(c), month, year, currency)
+	if err != nil {
+		conversionError(c, err)
 		return
 	}
 
@@ -773,6 +1174,7 @@ func getMonthlySummary(c *gin.Context) {
 // @Param category_id query string false "Category ID"
 // @Param start_date query string false "Start date (YYYY-MM-DD)"
 // @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param currency query string false "Reporting currency (default RUB)"
 // @Success 200 {array} models.CategorySummary
 // @Router /analytics/category-summary [get]
 func getCategorySummary(c *gin.Context) {
@@ -799,9 +1201,14 @@ func getCategorySummary(c *gin.Context) {
 		}
 	}
 
-	summary, err := services.GetCategorySummary(filters)
+	currency, ok := parseReportingCurrency(c)
+	if !ok {
+		return
+	}
+
+	summary, err := services.GetCategorySummary(currentBudgetID(c), filters, currency)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		conversionError(c, err)
 		return
 	}
 
@@ -816,7 +1223,7 @@ func getCategorySummary(c *gin.Context) {
 // @Success 200 {array} models.LimitExceeded
 // @Router /analytics/limit-exceeded [get]
 func getLimitExceeded(c *gin.Context) {
-	records, err := services.GetLimitExceeded()
+	records, err := services.GetLimitExceeded(currentBudgetID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -834,7 +1241,7 @@ func getLimitExceeded(c *gin.Context) {
 // @Success 200 {array} models.Notification
 // @Router /notifications [get]
 func getNotifications(c *gin.Context) {
-	notifications, err := services.GetNotifications()
+	notifications, err := services.GetNotifications(currentBudgetID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -857,7 +1264,7 @@ func createNotification(c *gin.Context) {
 		return
 	}
 
-	notification, err := services.CreateNotification(req)
+	notification, err := services.CreateNotification(currentBudgetID(c), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -881,7 +1288,7 @@ func markNotificationAsRead(c *gin.Context) {
 		return
 	}
 
-	if err := services.MarkNotificationAsRead(id); err != nil {
+	if err := services.MarkNotificationAsRead(currentBudgetID(c), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -897,7 +1304,7 @@ func markNotificationAsRead(c *gin.Context) {
 // @Success 200 {object} models.NotificationStats
 // @Router /notifications/stats [get]
 func getNotificationStats(c *gin.Context) {
-	stats, err := services.GetNotificationStats()
+	stats, err := services.GetNotificationStats(currentBudgetID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -913,7 +1320,7 @@ func getNotificationStats(c *gin.Context) {
 // @Success 200
 // @Router /notifications/check-daily [post]
 func checkDailyReminder(c *gin.Context) {
-	if err := services.CheckDailyReminder(); err != nil {
+	if err := services.CheckDailyReminder(currentBudgetID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -921,15 +1328,21 @@ func checkDailyReminder(c *gin.Context) {
 }
 
 // @Summary Check limit warnings
-// @Description Check if limit warnings should be sent
+// @Description Check if limit warnings should be sent, for the limits of the monthly summary in the reporting currency. A parent category without a limit of its own is checked against the sum of its subcategories' limits.
 // @Tags notifications
 // @Accept json
 // @Produce json
+// @Param currency query string false "Reporting currency (default RUB)"
 // @Success 200
 // @Router /notifications/check-limits [post]
 func checkLimitWarnings(c *gin.Context) {
-	if err := services.CheckLimitWarnings(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	currency, ok := parseReportingCurrency(c)
+	if !ok {
+		return
+	}
+
+	if err := services.CheckLimitWarnings(currentBudgetID(c), currency); err != nil {
+		conversionError(c, err)
 		return
 	}
 	c.Status(http.StatusOK)
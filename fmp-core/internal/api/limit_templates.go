@@ -0,0 +1,155 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Limit Templates handlers
+// @Summary Get all limit templates
+// @Description Get the default monthly limits of categories
+// @Tags limit-templates
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.LimitTemplate
+// @Router /limit-templates [get]
+func getLimitTemplates(c *gin.Context) {
+	templates, err := services.GetLimitTemplates(currentBudgetID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// @Summary Create a limit template
+// @Description Set the default monthly limit of a category, replacing its current template
+// @Tags limit-templates
+// @Accept json
+// @Produce json
+// @Param template body models.CreateLimitTemplateRequest true "Limit template data"
+// @Success 201 {object} models.LimitTemplate
+// @Router /limit-templates [post]
+func createLimitTemplate(c *gin.Context) {
+	var req models.CreateLimitTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := services.CreateLimitTemplate(currentBudgetID(c), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// @Summary Update limit template
+// @Description Update limit template
+// @Tags limit-templates
+// @Accept json
+// @Produce json
+// @Param id path string true "Limit template ID"
+// @Param template body models.CreateLimitTemplateRequest true "Limit template data"
+// @Success 200 {object} models.LimitTemplate
+// @Router /limit-templates/{id} [put]
+func updateLimitTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreateLimitTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := services.UpdateLimitTemplate(currentBudgetID(c), id, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// @Summary Delete limit template
+// @Description Delete limit template, limits generated from it stay
+// @Tags limit-templates
+// @Accept json
+// @Produce json
+// @Param id path string true "Limit template ID"
+// @Success 204
+// @Router /limit-templates/{id} [delete]
+func deleteLimitTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteLimitTemplate(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Generate category limits for a month
+// @Description Create a month's limits from the limit templates and/or the previous month's limits, skipping categories that already have a limit
+// @Tags category-limits
+// @Accept json
+// @Produce json
+// @Param month query int false "Month (default next month)"
+// @Param year query int false "Year (default year of next month)"
+// @Param source query string false "templates, previous or all (default all: templates first, then the previous month)"
+// @Success 200 {object} models.LimitGenerationResult
+// @Router /category-limits/generate [post]
+func generateCategoryLimits(c *gin.Context) {
+	next := time.Now().AddDate(0, 1, 1-time.Now().Day())
+	month, year := int(next.Month()), next.Year()
+
+	if value := c.Query("month"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 12 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid month"})
+			return
+		}
+		month = parsed
+	}
+
+	if value := c.Query("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+		year = parsed
+	}
+
+	source := models.LimitSource(c.DefaultQuery("source", string(models.LimitSourceAll)))
+	if source != models.LimitSourceAll && source != models.LimitSourceTemplates && source != models.LimitSourcePrevious {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid source"})
+		return
+	}
+
+	result, err := services.GenerateCategoryLimits(currentBudgetID(c), month, year, source)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	userIDKey   = "userID"
+	budgetIDKey = "budgetID"
+)
+
+// budgetHeader selects the budget a request works with, the user's personal
+// budget is used when it is missing
+const budgetHeader = "X-Budget-ID"
+
+// requireUser resolves the Telegram user from the session token issued by the
+// mini app backend and stores the matching fmp-core user ID in the request context
+func requireUser(jwtSecret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing session token"})
+			return
+		}
+
+		telegramID, err := parseSessionToken(jwtSecret, token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		user, err := services.GetOrCreateUser(telegramID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(userIDKey, user.ID)
+		c.Next()
+	}
+}
+
+func currentUserID(c *gin.Context) uuid.UUID {
+	return c.MustGet(userIDKey).(uuid.UUID)
+}
+
+// requireBudgetRole resolves the budget selected by the request and makes sure
+// the user has at least the required role in it
+func requireBudgetRole(required models.BudgetRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := currentUserID(c)
+		budgetID := userID
+		if header := c.GetHeader(budgetHeader); header != "" {
+			id, err := uuid.Parse(header)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid budget ID"})
+				return
+			}
+			budgetID = id
+		}
+
+		role, err := services.GetBudgetRole(userID, budgetID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !role.Allows(required) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient budget role"})
+			return
+		}
+
+		c.Set(budgetIDKey, budgetID)
+		c.Next()
+	}
+}
+
+// currentBudgetID returns the budget that owns the data of the request. Data
+// services take it in their userID parameter, see the services package.
+func currentBudgetID(c *gin.Context) uuid.UUID {
+	return c.MustGet(budgetIDKey).(uuid.UUID)
+}
+
+// parseSessionToken verifies an HS256 session token and returns the Telegram
+// user ID it was issued for
+func parseSessionToken(jwtSecret, token string) (int64, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(jwtSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return 0, fmt.Errorf("invalid session token: %w", err)
+	}
+
+	telegramID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid session token subject: %w", err)
+	}
+	return telegramID, nil
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// defaultTopPayeesLimit is how many payees the top payees endpoint returns
+// unless told otherwise
+const defaultTopPayeesLimit = 10
+
+// Payees handlers
+// @Summary Get all payees
+// @Description Get all payees with their aliases
+// @Tags payees
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Payee
+// @Router /payees [get]
+func getPayees(c *gin.Context) {
+	payees, err := services.GetPayees(currentBudgetID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, payees)
+}
+
+// @Summary Create a new payee
+// @Description Create a new payee, transactions without a payee whose description contains its name or an alias are matched to it
+// @Tags payees
+// @Accept json
+// @Produce json
+// @Param payee body models.CreatePayeeRequest true "Payee data"
+// @Success 201 {object} models.Payee
+// @Router /payees [post]
+func createPayee(c *gin.Context) {
+	var req models.CreatePayeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payee, err := services.CreatePayee(currentBudgetID(c), req)
+	if err != nil {
+		payeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, payee)
+}
+
+// @Summary Match payees
+// @Description Match the transactions without a payee to the payees by their description
+// @Tags payees
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]int
+// @Router /payees/match [post]
+func matchPayees(c *gin.Context) {
+	matched, err := services.MatchPayees(currentBudgetID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"matched": matched})
+}
+
+// @Summary Get payee by ID
+// @Description Get a specific payee by ID
+// @Tags payees
+// @Accept json
+// @Produce json
+// @Param id path string true "Payee ID"
+// @Success 200 {object} models.Payee
+// @Router /payees/{id} [get]
+func getPayee(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	payee, err := services.GetPayee(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payee)
+}
+
+// @Summary Update payee
+// @Description Rename a payee and replace its aliases
+// @Tags payees
+// @Accept json
+// @Produce json
+// @Param id path string true "Payee ID"
+// @Param payee body models.CreatePayeeRequest true "Payee data"
+// @Success 200 {object} models.Payee
+// @Router /payees/{id} [put]
+func updatePayee(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreatePayeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payee, err := services.UpdatePayee(currentBudgetID(c), id, req)
+	if err != nil {
+		payeeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payee)
+}
+
+// payeeError responds 409 to a name or an alias another payee already has
+// and 500 to anything else
+func payeeError(c *gin.Context, err error) {
+	var conflict *services.PayeeConflictError
+	if errors.As(err, &conflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// @Summary Delete payee
+// @Description Move a payee to the trash with the rules for it, its transactions get it back when it is restored
+// @Tags payees
+// @Accept json
+// @Produce json
+// @Param id path string true "Payee ID"
+// @Success 204
+// @Router /payees/{id} [delete]
+func deletePayee(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeletePayee(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get top payees
+// @Description Get the payees with the largest amount or the most transactions of a type over a period
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param type query string false "Transaction type: expense (default) or income"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param sort_by query string false "amount (default) or visits"
+// @Param limit query int false "Number of payees (default 10)"
+// @Param currency query string false "Reporting currency (default RUB)"
+// @Success 200 {array} models.PayeeSummary
+// @Router /analytics/top-payees [get]
+func getTopPayees(c *gin.Context) {
+	filters := models.PayeeSummaryFilters{
+		Type:   models.TransactionTypeExpense,
+		SortBy: "amount",
+		Limit:  defaultTopPayeesLimit,
+	}
+
+	if transactionType := c.Query("type"); transactionType != "" {
+		t := models.TransactionType(transactionType)
+		if t != models.TransactionTypeIncome && t != models.TransactionTypeExpense {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction type"})
+			return
+		}
+		filters.Type = t
+	}
+
+	if sortBy := c.Query("sort_by"); sortBy != "" {
+		if sortBy != "amount" && sortBy != "visits" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort_by"})
+			return
+		}
+		filters.SortBy = sortBy
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filters.Limit = n
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		if date, err := time.Parse("2006-01-02", startDate); err == nil {
+			filters.StartDate = &date
+		}
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		if date, err := time.Parse("2006-01-02", endDate); err == nil {
+			filters.EndDate = &date
+		}
+	}
+
+	currency, ok := parseReportingCurrency(c)
+	if !ok {
+		return
+	}
+
+	summary, err := services.GetTopPayees(currentBudgetID(c), filters, currency)
+	if err != nil {
+		conversionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Recurring Expenses handlers
+// @Summary Get all recurring expenses
+// @Description Get all recurring expense rules
+// @Tags recurring-expenses
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.RecurringExpense
+// @Router /recurring-expenses [get]
+func getRecurringExpenses(c *gin.Context) {
+	expenses, err := services.GetRecurringExpenses(currentBudgetID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, expenses)
+}
+
+// @Summary Create a new recurring expense
+// @Description Create a recurring expense rule, given either as frequency, interval, count and until or as an RRULE
+// @Tags recurring-expenses
+// @Accept json
+// @Produce json
+// @Param expense body models.CreateRecurringExpenseRequest true "Recurring expense data"
+// @Success 201 {object} models.RecurringExpense
+// @Router /recurring-expenses [post]
+func createRecurringExpense(c *gin.Context) {
+	var req models.CreateRecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expense, err := services.CreateRecurringExpense(currentBudgetID(c), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, expense)
+}
+
+// @Summary Get recurring expense by ID
+// @Description Get recurring expense by ID
+// @Tags recurring-expenses
+// @Accept json
+// @Produce json
+// @Param id path string true "Recurring expense ID"
+// @Success 200 {object} models.RecurringExpense
+// @Router /recurring-expenses/{id} [get]
+func getRecurringExpense(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	expense, err := services.GetRecurringExpense(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
+// @Summary Update recurring expense
+// @Description Update a recurring expense rule, its upcoming uncompleted occurrences are regenerated
+// @Tags recurring-expenses
+// @Accept json
+// @Produce json
+// @Param id path string true "Recurring expense ID"
+// @Param expense body models.CreateRecurringExpenseRequest true "Recurring expense data"
+// @Success 200 {object} models.RecurringExpense
+// @Router /recurring-expenses/{id} [put]
+func updateRecurringExpense(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreateRecurringExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expense, err := services.UpdateRecurringExpense(currentBudgetID(c), id, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, expense)
+}
+
+// @Summary Delete recurring expense
+// @Description Move a recurring expense rule to the trash with its upcoming uncompleted occurrences
+// @Tags recurring-expenses
+// @Accept json
+// @Produce json
+// @Param id path string true "Recurring expense ID"
+// @Success 204
+// @Router /recurring-expenses/{id} [delete]
+func deleteRecurringExpense(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteRecurringExpense(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Generate recurring expenses
+// @Description Materialize planned expenses for the coming days and post the due ones of auto-posted rules
+// @Tags recurring-expenses
+// @Accept json
+// @Produce json
+// @Param days query int false "Days ahead to generate (default from configuration)"
+// @Success 200 {object} models.RecurringGenerationResult
+// @Router /recurring-expenses/generate [post]
+func generateRecurringExpenses(defaultDays int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		days := defaultDays
+		if value := c.Query("days"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+				return
+			}
+			days = parsed
+		}
+
+		result, err := services.GenerateRecurringExpenses(currentBudgetID(c), time.Now().AddDate(0, 0, days))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"net/http"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Rules handlers
+// @Summary Get all rules
+// @Description Get all categorization rules in the order they run
+// @Tags rules
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Rule
+// @Router /rules [get]
+func getRules(c *gin.Context) {
+	rules, err := services.GetRules(currentBudgetID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// @Summary Create a new rule
+// @Description Create a categorization rule. New transactions meeting all of its conditions get its category, unless one is given, and its tags.
+// @Tags rules
+// @Accept json
+// @Produce json
+// @Param rule body models.CreateRuleRequest true "Rule data"
+// @Success 201 {object} models.Rule
+// @Router /rules [post]
+func createRule(c *gin.Context) {
+	var req models.CreateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := services.CreateRule(currentBudgetID(c), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// @Summary Dry-run rules
+// @Description Report how the rules would change the category and tags of past transactions without changing them. Rules named in rule_ids are tried even when inactive.
+// @Tags rules
+// @Accept json
+// @Produce json
+// @Param run body models.ApplyRulesRequest false "Rules and period"
+// @Success 200 {object} models.ApplyRulesResult
+// @Router /rules/dry-run [post]
+func dryRunRules(c *gin.Context) {
+	runRules(c, true)
+}
+
+// @Summary Apply rules
+// @Description Apply the rules to past transactions, changing their category and adding tags. Rules named in rule_ids are applied even when inactive.
+// @Tags rules
+// @Accept json
+// @Produce json
+// @Param run body models.ApplyRulesRequest false "Rules and period"
+// @Success 200 {object} models.ApplyRulesResult
+// @Router /rules/apply [post]
+func applyRules(c *gin.Context) {
+	runRules(c, false)
+}
+
+func runRules(c *gin.Context, dryRun bool) {
+	var req models.ApplyRulesRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := services.ApplyRules(currentBudgetID(c), req, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// @Summary Get rule by ID
+// @Description Get a specific rule by ID
+// @Tags rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Success 200 {object} models.Rule
+// @Router /rules/{id} [get]
+func getRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	rule, err := services.GetRule(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// @Summary Update rule
+// @Description Update rule
+// @Tags rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Param rule body models.CreateRuleRequest true "Rule data"
+// @Success 200 {object} models.Rule
+// @Router /rules/{id} [put]
+func updateRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := services.UpdateRule(currentBudgetID(c), id, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// @Summary Delete rule
+// @Description Move rule to the trash
+// @Tags rules
+// @Accept json
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Success 204
+// @Router /rules/{id} [delete]
+func deleteRule(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteRule(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
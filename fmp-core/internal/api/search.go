@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSearchLimit is how many results a search returns unless told otherwise
+const defaultSearchLimit = 20
+
+// @Summary Search
+// @Description Full-text search over the descriptions, category names, payees and notes of transactions and planned expenses. Russian and English words are stemmed, typos are matched by trigram similarity. Best matches come first, with the matches highlighted in <mark> tags.
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param q query string true "Search query, supports quoted phrases, or and -word"
+// @Param kind query []string false "transaction or planned_expense, repeat for both (default both)" collectionFormat(multi)
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param limit query int false "Number of results (default 20)"
+// @Success 200 {array} models.SearchResult
+// @Router /search [get]
+func search(c *gin.Context) {
+	filters := models.SearchFilters{
+		Query: strings.TrimSpace(c.Query("q")),
+		Limit: defaultSearchLimit,
+	}
+	if filters.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		return
+	}
+
+	for _, kind := range c.QueryArray("kind") {
+		if kind != models.SearchKindTransaction && kind != models.SearchKindPlannedExpense {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kind"})
+			return
+		}
+		filters.Kinds = append(filters.Kinds, kind)
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filters.Limit = n
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		if date, err := time.Parse("2006-01-02", startDate); err == nil {
+			filters.StartDate = &date
+		}
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		if date, err := time.Parse("2006-01-02", endDate); err == nil {
+			filters.EndDate = &date
+		}
+	}
+
+	results, err := services.Search(currentBudgetID(c), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
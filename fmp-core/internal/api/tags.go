@@ -0,0 +1,172 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// normalizeTagParams accepts both repeated and comma separated tag params
+func normalizeTagParams(values []string) []string {
+	var tags []string
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// Tags handlers
+// @Summary Get all tags
+// @Description Get all tags of the user
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Tag
+// @Router /tags [get]
+func getTags(c *gin.Context) {
+	tags, err := services.GetTags(currentBudgetID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tags)
+}
+
+// @Summary Create a new tag
+// @Description Create a new tag
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param tag body models.CreateTagRequest true "Tag data"
+// @Success 201 {object} models.Tag
+// @Router /tags [post]
+func createTag(c *gin.Context) {
+	var req models.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := services.CreateTag(currentBudgetID(c), req)
+	if err != nil {
+		tagError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// @Summary Update tag
+// @Description Rename a tag
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Param tag body models.CreateTagRequest true "Tag data"
+// @Success 200 {object} models.Tag
+// @Router /tags/{id} [put]
+func updateTag(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req models.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := services.UpdateTag(currentBudgetID(c), id, req)
+	if err != nil {
+		tagError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// tagError responds 409 to a name another tag already has and 500 to
+// anything else
+func tagError(c *gin.Context, err error) {
+	var exists *services.TagExistsError
+	if errors.As(err, &exists) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// @Summary Delete tag
+// @Description Move a tag to the trash, transactions and planned expenses get it back when it is restored
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Success 204
+// @Router /tags/{id} [delete]
+func deleteTag(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteTag(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Get tag summary
+// @Description Get spending and income per tag for a specific period
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Param currency query string false "Reporting currency (default RUB)"
+// @Success 200 {array} models.TagSummary
+// @Router /analytics/tag-summary [get]
+func getTagSummary(c *gin.Context) {
+	var filters models.TransactionFilters
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		if date, err := time.Parse("2006-01-02", startDate); err == nil {
+			filters.StartDate = &date
+		}
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		if date, err := time.Parse("2006-01-02", endDate); err == nil {
+			filters.EndDate = &date
+		}
+	}
+
+	currency, ok := parseReportingCurrency(c)
+	if !ok {
+		return
+	}
+
+	summary, err := services.GetTagSummary(currentBudgetID(c), filters, currency)
+	if err != nil {
+		conversionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
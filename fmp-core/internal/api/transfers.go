@@ -0,0 +1,126 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Transfers handlers
+// @Summary Get all transfers
+// @Description Get transfers between accounts with optional filtering
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Param account_id query string false "Account ID, either side of the transfer"
+// @Param start_date query string false "Start date (YYYY-MM-DD)"
+// @Param end_date query string false "End date (YYYY-MM-DD)"
+// @Success 200 {array} models.Transfer
+// @Router /transfers [get]
+func getTransfers(c *gin.Context) {
+	var filters models.TransferFilters
+
+	if accountID := c.Query("account_id"); accountID != "" {
+		id, err := uuid.Parse(accountID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+			return
+		}
+		filters.AccountID = &id
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		if date, err := time.Parse("2006-01-02", startDate); err == nil {
+			filters.StartDate = &date
+		}
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		if date, err := time.Parse("2006-01-02", endDate); err == nil {
+			filters.EndDate = &date
+		}
+	}
+
+	transfers, err := services.GetTransfers(currentBudgetID(c), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, transfers)
+}
+
+// @Summary Create a new transfer
+// @Description Move money between two accounts, recorded as a linked pair of transactions that do not count as spending
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Param transfer body models.CreateTransferRequest true "Transfer data"
+// @Success 201 {object} models.Transfer
+// @Router /transfers [post]
+func createTransfer(c *gin.Context) {
+	var req models.CreateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, err := services.CreateTransfer(currentBudgetID(c), req)
+	if err != nil {
+		conversionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// @Summary Get transfer by ID
+// @Description Get transfer by ID
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Param id path string true "Transfer ID"
+// @Success 200 {object} models.Transfer
+// @Router /transfers/{id} [get]
+func getTransfer(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	transfer, err := services.GetTransfer(currentBudgetID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+// @Summary Delete transfer
+// @Description Move a transfer to the trash together with both of its transactions
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Param id path string true "Transfer ID"
+// @Success 204
+// @Router /transfers/{id} [delete]
+func deleteTransfer(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.DeleteTransfer(currentBudgetID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func isTrashKind(kind string) bool {
+	for _, k := range models.TrashKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Trash handlers
+// @Summary Get the trash
+// @Description List deleted items that can still be restored, most recent first. Things deleted along with an item, like the transactions of a category, are restored with it and not listed on their own.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param kind query []string false "Item kind, repeat for several (default all)" collectionFormat(multi)
+// @Success 200 {array} models.TrashItem
+// @Router /trash [get]
+func getTrash(c *gin.Context) {
+	var filters models.TrashFilters
+	for _, kind := range c.QueryArray("kind") {
+		if !isTrashKind(kind) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kind"})
+			return
+		}
+		filters.Kinds = append(filters.Kinds, kind)
+	}
+
+	items, err := services.GetTrash(currentBudgetID(c), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// parseTrashItem reads the kind and id of a trash item from the path
+func parseTrashItem(c *gin.Context) (string, uuid.UUID, bool) {
+	kind := c.Param("kind")
+	if !isTrashKind(kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid kind"})
+		return "", uuid.Nil, false
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return "", uuid.Nil, false
+	}
+	return kind, id, true
+}
+
+// @Summary Restore an item from the trash
+// @Description Restore a deleted item together with everything deleted along with it. Fails while something the item belongs to, like its category, is still in the trash.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param kind path string true "Item kind"
+// @Param id path string true "Item ID"
+// @Success 204
+// @Router /trash/{kind}/{id}/restore [post]
+func restoreTrashItem(c *gin.Context) {
+	kind, id, ok := parseTrashItem(c)
+	if !ok {
+		return
+	}
+
+	if err := services.RestoreTrashItem(currentBudgetID(c), kind, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Delete an item from the trash
+// @Description Permanently delete an item in the trash together with everything deleted along with it, including attachment contents. Items in the trash that belong to it, like the transactions of a category, are deleted too.
+// @Tags trash
+// @Accept json
+// @Produce json
+// @Param kind path string true "Item kind"
+// @Param id path string true "Item ID"
+// @Success 204
+// @Router /trash/{kind}/{id} [delete]
+func purgeTrashItem(c *gin.Context) {
+	kind, id, ok := parseTrashItem(c)
+	if !ok {
+		return
+	}
+
+	if err := services.PurgeTrashItem(currentBudgetID(c), kind, id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"log"
 	"os"
+	"strconv"
+	"time"
+
+	"fmp-core/internal/storage"
 
 	"github.com/joho/godotenv"
 )
@@ -10,6 +15,22 @@ type Config struct {
 	Environment string
 	DatabaseURL string
 	Port        string
+	// DefaultOwnerTelegramID is the Telegram user that takes over data
+	// created before fmp-core became multi-user (0 leaves it unclaimed)
+	DefaultOwnerTelegramID int64
+	// JWTSecret verifies session tokens issued by the mini app backend
+	JWTSecret string
+	// SchedulerInterval is how often background jobs run
+	SchedulerInterval time.Duration
+	// RecurringHorizonDays is how many days ahead recurring expenses are planned
+	RecurringHorizonDays int
+	// TrashRetentionDays is how long deleted items can be restored before
+	// they are purged
+	TrashRetentionDays int
+	// Storage is where attachment contents are kept
+	Storage storage.Options
+	// AttachmentMaxBytes caps the size of an uploaded attachment
+	AttachmentMaxBytes int64
 }
 
 func Load() *Config {
@@ -28,10 +49,30 @@ func Load() *Config {
 		databaseURL = "postgres://" + dbUser + ":" + dbPassword + "@" + dbHost + ":" + dbPort + "/" + dbName + "?sslmode=disable"
 	}
 
+	defaultOwnerTelegramID, _ := strconv.ParseInt(getEnv("DEFAULT_OWNER_TELEGRAM_ID", "0"), 10, 64)
+	recurringHorizonDays, _ := strconv.Atoi(getEnv("RECURRING_HORIZON_DAYS", "31"))
+	trashRetentionDays := getPositiveIntEnv("TRASH_RETENTION_DAYS", 30)
+	attachmentMaxBytes, _ := strconv.ParseInt(getEnv("ATTACHMENT_MAX_BYTES", "10485760"), 10, 64)
+
 	return &Config{
-		Environment: getEnv("ENVIRONMENT", "development"),
-		DatabaseURL: databaseURL,
-		Port:        getEnv("API_PORT", "8080"),
+		Environment:            getEnv("ENVIRONMENT", "development"),
+		DatabaseURL:            databaseURL,
+		Port:                   getEnv("API_PORT", "8080"),
+		DefaultOwnerTelegramID: defaultOwnerTelegramID,
+		JWTSecret:              getEnv("JWT_SECRET", ""),
+		SchedulerInterval:      getDurationEnv("SCHEDULER_INTERVAL", time.Hour),
+		RecurringHorizonDays:   recurringHorizonDays,
+		TrashRetentionDays:     trashRetentionDays,
+		Storage: storage.Options{
+			Backend:           getEnv("STORAGE_BACKEND", "local"),
+			LocalDir:          getEnv("STORAGE_LOCAL_DIR", "data/attachments"),
+			S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+			S3Bucket:          getEnv("S3_BUCKET", ""),
+			S3Region:          getEnv("S3_REGION", "us-east-1"),
+			S3AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+			S3SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+		},
+		AttachmentMaxBytes: attachmentMaxBytes,
 	}
 }
 
@@ -41,3 +82,27 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getPositiveIntEnv falls back to the default on anything but a positive
+// integer, where a typo should not turn into zero
+func getPositiveIntEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		log.Printf("%s=%q is not a positive integer, using %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return number
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
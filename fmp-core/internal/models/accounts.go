@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AccountType string
+
+const (
+	AccountTypeDebitCard  AccountType = "debit_card"
+	AccountTypeCreditCard AccountType = "credit_card"
+	AccountTypeCash       AccountType = "cash"
+	AccountTypeSavings    AccountType = "savings"
+)
+
+type Account struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	UserID         uuid.UUID   `json:"user_id" db:"user_id"`
+	Name           string      `json:"name" db:"name"`
+	Type           AccountType `json:"type" db:"type"`
+	Currency       string      `json:"currency" db:"currency"`
+	OpeningBalance Money       `json:"opening_balance" db:"opening_balance"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+type CreateAccountRequest struct {
+	Name           string      `json:"name" binding:"required"`
+	Type           AccountType `json:"type" binding:"required,oneof=debit_card credit_card cash savings"`
+	Currency       string      `json:"currency" binding:"omitempty,iso4217"`
+	OpeningBalance Money       `json:"opening_balance"`
+}
+
+// AccountBalance is the balance of an account at the end of AsOf
+type AccountBalance struct {
+	AccountID      uuid.UUID `json:"account_id"`
+	AccountName    string    `json:"account_name"`
+	Currency       string    `json:"currency"`
+	OpeningBalance Money     `json:"opening_balance"`
+	Balance        Money     `json:"balance"`
+	AsOf           time.Time `json:"as_of"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "math"
+
+// Amortize lays out the monthly payments of a debt with a payment schedule,
+// the first one a month after StartDate. Amounts are rounded to minor units,
+// the last payment pays off whatever principal is left.
+func (d Debt) Amortize() []AmortizationRow {
+	if d.Schedule == DebtScheduleNone || d.Schedule == "" || d.TermMonths == nil || *d.TermMonths < 1 {
+		return nil
+	}
+
+	n := *d.TermMonths
+	monthlyRate := d.InterestRate / 100 / 12
+	balance := d.Principal
+
+	var annuity Money
+	if d.Schedule == DebtScheduleAnnuity {
+		if monthlyRate == 0 {
+			annuity = Money(math.Ceil(float64(d.Principal) / float64(n)))
+		} else {
+			annuity = Money(math.Round(float64(d.Principal) * monthlyRate / (1 - math.Pow(1+monthlyRate, -float64(n)))))
+		}
+	}
+
+	rows := make([]AmortizationRow, 0, n)
+	for k := 1; k <= n && balance > 0; k++ {
+		interest := Money(math.Round(float64(balance) * monthlyRate))
+
+		var principal Money
+		if d.Schedule == DebtScheduleAnnuity {
+			principal = annuity - interest
+		} else {
+			principal = d.Principal / Money(n)
+		}
+		if k == n || principal > balance {
+			principal = balance
+		}
+		balance -= principal
+
+		rows = append(rows, AmortizationRow{
+			Number:    k,
+			Date:      addMonthsClamped(d.StartDate, k),
+			Payment:   principal + interest,
+			Principal: principal,
+			Interest:  interest,
+			Balance:   balance,
+		})
+	}
+	return rows
+}
+
+// AccruedInterest is the simple interest on principal over the given number
+// of days at the debt's yearly rate
+func (d Debt) AccruedInterest(principal Money, days int) Money {
+	if principal <= 0 || days <= 0 || d.InterestRate == 0 {
+		return 0
+	}
+	return Money(math.Round(float64(principal) * d.InterestRate / 100 * float64(days) / 365))
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDebtAmortize(t *testing.T) {
+	tests := []struct {
+		name string
+		debt Debt
+		want []AmortizationRow
+	}{
+		{
+			name: "no schedule",
+			debt: Debt{Principal: 100000, InterestRate: 12, Schedule: DebtScheduleNone, TermMonths: intPtr(3), StartDate: date(2024, 1, 15)},
+			want: nil,
+		},
+		{
+			name: "no term",
+			debt: Debt{Principal: 100000, InterestRate: 12, Schedule: DebtScheduleAnnuity, StartDate: date(2024, 1, 15)},
+			want: nil,
+		},
+		{
+			name: "zero term",
+			debt: Debt{Principal: 100000, InterestRate: 12, Schedule: DebtScheduleAnnuity, TermMonths: intPtr(0), StartDate: date(2024, 1, 15)},
+			want: nil,
+		},
+		{
+			name: "annuity",
+			debt: Debt{Principal: 100000, InterestRate: 12, Schedule: DebtScheduleAnnuity, TermMonths: intPtr(3), StartDate: date(2024, 1, 15)},
+			// 1000.00 at 1% a month: 340.02 a month, the last payment takes the rounding
+			want: []AmortizationRow{
+				{Number: 1, Date: date(2024, 2, 15), Payment: 34002, Principal: 33002, Interest: 1000, Balance: 66998},
+				{Number: 2, Date: date(2024, 3, 15), Payment: 34002, Principal: 33332, Interest: 670, Balance: 33666},
+				{Number: 3, Date: date(2024, 4, 15), Payment: 34003, Principal: 33666, Interest: 337, Balance: 0},
+			},
+		},
+		{
+			name: "annuity without interest",
+			debt: Debt{Principal: 10000, Schedule: DebtScheduleAnnuity, TermMonths: intPtr(3), StartDate: date(2024, 1, 31)},
+			// 33.34 a month rounded up, so the last payment is smaller
+			want: []AmortizationRow{
+				{Number: 1, Date: date(2024, 2, 29), Payment: 3334, Principal: 3334, Interest: 0, Balance: 6666},
+				{Number: 2, Date: date(2024, 3, 31), Payment: 3334, Principal: 3334, Interest: 0, Balance: 3332},
+				{Number: 3, Date: date(2024, 4, 30), Payment: 3332, Principal: 3332, Interest: 0, Balance: 0},
+			},
+		},
+		{
+			name: "differentiated",
+			debt: Debt{Principal: 90000, InterestRate: 12, Schedule: DebtScheduleDifferentiated, TermMonths: intPtr(3), StartDate: date(2024, 1, 15)},
+			want: []AmortizationRow{
+				{Number: 1, Date: date(2024, 2, 15), Payment: 30900, Principal: 30000, Interest: 900, Balance: 60000},
+				{Number: 2, Date: date(2024, 3, 15), Payment: 30600, Principal: 30000, Interest: 600, Balance: 30000},
+				{Number: 3, Date: date(2024, 4, 15), Payment: 30300, Principal: 30000, Interest: 300, Balance: 0},
+			},
+		},
+		{
+			name: "differentiated with a remainder",
+			debt: Debt{Principal: 10000, Schedule: DebtScheduleDifferentiated, TermMonths: intPtr(3), StartDate: date(2024, 1, 15)},
+			want: []AmortizationRow{
+				{Number: 1, Date: date(2024, 2, 15), Payment: 3333, Principal: 3333, Interest: 0, Balance: 6667},
+				{Number: 2, Date: date(2024, 3, 15), Payment: 3333, Principal: 3333, Interest: 0, Balance: 3334},
+				{Number: 3, Date: date(2024, 4, 15), Payment: 3334, Principal: 3334, Interest: 0, Balance: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.debt.Amortize()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Amortize() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDebtAmortizePaysOff checks longer schedules add up: every payment is its
+// principal plus interest and the principal is paid off exactly
+func TestDebtAmortizePaysOff(t *testing.T) {
+	for _, schedule := range []DebtSchedule{DebtScheduleAnnuity, DebtScheduleDifferentiated} {
+		for _, rate := range []float64{0, 7.5, 19.9} {
+			debt := Debt{Principal: 123456789, InterestRate: rate, Schedule: schedule, TermMonths: intPtr(240), StartDate: date(2024, 1, 31)}
+			rows := debt.Amortize()
+			if len(rows) != 240 {
+				t.Fatalf("%s at %v%%: %d rows, want 240", schedule, rate, len(rows))
+			}
+
+			var paid Money
+			balance := debt.Principal
+			for _, row := range rows {
+				if row.Payment != row.Principal+row.Interest {
+					t.Errorf("%s at %v%% row %d: payment %v is not principal %v plus interest %v", schedule, rate, row.Number, row.Payment, row.Principal, row.Interest)
+				}
+				balance -= row.Principal
+				if row.Balance != balance || row.Principal < 0 || row.Interest < 0 {
+					t.Errorf("%s at %v%% row %d: %+v, balance want %v", schedule, rate, row.Number, row, balance)
+				}
+				paid += row.Principal
+			}
+			if paid != debt.Principal || rows[len(rows)-1].Balance != 0 {
+				t.Errorf("%s at %v%%: paid %v of %v, left %v", schedule, rate, paid, debt.Principal, rows[len(rows)-1].Balance)
+			}
+		}
+	}
+}
+
+func TestDebtAccruedInterest(t *testing.T) {
+	debt := Debt{InterestRate: 36.5}
+	tests := []struct {
+		principal Money
+		days      int
+		want      Money
+	}{
+		{principal: 100000, days: 1, want: 100},
+		{principal: 100000, days: 10, want: 1000},
+		{principal: 12345, days: 1, want: 12},
+		{principal: 100000, days: 0, want: 0},
+		{principal: 0, days: 30, want: 0},
+		{principal: -100, days: 30, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := debt.AccruedInterest(tt.principal, tt.days); got != tt.want {
+			t.Errorf("AccruedInterest(%v, %d) = %v, want %v", tt.principal, tt.days, got, tt.want)
+		}
+	}
+}
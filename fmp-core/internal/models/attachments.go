@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment is a receipt photo or document kept with a transaction or a
+// planned expense. The contents live in the blob store under StorageKey.
+type Attachment struct {
+	ID               uuid.UUID  `json:"id" db:"id"`
+	UserID           uuid.UUID  `json:"user_id" db:"user_id"`
+	TransactionID    *uuid.UUID `json:"transaction_id,omitempty" db:"transaction_id"`
+	PlannedExpenseID *uuid.UUID `json:"planned_expense_id,omitempty" db:"planned_expense_id"`
+	FileName         string     `json:"file_name" db:"file_name"`
+	ContentType      string     `json:"content_type" db:"content_type"`
+	Size             int64      `json:"size" db:"size"`
+	StorageKey       string     `json:"-" db:"storage_key"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BudgetRole is what a member may do in a budget
+type BudgetRole string
+
+const (
+	// BudgetRoleOwner manages the budget, its members and invites
+	BudgetRoleOwner BudgetRole = "owner"
+	// BudgetRoleEditor changes the data of the budget
+	BudgetRoleEditor BudgetRole = "editor"
+	// BudgetRoleViewer only reads it
+	BudgetRoleViewer BudgetRole = "viewer"
+)
+
+// Allows tells whether the role grants at least the rights of required
+func (r BudgetRole) Allows(required BudgetRole) bool {
+	rank := map[BudgetRole]int{BudgetRoleViewer: 1, BudgetRoleEditor: 2, BudgetRoleOwner: 3}
+	return rank[r] >= rank[required]
+}
+
+// Budget owns categories, accounts, transactions, limits and the rest of the
+// data. Every user has a personal budget and can share others with members.
+type Budget struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	IsPersonal bool      `json:"is_personal" db:"is_personal"`
+	// Role is the role of the current user in the budget
+	Role      BudgetRole `json:"role"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CreateBudgetRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+type BudgetMember struct {
+	BudgetID   uuid.UUID  `json:"budget_id" db:"budget_id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	TelegramID *int64     `json:"telegram_id,omitempty" db:"telegram_id"`
+	Role       BudgetRole `json:"role" db:"role"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type UpdateBudgetMemberRequest struct {
+	Role BudgetRole `json:"role" binding:"required,oneof=owner editor viewer"`
+}
+
+// BudgetInvite lets whoever opens the bot with StartParameter join the
+// budget with Role, once and before ExpiresAt
+type BudgetInvite struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	BudgetID       uuid.UUID  `json:"budget_id" db:"budget_id"`
+	Token          string     `json:"token" db:"token"`
+	StartParameter string     `json:"start_parameter"`
+	Role           BudgetRole `json:"role" db:"role"`
+	CreatedBy      uuid.UUID  `json:"created_by" db:"created_by"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedBy     *uuid.UUID `json:"accepted_by,omitempty" db:"accepted_by"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateBudgetInviteRequest defaults to an editor invite valid for 7 days
+type CreateBudgetInviteRequest struct {
+	Role    BudgetRole `json:"role" binding:"omitempty,oneof=editor viewer"`
+	TTLDays int        `json:"ttl_days" binding:"omitempty,min=1,max=30"`
+}
+
+type AcceptBudgetInviteRequest struct {
+	Token string `json:"token" binding:"required,max=64"`
+}
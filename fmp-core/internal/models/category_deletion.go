@@ -0,0 +1,34 @@
+package models
+
+import "github.com/google/uuid"
+
+// CategoryUsage counts what refers to a category. Only rows that are not in
+// the trash count.
+type CategoryUsage struct {
+	Subcategories     int `json:"subcategories"`
+	Transactions      int `json:"transactions"`
+	TransactionSplits int `json:"transaction_splits"`
+	PlannedExpenses   int `json:"planned_expenses"`
+	RecurringExpenses int `json:"recurring_expenses"`
+	CategoryLimits    int `json:"category_limits"`
+	LimitTemplates    int `json:"limit_templates"`
+	PlannedIncomes    int `json:"planned_incomes"`
+	Goals             int `json:"goals"`
+	Debts             int `json:"debts"`
+	Rules             int `json:"rules"`
+}
+
+func (u CategoryUsage) Total() int {
+	return u.Subcategories + u.Transactions + u.TransactionSplits + u.PlannedExpenses + u.RecurringExpenses +
+		u.CategoryLimits + u.LimitTemplates + u.PlannedIncomes + u.Goals + u.Debts + u.Rules
+}
+
+// CategoryReassignment reports what deleting a category moved to ReassignedTo.
+// Limits for months the other category already has a limit for, and the limit
+// template when it already has one, are not moved and are deleted with the
+// category, Trashed counts them.
+type CategoryReassignment struct {
+	ReassignedTo uuid.UUID     `json:"reassigned_to"`
+	Moved        CategoryUsage `json:"moved"`
+	Trashed      CategoryUsage `json:"trashed"`
+}
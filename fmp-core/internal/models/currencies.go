@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCurrency is used when an amount comes without a currency code and
+// as the default reporting currency
+const DefaultCurrency = "RUB"
+
+// ExchangeRate says that one unit of BaseCurrency costs Rate units of
+// QuoteCurrency on Date. Rates keep their full precision, so they are not Money.
+type ExchangeRate struct {
+	ID            uuid.UUID   `json:"id" db:"id"`
+	UserID        uuid.UUID   `json:"user_id" db:"user_id"`
+	BaseCurrency  string      `json:"base_currency" db:"base_currency"`
+	QuoteCurrency string      `json:"quote_currency" db:"quote_currency"`
+	Rate          json.Number `json:"rate" db:"rate"`
+	Date          time.Time   `json:"date" db:"date"`
+	CreatedAt     time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+type CreateExchangeRateRequest struct {
+	BaseCurrency  string      `json:"base_currency" binding:"required,iso4217"`
+	QuoteCurrency string      `json:"quote_currency" binding:"required,iso4217"`
+	Rate          json.Number `json:"rate" binding:"required"`
+	Date          time.Time   `json:"date" binding:"required"`
+}
+
+type ExchangeRateFilters struct {
+	BaseCurrency  *string    `json:"base_currency,omitempty"`
+	QuoteCurrency *string    `json:"quote_currency,omitempty"`
+	StartDate     *time.Time `json:"start_date,omitempty"`
+	EndDate       *time.Time `json:"end_date,omitempty"`
+}
+
+type ExchangeRateImportResult struct {
+	Imported int `json:"imported"`
+}
+
+// IsCurrencyCode reports whether code looks like an ISO 4217 code such as "EUR"
+func IsCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Counterparty is someone money is lent to or borrowed from, a friend or a bank
+type Counterparty struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	Note      string    `json:"note" db:"note"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateCounterpartyRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+	Note string `json:"note"`
+}
+
+// DebtDirection tells who owes whom
+type DebtDirection string
+
+const (
+	// DebtDirectionLent is money the counterparty owes the user
+	DebtDirectionLent DebtDirection = "lent"
+	// DebtDirectionBorrowed is money the user owes the counterparty
+	DebtDirectionBorrowed DebtDirection = "borrowed"
+)
+
+// DebtSchedule is how a debt is paid off
+type DebtSchedule string
+
+const (
+	// DebtScheduleNone is paid back whenever, like a loan between friends
+	DebtScheduleNone DebtSchedule = "none"
+	// DebtScheduleAnnuity is paid off in equal monthly payments
+	DebtScheduleAnnuity DebtSchedule = "annuity"
+	// DebtScheduleDifferentiated pays off equal parts of the principal every
+	// month plus the interest on what is left
+	DebtScheduleDifferentiated DebtSchedule = "differentiated"
+)
+
+// Debt is a loan given or taken. InterestRate is a yearly percentage.
+type Debt struct {
+	ID             uuid.UUID     `json:"id" db:"id"`
+	UserID         uuid.UUID     `json:"user_id" db:"user_id"`
+	CounterpartyID uuid.UUID     `json:"counterparty_id" db:"counterparty_id"`
+	Direction      DebtDirection `json:"direction" db:"direction"`
+	Name           string        `json:"name" db:"name"`
+	Principal      Money         `json:"principal" db:"principal"`
+	Currency       string        `json:"currency" db:"currency"`
+	InterestRate   float64       `json:"interest_rate" db:"interest_rate"`
+	Schedule       DebtSchedule  `json:"schedule" db:"schedule"`
+	TermMonths     *int          `json:"term_months,omitempty" db:"term_months"`
+	StartDate      time.Time     `json:"start_date" db:"start_date"`
+	// AccountID and CategoryID are used for the transactions of payments
+	AccountID  *uuid.UUID `json:"account_id,omitempty" db:"account_id"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty" db:"category_id"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreateDebtRequest needs TermMonths for debts with a payment schedule
+type CreateDebtRequest struct {
+	CounterpartyID uuid.UUID     `json:"counterparty_id" binding:"required"`
+	Direction      DebtDirection `json:"direction" binding:"required,oneof=lent borrowed"`
+	Name           string        `json:"name" binding:"required,max=255"`
+	Principal      Money         `json:"principal" binding:"required,gt=0"`
+	Currency       string        `json:"currency" binding:"omitempty,iso4217"`
+	InterestRate   float64       `json:"interest_rate" binding:"gte=0,lte=1000"`
+	Schedule       DebtSchedule  `json:"schedule" binding:"omitempty,oneof=none annuity differentiated"`
+	TermMonths     *int          `json:"term_months" binding:"omitempty,gt=0,lte=1200"`
+	StartDate      time.Time     `json:"start_date"`
+	AccountID      *uuid.UUID    `json:"account_id"`
+	CategoryID     *uuid.UUID    `json:"category_id"`
+}
+
+type DebtFilters struct {
+	CounterpartyID *uuid.UUID     `json:"counterparty_id,omitempty"`
+	Direction      *DebtDirection `json:"direction,omitempty"`
+}
+
+// DebtPayment pays off part of a debt. PrincipalAmount and InterestAmount add
+// up to Amount.
+type DebtPayment struct {
+	ID              uuid.UUID `json:"id" db:"id"`
+	UserID          uuid.UUID `json:"user_id" db:"user_id"`
+	DebtID          uuid.UUID `json:"debt_id" db:"debt_id"`
+	Amount          Money     `json:"amount" db:"amount"`
+	PrincipalAmount Money     `json:"principal_amount" db:"principal_amount"`
+	InterestAmount  Money     `json:"interest_amount" db:"interest_amount"`
+	Date            time.Time `json:"date" db:"date"`
+	Note            string    `json:"note" db:"note"`
+	// TransactionID is the transaction recorded for the payment
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty" db:"transaction_id"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreateDebtPaymentRequest pays the interest accrued since the last payment
+// first unless InterestAmount says otherwise. With CreateTransaction the
+// payment is also recorded as an income for lent debts and an expense for
+// borrowed ones, on the debt's account and category unless given here.
+type CreateDebtPaymentRequest struct {
+	Amount            Money      `json:"amount" binding:"required,gt=0"`
+	InterestAmount    *Money     `json:"interest_amount" binding:"omitempty,gte=0,ltefield=Amount"`
+	Date              time.Time  `json:"date"`
+	Note              string     `json:"note"`
+	CreateTransaction bool       `json:"create_transaction"`
+	AccountID         *uuid.UUID `json:"account_id"`
+	CategoryID        *uuid.UUID `json:"category_id"`
+}
+
+// DebtBalance is what is left of a debt at the end of AsOf, in the debt
+// currency. AccruedInterest is the interest since the last payment.
+type DebtBalance struct {
+	DebtID               uuid.UUID     `json:"debt_id"`
+	CounterpartyID       uuid.UUID     `json:"counterparty_id"`
+	Name                 string        `json:"name"`
+	Direction            DebtDirection `json:"direction"`
+	Currency             string        `json:"currency"`
+	Principal            Money         `json:"principal"`
+	PrincipalPaid        Money         `json:"principal_paid"`
+	InterestPaid         Money         `json:"interest_paid"`
+	OutstandingPrincipal Money         `json:"outstanding_principal"`
+	AccruedInterest      Money         `json:"accrued_interest"`
+	Outstanding          Money         `json:"outstanding"`
+	LastPaymentDate      *time.Time    `json:"last_payment_date,omitempty"`
+	// NextPayment is the next payment due by the schedule
+	NextPayment *AmortizationRow `json:"next_payment,omitempty"`
+	IsClosed    bool             `json:"is_closed"`
+	AsOf        time.Time        `json:"as_of"`
+}
+
+// AmortizationRow is one monthly payment of a schedule, Balance is the
+// principal left after it
+type AmortizationRow struct {
+	Number    int       `json:"number"`
+	Date      time.Time `json:"date"`
+	Payment   Money     `json:"payment"`
+	Principal Money     `json:"principal"`
+	Interest  Money     `json:"interest"`
+	Balance   Money     `json:"balance"`
+}
+
+type Amortization struct {
+	DebtID        uuid.UUID         `json:"debt_id"`
+	Schedule      DebtSchedule      `json:"schedule"`
+	Currency      string            `json:"currency"`
+	TotalPayment  Money             `json:"total_payment"`
+	TotalInterest Money             `json:"total_interest"`
+	Rows          []AmortizationRow `json:"rows"`
+}
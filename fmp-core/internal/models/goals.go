@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Goal is an amount saved up toward, optionally by a deadline. Besides its
+// contributions, the transactions of the linked account or category since
+// StartDate count toward it.
+type Goal struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	Name         string     `json:"name" db:"name"`
+	TargetAmount Money      `json:"target_amount" db:"target_amount"`
+	Currency     string     `json:"currency" db:"currency"`
+	StartDate    time.Time  `json:"start_date" db:"start_date"`
+	Deadline     *time.Time `json:"deadline,omitempty" db:"deadline"`
+	// AccountID counts money coming into the account as saved
+	AccountID *uuid.UUID `json:"account_id,omitempty" db:"account_id"`
+	// CategoryID counts spending in the category as saved, e.g. "Savings"
+	CategoryID *uuid.UUID `json:"category_id,omitempty" db:"category_id"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CreateGoalRequest struct {
+	Name         string     `json:"name" binding:"required,max=255"`
+	TargetAmount Money      `json:"target_amount" binding:"required,gt=0"`
+	Currency     string     `json:"currency" binding:"omitempty,iso4217"`
+	StartDate    time.Time  `json:"start_date"`
+	Deadline     *time.Time `json:"deadline"`
+	AccountID    *uuid.UUID `json:"account_id" binding:"excluded_with=CategoryID"`
+	CategoryID   *uuid.UUID `json:"category_id"`
+}
+
+type GoalContribution struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	UserID uuid.UUID `json:"user_id" db:"user_id"`
+	GoalID uuid.UUID `json:"goal_id" db:"goal_id"`
+	// Amount is negative when money was taken out of the goal
+	Amount    Money     `json:"amount" db:"amount"`
+	Currency  string    `json:"currency" db:"currency"`
+	Date      time.Time `json:"date" db:"date"`
+	Note      string    `json:"note" db:"note"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateGoalContributionRequest struct {
+	Amount   Money     `json:"amount" binding:"required"`
+	Currency string    `json:"currency" binding:"omitempty,iso4217"`
+	Date     time.Time `json:"date"`
+	Note     string    `json:"note"`
+}
+
+// GoalProgress is how far a goal got by AsOf, amounts in the goal currency.
+// AverageMonthly is what was saved per month over the last Months months,
+// ProjectedDate is when the goal is reached at that pace.
+type GoalProgress struct {
+	GoalID          uuid.UUID  `json:"goal_id"`
+	Name            string     `json:"name"`
+	Currency        string     `json:"currency"`
+	TargetAmount    Money      `json:"target_amount"`
+	Saved           Money      `json:"saved"`
+	Remaining       Money      `json:"remaining"`
+	Percent         int        `json:"percent"`
+	IsReached       bool       `json:"is_reached"`
+	Deadline        *time.Time `json:"deadline,omitempty"`
+	MonthsLeft      *int       `json:"months_left,omitempty"`
+	RequiredMonthly *Money     `json:"required_monthly,omitempty"`
+	Months          int        `json:"months"`
+	AverageMonthly  Money      `json:"average_monthly"`
+	ProjectedDate   *time.Time `json:"projected_date,omitempty"`
+	// OnTrack tells whether the projected date is not after the deadline
+	OnTrack *bool     `json:"on_track,omitempty"`
+	AsOf    time.Time `json:"as_of"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "github.com/google/uuid"
+
+// PlannedIncomeSummary compares a month's planned income sources with the
+// income received, every amount converted to Currency
+type PlannedIncomeSummary struct {
+	Month    int                   `json:"month"`
+	Year     int                   `json:"year"`
+	Currency string                `json:"currency"`
+	Sources  []IncomeSourceSummary `json:"sources"`
+	Planned  Money                 `json:"planned"`
+	// Received is the income in the categories linked to the sources
+	Received Money `json:"received"`
+}
+
+// IncomeSourceSummary is a planned income source with what came in on it.
+// Sources linked to the same category share its income: each gets it in
+// full as Received.
+type IncomeSourceSummary struct {
+	ID           uuid.UUID  `json:"id"`
+	Name         string     `json:"name"`
+	CategoryID   *uuid.UUID `json:"category_id,omitempty"`
+	CategoryName string     `json:"category_name,omitempty"`
+	Planned      Money      `json:"planned"`
+	Received     *Money     `json:"received,omitempty"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LimitTemplate is the default monthly limit of a category
+type LimitTemplate struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
+	CategoryID uuid.UUID `json:"category_id" db:"category_id"`
+	Limit      Money     `json:"limit" db:"limit"`
+	Currency   string    `json:"currency" db:"currency"`
+	Rollover   bool      `json:"rollover" db:"rollover"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateLimitTemplateRequest struct {
+	CategoryID uuid.UUID `json:"category_id" binding:"required"`
+	Limit      Money     `json:"limit" binding:"required,gt=0"`
+	Currency   string    `json:"currency" binding:"omitempty,iso4217"`
+	Rollover   bool      `json:"rollover"`
+}
+
+// LimitSource tells where generated limits come from
+type LimitSource string
+
+const (
+	LimitSourceTemplates LimitSource = "templates"
+	LimitSourcePrevious  LimitSource = "previous"
+	// LimitSourceAll takes templates first and the previous month's limits
+	// for categories without a template
+	LimitSourceAll LimitSource = "all"
+)
+
+// LimitGenerationResult lists the limits created for a month, categories
+// that already had a limit are skipped
+type LimitGenerationResult struct {
+	Month   int             `json:"month"`
+	Year    int             `json:"year"`
+	Created []CategoryLimit `json:"created"`
+}
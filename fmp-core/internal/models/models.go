@@ -6,60 +6,113 @@ import (
 	"github.com/google/uuid"
 )
 
+type User struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	TelegramID *int64    `json:"telegram_id,omitempty" db:"telegram_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
 type Category struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty" db:"parent_id"`
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	// Children is only filled in category trees
+	Children []Category `json:"children,omitempty"`
 }
 
+// TransactionType is the direction of money: amounts are always positive
+// and the type tells whether they came in or went out
+type TransactionType string
+
+const (
+	TransactionTypeExpense TransactionType = "expense"
+	TransactionTypeIncome  TransactionType = "income"
+)
+
 type Transaction struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	CategoryID  uuid.UUID `json:"category_id" db:"category_id"`
-	Amount      float64   `json:"amount" db:"amount"`
-	Description string    `json:"description" db:"description"`
-	Date        time.Time `json:"date" db:"date"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID     uuid.UUID `json:"id" db:"id"`
+	UserID uuid.UUID `json:"user_id" db:"user_id"`
+	// CategoryID is empty on the legs of a transfer
+	CategoryID  *uuid.UUID      `json:"category_id" db:"category_id"`
+	AccountID   *uuid.UUID      `json:"account_id,omitempty" db:"account_id"`
+	Type        TransactionType `json:"type" db:"type"`
+	Amount      Money           `json:"amount" db:"amount"`
+	Currency    string          `json:"currency" db:"currency"`
+	Description string          `json:"description" db:"description"`
+	// PayeeID is set by hand or matched from the description
+	PayeeID *uuid.UUID `json:"payee_id,omitempty" db:"payee_id"`
+	Date    time.Time  `json:"date" db:"date"`
+	Tags    []string   `json:"tags"`
+	// Splits spread the amount over several categories, CategoryID is then
+	// the category of the first line
+	Splits []TransactionSplit `json:"splits,omitempty"`
+	// TransferID links the two legs of a transfer between accounts
+	TransferID *uuid.UUID `json:"transfer_id,omitempty" db:"transfer_id"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 type PlannedExpense struct {
 	ID          uuid.UUID `json:"id" db:"id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
 	CategoryID  uuid.UUID `json:"category_id" db:"category_id"`
-	Amount      float64   `json:"amount" db:"amount"`
+	Amount      Money     `json:"amount" db:"amount"`
+	Currency    string    `json:"currency" db:"currency"`
 	Description string    `json:"description" db:"description"`
 	PlannedDate time.Time `json:"planned_date" db:"planned_date"`
 	IsCompleted bool      `json:"is_completed" db:"is_completed"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	Tags        []string  `json:"tags"`
+	// RecurringExpenseID is set on occurrences generated from a recurring expense
+	RecurringExpenseID *uuid.UUID `json:"recurring_expense_id,omitempty" db:"recurring_expense_id"`
+	// TransactionID is the transaction the expense was posted as
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty" db:"transaction_id"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// PlannedIncome is one income source planned for a month, a month can have
+// several of them
 type PlannedIncome struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	Amount      float64   `json:"amount" db:"amount"`
-	Description string    `json:"description" db:"description"`
-	Month       int       `json:"month" db:"month"`
-	Year        int       `json:"year" db:"year"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID     uuid.UUID `json:"id" db:"id"`
+	UserID uuid.UUID `json:"user_id" db:"user_id"`
+	Name   string    `json:"name" db:"name"`
+	// CategoryID is the income category the source is recorded in
+	CategoryID  *uuid.UUID `json:"category_id,omitempty" db:"category_id"`
+	Amount      Money      `json:"amount" db:"amount"`
+	Currency    string     `json:"currency" db:"currency"`
+	Description string     `json:"description" db:"description"`
+	Month       int        `json:"month" db:"month"`
+	Year        int        `json:"year" db:"year"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 type CategoryLimit struct {
 	ID         uuid.UUID `json:"id" db:"id"`
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
 	CategoryID uuid.UUID `json:"category_id" db:"category_id"`
-	Limit      float64   `json:"limit" db:"limit"`
-	Month      int       `json:"month" db:"month"`
-	Year       int       `json:"year" db:"year"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	Limit      Money     `json:"limit" db:"limit"`
+	Currency   string    `json:"currency" db:"currency"`
+	// Rollover carries what is left of the limit, or the overspending, into
+	// the next month's limit of the category
+	Rollover  bool      `json:"rollover" db:"rollover"`
+	Month     int       `json:"month" db:"month"`
+	Year      int       `json:"year" db:"year"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type LimitExceeded struct {
 	ID         uuid.UUID `json:"id" db:"id"`
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
 	CategoryID uuid.UUID `json:"category_id" db:"category_id"`
-	Limit      float64   `json:"limit" db:"limit"`
-	Actual     float64   `json:"actual" db:"actual"`
+	Limit      Money     `json:"limit" db:"limit"`
+	Actual     Money     `json:"actual" db:"actual"`
 	Month      int       `json:"month" db:"month"`
 	Year       int       `json:"year" db:"year"`
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
@@ -67,70 +120,135 @@ type LimitExceeded struct {
 
 // Request/Response DTOs
 type CreateCategoryRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	Name        string     `json:"name" binding:"required"`
+	Description string     `json:"description"`
 }
 
 type CreateTransactionRequest struct {
-	CategoryID  uuid.UUID `json:"category_id" binding:"required"`
-	Amount      float64   `json:"amount" binding:"required"`
-	Description string    `json:"description"`
-	Date        time.Time `json:"date"`
+	// CategoryID may be left out on create for the rules to fill in
+	CategoryID  uuid.UUID       `json:"category_id"`
+	AccountID   *uuid.UUID      `json:"account_id"`
+	Type        TransactionType `json:"type" binding:"omitempty,oneof=income expense"`
+	Amount      Money           `json:"amount" binding:"required,gt=0"`
+	Currency    string          `json:"currency" binding:"omitempty,iso4217"`
+	Description string          `json:"description"`
+	// PayeeID is matched from the description when left out
+	PayeeID *uuid.UUID `json:"payee_id"`
+	Date    time.Time  `json:"date"`
+	// Tags replace the current ones, leaving them out keeps them on update
+	Tags []string `json:"tags" binding:"omitempty,dive,required,max=100"`
+	// Splits must add up to Amount. They replace the current ones on update,
+	// leaving them out puts the whole amount back into CategoryID.
+	Splits []TransactionSplitRequest `json:"splits" binding:"omitempty,dive"`
 }
 
 type CreatePlannedExpenseRequest struct {
 	CategoryID  uuid.UUID `json:"category_id" binding:"required"`
-	Amount      float64   `json:"amount" binding:"required"`
+	Amount      Money     `json:"amount" binding:"required"`
+	Currency    string    `json:"currency" binding:"omitempty,iso4217"`
 	Description string    `json:"description"`
 	PlannedDate time.Time `json:"planned_date" binding:"required"`
+	// Tags replace the current ones, leaving them out keeps them on update
+	Tags []string `json:"tags" binding:"omitempty,dive,required,max=100"`
 }
 
 type CreatePlannedIncomeRequest struct {
-	Amount      float64 `json:"amount" binding:"required"`
-	Description string  `json:"description"`
-	Month       int     `json:"month" binding:"required"`
-	Year        int     `json:"year" binding:"required"`
+	// Name defaults to the description
+	Name        string     `json:"name" binding:"max=255"`
+	CategoryID  *uuid.UUID `json:"category_id"`
+	Amount      Money      `json:"amount" binding:"required"`
+	Currency    string     `json:"currency" binding:"omitempty,iso4217"`
+	Description string     `json:"description"`
+	Month       int        `json:"month" binding:"required"`
+	Year        int        `json:"year" binding:"required"`
 }
 
 type CreateCategoryLimitRequest struct {
 	CategoryID uuid.UUID `json:"category_id" binding:"required"`
-	Limit      float64   `json:"limit" binding:"required"`
+	Limit      Money     `json:"limit" binding:"required"`
+	Currency   string    `json:"currency" binding:"omitempty,iso4217"`
+	Rollover   bool      `json:"rollover"`
 	Month      int       `json:"month" binding:"required"`
 	Year       int       `json:"year" binding:"required"`
 }
 
+// MonthlySummary has every amount converted to Currency at the rate of the
+// transaction date
 type MonthlySummary struct {
 	Month      int               `json:"month"`
 	Year       int               `json:"year"`
+	Currency   string            `json:"currency"`
 	Categories []CategorySummary `json:"categories"`
-	Total      float64           `json:"total"`
+	// Total is the month's spending, same as Expenses
+	Total    Money `json:"total"`
+	Income   Money `json:"income"`
+	Expenses Money `json:"expenses"`
+	Net      Money `json:"net"`
+	// PlannedIncome adds up the month's planned income sources
+	PlannedIncome Money `json:"planned_income"`
 }
 
+// CategorySummary rolls up subcategories: Amount, Income and Net include the
+// whole subtree, and so does Limit when the category has no limit of its own
 type CategorySummary struct {
-	CategoryID   uuid.UUID `json:"category_id"`
-	CategoryName string    `json:"category_name"`
-	Amount       float64   `json:"amount"`
-	Limit        *float64  `json:"limit,omitempty"`
-	IsExceeded   bool      `json:"is_exceeded"`
+	CategoryID   uuid.UUID  `json:"category_id"`
+	ParentID     *uuid.UUID `json:"parent_id,omitempty"`
+	CategoryName string     `json:"category_name"`
+	// Amount is the spending in the category, limits apply to it
+	Amount Money `json:"amount"`
+	Income Money `json:"income"`
+	Net    Money `json:"net"`
+	// Limit is the effective limit, BaseLimit plus what Carried over from the
+	// previous month with rollover
+	Limit      *Money `json:"limit,omitempty"`
+	BaseLimit  *Money `json:"base_limit,omitempty"`
+	Carried    *Money `json:"carried,omitempty"`
+	IsExceeded bool   `json:"is_exceeded"`
 }
 
 // Filter types
 type TransactionFilters struct {
-	CategoryID *uuid.UUID `json:"category_id,omitempty"`
-	StartDate  *time.Time `json:"start_date,omitempty"`
-	EndDate    *time.Time `json:"end_date,omitempty"`
+	CategoryID *uuid.UUID       `json:"category_id,omitempty"`
+	AccountID  *uuid.UUID       `json:"account_id,omitempty"`
+	Type       *TransactionType `json:"type,omitempty"`
+	PayeeID    *uuid.UUID       `json:"payee_id,omitempty"`
+	// Query is a full-text search, results are then ordered by rank
+	Query string `json:"q,omitempty"`
+	// Tags matches transactions that carry all of them
+	Tags      []string   `json:"tags,omitempty"`
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+	// ExcludeTransfers leaves out the two transactions of every transfer
+	ExcludeTransfers bool `json:"exclude_transfers,omitempty"`
+	// Sort orders by date or by when the transactions were recorded, newest
+	// first, when there is no Query
+	Sort TransactionSort `json:"sort,omitempty"`
+	// Limit caps the number of transactions, all of them when 0
+	Limit int `json:"limit,omitempty"`
 }
 
+type TransactionSort string
+
+const (
+	TransactionSortDate      TransactionSort = "date"
+	TransactionSortCreatedAt TransactionSort = "created_at"
+)
+
 type PlannedExpenseFilters struct {
-	CategoryID  *uuid.UUID `json:"category_id,omitempty"`
-	StartDate   *time.Time `json:"start_date,omitempty"`
-	EndDate     *time.Time `json:"end_date,omitempty"`
-	IsCompleted *bool      `json:"is_completed,omitempty"`
+	CategoryID         *uuid.UUID `json:"category_id,omitempty"`
+	RecurringExpenseID *uuid.UUID `json:"recurring_expense_id,omitempty"`
+	StartDate          *time.Time `json:"start_date,omitempty"`
+	EndDate            *time.Time `json:"end_date,omitempty"`
+	IsCompleted        *bool      `json:"is_completed,omitempty"`
+	// Query is a full-text search, results are then ordered by rank
+	Query string `json:"q,omitempty"`
 }
 
 type PlannedIncomeFilters struct {
-	Month *int `json:"month,omitempty"`
-	Year  *int `json:"year,omitempty"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	Month      *int       `json:"month,omitempty"`
+	Year       *int       `json:"year,omitempty"`
 }
 
 type CategoryLimitFilters struct {
@@ -151,6 +269,7 @@ const (
 
 type Notification struct {
 	ID        uuid.UUID        `json:"id" db:"id"`
+	UserID    uuid.UUID        `json:"user_id" db:"user_id"`
 	Type      NotificationType `json:"type" db:"type"`
 	Title     string           `json:"title" db:"title"`
 	Message   string           `json:"message" db:"message"`
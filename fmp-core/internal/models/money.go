@@ -0,0 +1,126 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in minor units (kopecks, cents). It is stored as
+// DECIMAL(18,2) and travels in JSON as a string with two decimals like "12.50",
+// so amounts never go through a float, not even in JavaScript clients.
+type Money int64
+
+const moneyScale = 100
+
+// ParseMoney parses a decimal string like "-1234.5" with at most two decimals
+func ParseMoney(s string) (Money, error) {
+	return parseMoney(s, false)
+}
+
+// parseMoney parses a decimal string, rounding extra decimals half away from
+// zero when round is set and rejecting them otherwise
+func parseMoney(s string, round bool) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	unsigned := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(unsigned, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("invalid money amount %q", s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	roundUp := false
+	if len(fraction) > 2 {
+		if !round {
+			return 0, fmt.Errorf("money amount %q has more than two decimals", s)
+		}
+		roundUp = fraction[2] >= '5'
+		fraction = fraction[:2]
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || strings.ContainsAny(unsigned, "+-") {
+		return 0, fmt.Errorf("invalid money amount %q", s)
+	}
+	if roundUp {
+		units++
+	}
+	if negative {
+		units = -units
+	}
+
+	return Money(units), nil
+}
+
+func (m Money) String() string {
+	sign := ""
+	units := int64(m)
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, units/moneyScale, units%moneyScale)
+}
+
+// Percent returns m as a whole percentage of total, rounded down
+func (m Money) Percent(total Money) int {
+	if total == 0 {
+		return 0
+	}
+	return int(int64(m) * 100 / int64(total))
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON accepts both a JSON number and a string, e.g. 12.5 or "12.50"
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads a DECIMAL column, rounding computed values to two decimals
+func (m *Money) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		*m = Money(v * moneyScale)
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+
+	parsed, err := parseMoney(s, true)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
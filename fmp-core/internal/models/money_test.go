@@ -0,0 +1,154 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "0", want: 0},
+		{in: "12", want: 1200},
+		{in: "12.5", want: 1250},
+		{in: "12.50", want: 1250},
+		{in: "0.01", want: 1},
+		{in: ".5", want: 50},
+		{in: "5.", want: 500},
+		{in: "-1234.56", want: -123456},
+		{in: "+7.10", want: 710},
+		{in: " 3.20 ", want: 320},
+		{in: "92233720368547758.07", want: 9223372036854775807},
+		{in: "", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: "1.005", wantErr: true},
+		{in: "1,50", wantErr: true},
+		{in: "1e3", wantErr: true},
+		{in: "--1", wantErr: true},
+		{in: "1-2", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "92233720368547758.08", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMoney(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{in: 0, want: "0.00"},
+		{in: 1, want: "0.01"},
+		{in: -1, want: "-0.01"},
+		{in: 1250, want: "12.50"},
+		{in: -123456, want: "-1234.56"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(tt.in), got, tt.want)
+		}
+		parsed, err := ParseMoney(tt.want)
+		if err != nil || parsed != tt.in {
+			t.Errorf("ParseMoney(%q) = %v, %v, want %d", tt.want, parsed, err, int64(tt.in))
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    Money
+		wantErr bool
+	}{
+		{json: `"12.50"`, want: 1250},
+		{json: `12.5`, want: 1250},
+		{json: `"-0.01"`, want: -1},
+		{json: `0`, want: 0},
+		{json: `"1.005"`, wantErr: true},
+		{json: `0.1e1`, wantErr: true},
+		{json: `true`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.json), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %v, want an error", tt.json, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, %v, want %v", tt.json, got, err, tt.want)
+			continue
+		}
+
+		// Amounts go out as strings and come back unchanged
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Fatalf("Marshal(%v) error = %v", got, err)
+		}
+		if want := `"` + tt.want.String() + `"`; string(data) != want {
+			t.Errorf("Marshal(%v) = %s, want %s", got, data, want)
+		}
+		var back Money
+		if err := json.Unmarshal(data, &back); err != nil || back != got {
+			t.Errorf("Unmarshal(Marshal(%v)) = %v, %v", got, back, err)
+		}
+	}
+
+	// null leaves the amount alone, like for other types
+	got := Money(500)
+	if err := json.Unmarshal([]byte(`null`), &got); err != nil || got != 500 {
+		t.Errorf("Unmarshal(null) = %v, %v, want 5.00 untouched", got, err)
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    Money
+		wantErr bool
+	}{
+		{src: []byte("12.50"), want: 1250},
+		{src: "12.5", want: 1250},
+		{src: int64(3), want: 300},
+		// Computed values are rounded half away from zero
+		{src: "0.125", want: 13},
+		{src: "-0.125", want: -13},
+		{src: "0.124999", want: 12},
+		{src: 1.5, wantErr: true},
+		{src: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var got Money
+		err := got.Scan(tt.src)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Scan(%#v) = %v, want an error", tt.src, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Scan(%#v) = %v, %v, want %v", tt.src, got, err, tt.want)
+		}
+	}
+}
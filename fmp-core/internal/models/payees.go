@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payee is who a transaction was paid to or received from. Transactions
+// whose description contains its name or one of its aliases are matched to it.
+type Payee struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	Aliases   []string  `json:"aliases"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreatePayeeRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+	// Aliases replace the current ones, leaving them out keeps them on update
+	Aliases []string `json:"aliases" binding:"omitempty,dive,required,max=255"`
+}
+
+// PayeeSummary totals the transactions of a payee over a period, converted to
+// the reporting currency at the rate of each transaction's date
+type PayeeSummary struct {
+	PayeeID   uuid.UUID `json:"payee_id"`
+	PayeeName string    `json:"payee_name"`
+	Amount    Money     `json:"amount"`
+	// Visits counts the transactions with the payee
+	Visits        int       `json:"visits"`
+	AverageAmount Money     `json:"average_amount"`
+	LastDate      time.Time `json:"last_date"`
+}
+
+type PayeeSummaryFilters struct {
+	Type      TransactionType `json:"type"`
+	StartDate *time.Time      `json:"start_date,omitempty"`
+	EndDate   *time.Time      `json:"end_date,omitempty"`
+	// SortBy is amount or visits
+	SortBy string `json:"sort_by"`
+	Limit  int    `json:"limit"`
+}
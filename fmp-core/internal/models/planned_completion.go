@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CompletePlannedExpenseRequest settles a planned expense. With TransactionID
+// it is linked to that transaction, otherwise a transaction is created from
+// it, Amount and Date overriding the planned ones.
+type CompletePlannedExpenseRequest struct {
+	TransactionID *uuid.UUID `json:"transaction_id"`
+	AccountID     *uuid.UUID `json:"account_id"`
+	Amount        Money      `json:"amount" binding:"omitempty,gt=0"`
+	Date          *time.Time `json:"date"`
+}
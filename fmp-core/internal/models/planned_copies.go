@@ -0,0 +1,25 @@
+package models
+
+// CopyPlannedIncomeRequest copies a month's planned income, and with
+// IncludeExpenses its planned expenses, into another month. The source month
+// defaults to the one before the target month.
+type CopyPlannedIncomeRequest struct {
+	FromMonth       int  `json:"from_month" binding:"omitempty,min=1,max=12"`
+	FromYear        int  `json:"from_year"`
+	ToMonth         int  `json:"to_month" binding:"required,min=1,max=12"`
+	ToYear          int  `json:"to_year" binding:"required"`
+	IncludeExpenses bool `json:"include_expenses"`
+}
+
+// PlannedCopyResult lists what a copy created. Entries copied before are
+// not copied again, so repeating a copy creates nothing.
+type PlannedCopyResult struct {
+	FromMonth int              `json:"from_month"`
+	FromYear  int              `json:"from_year"`
+	ToMonth   int              `json:"to_month"`
+	ToYear    int              `json:"to_year"`
+	Income    []PlannedIncome  `json:"income"`
+	Expenses  []PlannedExpense `json:"expenses"`
+	// IncomeTotals sums the copied income per currency
+	IncomeTotals map[string]Money `json:"income_totals"`
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "daily"
+	FrequencyWeekly  Frequency = "weekly"
+	FrequencyMonthly Frequency = "monthly"
+	FrequencyYearly  Frequency = "yearly"
+)
+
+// Recurrence is the part of an RFC 5545 RRULE that fmp-core understands:
+// FREQ, INTERVAL and at most one of COUNT and UNTIL. Monthly and yearly
+// occurrences that fall on a day the month does not have, like the 31st,
+// move to the last day of that month.
+type Recurrence struct {
+	Frequency Frequency  `json:"frequency" binding:"omitempty,oneof=daily weekly monthly yearly"`
+	Interval  int        `json:"interval" binding:"omitempty,min=1"`
+	Count     *int       `json:"count,omitempty" binding:"omitempty,min=1"`
+	Until     *time.Time `json:"until,omitempty"`
+}
+
+func (r Recurrence) Validate() error {
+	switch r.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+	case "":
+		return fmt.Errorf("recurrence frequency is required")
+	default:
+		return fmt.Errorf("invalid recurrence frequency %q", r.Frequency)
+	}
+	if r.Interval < 1 {
+		return fmt.Errorf("recurrence interval must be at least 1")
+	}
+	if r.Count != nil && *r.Count < 1 {
+		return fmt.Errorf("recurrence count must be at least 1")
+	}
+	if r.Count != nil && r.Until != nil {
+		return fmt.Errorf("recurrence can have either a count or an end date, not both")
+	}
+	return nil
+}
+
+// RRule formats the recurrence as an RRULE value, e.g. "FREQ=MONTHLY;INTERVAL=1;COUNT=12"
+func (r Recurrence) RRule() string {
+	rule := fmt.Sprintf("FREQ=%s;INTERVAL=%d", strings.ToUpper(string(r.Frequency)), r.Interval)
+	if r.Count != nil {
+		rule += fmt.Sprintf(";COUNT=%d", *r.Count)
+	}
+	if r.Until != nil {
+		rule += ";UNTIL=" + r.Until.Format("20060102")
+	}
+	return rule
+}
+
+// ParseRRule reads an RRULE value with or without the "RRULE:" prefix.
+// Parts other than FREQ, INTERVAL, COUNT and UNTIL are rejected rather than
+// silently ignored.
+func ParseRRule(s string) (Recurrence, error) {
+	r := Recurrence{Interval: 1}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Recurrence{}, fmt.Errorf("invalid RRULE part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Frequency = Frequency(strings.ToLower(value))
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil {
+				return Recurrence{}, fmt.Errorf("invalid RRULE interval %q", value)
+			}
+			r.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil {
+				return Recurrence{}, fmt.Errorf("invalid RRULE count %q", value)
+			}
+			r.Count = &count
+		case "UNTIL":
+			until, err := time.Parse("20060102", value)
+			if err != nil {
+				until, err = time.Parse("20060102T150405Z", value)
+			}
+			if err != nil {
+				return Recurrence{}, fmt.Errorf("invalid RRULE end date %q", value)
+			}
+			r.Until = &until
+		default:
+			return Recurrence{}, fmt.Errorf("unsupported RRULE part %q", key)
+		}
+	}
+
+	if err := r.Validate(); err != nil {
+		return Recurrence{}, err
+	}
+	return r, nil
+}
+
+// Occurrence returns the date of the n-th occurrence, counting from 0 at start
+func (r Recurrence) Occurrence(start time.Time, n int) time.Time {
+	steps := n * r.Interval
+	switch r.Frequency {
+	case FrequencyDaily:
+		return start.AddDate(0, 0, steps)
+	case FrequencyWeekly:
+		return start.AddDate(0, 0, 7*steps)
+	case FrequencyMonthly:
+		return addMonthsClamped(start, steps)
+	default:
+		return addMonthsClamped(start, 12*steps)
+	}
+}
+
+// Between returns the occurrences after from and up to and including to
+func (r Recurrence) Between(start, from, to time.Time) []time.Time {
+	var dates []time.Time
+	for n := 0; r.Count == nil || n < *r.Count; n++ {
+		date := r.Occurrence(start, n)
+		if date.After(to) || (r.Until != nil && date.After(*r.Until)) {
+			break
+		}
+		if date.After(from) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestParseRRule(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Recurrence
+		wantErr string
+	}{
+		{in: "FREQ=DAILY", want: Recurrence{Frequency: FrequencyDaily, Interval: 1}},
+		{in: "RRULE:FREQ=WEEKLY;INTERVAL=2", want: Recurrence{Frequency: FrequencyWeekly, Interval: 2}},
+		{in: " freq=monthly;count=12 ", want: Recurrence{Frequency: FrequencyMonthly, Interval: 1, Count: intPtr(12)}},
+		{in: "FREQ=YEARLY;UNTIL=20301231", want: Recurrence{Frequency: FrequencyYearly, Interval: 1, Until: timePtr(date(2030, 12, 31))}},
+		{in: "FREQ=MONTHLY;UNTIL=20300115T120000Z", want: Recurrence{Frequency: FrequencyMonthly, Interval: 1, Until: timePtr(time.Date(2030, 1, 15, 12, 0, 0, 0, time.UTC))}},
+		{in: "", wantErr: "invalid RRULE part"},
+		{in: "FREQ", wantErr: "invalid RRULE part"},
+		{in: "INTERVAL=2", wantErr: "recurrence frequency is required"},
+		{in: "FREQ=HOURLY", wantErr: "invalid recurrence frequency"},
+		{in: "FREQ=DAILY;INTERVAL=0", wantErr: "interval must be at least 1"},
+		{in: "FREQ=DAILY;INTERVAL=x", wantErr: "invalid RRULE interval"},
+		{in: "FREQ=DAILY;COUNT=0", wantErr: "count must be at least 1"},
+		{in: "FREQ=DAILY;COUNT=x", wantErr: "invalid RRULE count"},
+		{in: "FREQ=DAILY;UNTIL=2030-12-31", wantErr: "invalid RRULE end date"},
+		{in: "FREQ=DAILY;COUNT=3;UNTIL=20301231", wantErr: "either a count or an end date"},
+		{in: "FREQ=MONTHLY;BYMONTHDAY=15", wantErr: "unsupported RRULE part"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRRule(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseRRule(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRRule(%q) error = %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseRRule(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+
+		// Formatting and parsing again gives the same rule, UNTIL as a date
+		again, err := ParseRRule(got.RRule())
+		if err != nil || again.RRule() != got.RRule() {
+			t.Errorf("ParseRRule(%q) = %+v, %v, want %+v", got.RRule(), again, err, got)
+		}
+	}
+}
+
+func TestRecurrenceBetween(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		start      time.Time
+		from       time.Time
+		to         time.Time
+		want       []time.Time
+	}{
+		{
+			name:       "daily from the start",
+			recurrence: Recurrence{Frequency: FrequencyDaily, Interval: 1},
+			start:      date(2024, 1, 1),
+			from:       date(2023, 12, 31),
+			to:         date(2024, 1, 3),
+			want:       []time.Time{date(2024, 1, 1), date(2024, 1, 2), date(2024, 1, 3)},
+		},
+		{
+			name:       "from is excluded and to included",
+			recurrence: Recurrence{Frequency: FrequencyDaily, Interval: 1},
+			start:      date(2024, 1, 1),
+			from:       date(2024, 1, 2),
+			to:         date(2024, 1, 4),
+			want:       []time.Time{date(2024, 1, 3), date(2024, 1, 4)},
+		},
+		{
+			name:       "every other week",
+			recurrence: Recurrence{Frequency: FrequencyWeekly, Interval: 2},
+			start:      date(2024, 1, 1),
+			from:       date(2023, 12, 31),
+			to:         date(2024, 2, 1),
+			want:       []time.Time{date(2024, 1, 1), date(2024, 1, 15), date(2024, 1, 29)},
+		},
+		{
+			name:       "monthly on the 31st clamps to the end of shorter months",
+			recurrence: Recurrence{Frequency: FrequencyMonthly, Interval: 1},
+			start:      date(2024, 1, 31),
+			from:       date(2024, 1, 30),
+			to:         date(2024, 5, 31),
+			want:       []time.Time{date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 31), date(2024, 4, 30), date(2024, 5, 31)},
+		},
+		{
+			name:       "yearly on a leap day",
+			recurrence: Recurrence{Frequency: FrequencyYearly, Interval: 1},
+			start:      date(2024, 2, 29),
+			from:       date(2024, 2, 28),
+			to:         date(2028, 3, 1),
+			want:       []time.Time{date(2024, 2, 29), date(2025, 2, 28), date(2026, 2, 28), date(2027, 2, 28), date(2028, 2, 29)},
+		},
+		{
+			name:       "count limits the occurrences, also the ones already generated",
+			recurrence: Recurrence{Frequency: FrequencyMonthly, Interval: 1, Count: intPtr(3)},
+			start:      date(2024, 1, 10),
+			from:       date(2024, 1, 10),
+			to:         date(2025, 1, 1),
+			want:       []time.Time{date(2024, 2, 10), date(2024, 3, 10)},
+		},
+		{
+			name:       "until is inclusive",
+			recurrence: Recurrence{Frequency: FrequencyWeekly, Interval: 1, Until: timePtr(date(2024, 1, 15))},
+			start:      date(2024, 1, 1),
+			from:       date(2023, 12, 31),
+			to:         date(2024, 12, 31),
+			want:       []time.Time{date(2024, 1, 1), date(2024, 1, 8), date(2024, 1, 15)},
+		},
+		{
+			name:       "nothing before the start",
+			recurrence: Recurrence{Frequency: FrequencyDaily, Interval: 1},
+			start:      date(2024, 6, 1),
+			from:       date(2024, 1, 1),
+			to:         date(2024, 5, 31),
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.recurrence.Between(tt.start, tt.from, tt.to)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Between() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
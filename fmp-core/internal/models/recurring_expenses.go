@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecurringExpense is a rule that materializes planned expenses from
+// StartDate on. With AutoPost the planned expenses are also posted as
+// transactions on their due date.
+type RecurringExpense struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	CategoryID  uuid.UUID  `json:"category_id" db:"category_id"`
+	AccountID   *uuid.UUID `json:"account_id,omitempty" db:"account_id"`
+	Amount      Money      `json:"amount" db:"amount"`
+	Currency    string     `json:"currency" db:"currency"`
+	Description string     `json:"description" db:"description"`
+	Recurrence
+	RRule     string    `json:"rrule"`
+	StartDate time.Time `json:"start_date" db:"start_date"`
+	AutoPost  bool      `json:"auto_post" db:"auto_post"`
+	// GeneratedUntil is the last day occurrences have been materialized for
+	GeneratedUntil *time.Time `json:"generated_until,omitempty" db:"generated_until"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// CreateRecurringExpenseRequest takes the recurrence either as separate
+// fields or as an RRULE string, which wins when both are given
+type CreateRecurringExpenseRequest struct {
+	CategoryID  uuid.UUID  `json:"category_id" binding:"required"`
+	AccountID   *uuid.UUID `json:"account_id"`
+	Amount      Money      `json:"amount" binding:"required,gt=0"`
+	Currency    string     `json:"currency" binding:"omitempty,iso4217"`
+	Description string     `json:"description"`
+	Recurrence
+	RRule     string    `json:"rrule"`
+	StartDate time.Time `json:"start_date" binding:"required"`
+	AutoPost  bool      `json:"auto_post"`
+}
+
+type RecurringGenerationResult struct {
+	Planned int `json:"planned"`
+	Posted  int `json:"posted"`
+	// Failed counts the rules that could not be generated, they are retried
+	// on the next run
+	Failed int `json:"failed"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rule fills in the category and tags of a transaction whose description,
+// amount, payee and account meet all of its conditions. Amounts are compared
+// in the transaction's own currency.
+type Rule struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	UserID uuid.UUID `json:"user_id" db:"user_id"`
+	Name   string    `json:"name" db:"name"`
+	// Priority orders the rules, lower numbers run first
+	Priority            int        `json:"priority" db:"priority"`
+	IsActive            bool       `json:"is_active" db:"is_active"`
+	DescriptionContains string     `json:"description_contains,omitempty" db:"description_contains"`
+	DescriptionRegex    string     `json:"description_regex,omitempty" db:"description_regex"`
+	MinAmount           *Money     `json:"min_amount,omitempty" db:"min_amount"`
+	MaxAmount           *Money     `json:"max_amount,omitempty" db:"max_amount"`
+	PayeeID             *uuid.UUID `json:"payee_id,omitempty" db:"payee_id"`
+	AccountID           *uuid.UUID `json:"account_id,omitempty" db:"account_id"`
+	CategoryID          *uuid.UUID `json:"category_id,omitempty" db:"category_id"`
+	Tags                []string   `json:"tags"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CreateRuleRequest struct {
+	Name     string `json:"name" binding:"required,max=255"`
+	Priority int    `json:"priority"`
+	// IsActive defaults to true
+	IsActive            *bool      `json:"is_active"`
+	DescriptionContains string     `json:"description_contains" binding:"max=255"`
+	DescriptionRegex    string     `json:"description_regex" binding:"max=255"`
+	MinAmount           *Money     `json:"min_amount" binding:"omitempty,gte=0"`
+	MaxAmount           *Money     `json:"max_amount" binding:"omitempty,gte=0"`
+	PayeeID             *uuid.UUID `json:"payee_id"`
+	AccountID           *uuid.UUID `json:"account_id"`
+	CategoryID          *uuid.UUID `json:"category_id" binding:"required_without=Tags"`
+	Tags                []string   `json:"tags" binding:"omitempty,dive,required,max=100"`
+}
+
+// ApplyRulesRequest selects the transactions and the rules to run over the
+// history. Transfers and split transactions are left alone.
+type ApplyRulesRequest struct {
+	// RuleIDs limits the run to some rules, inactive ones too, all active
+	// rules when empty
+	RuleIDs   []uuid.UUID `json:"rule_ids"`
+	StartDate *time.Time  `json:"start_date"`
+	EndDate   *time.Time  `json:"end_date"`
+}
+
+// RuleMatch is a transaction a rule changes: the category it moves to, the
+// tags it gains, or both
+type RuleMatch struct {
+	TransactionID uuid.UUID  `json:"transaction_id"`
+	Description   string     `json:"description"`
+	Date          time.Time  `json:"date"`
+	Amount        Money      `json:"amount"`
+	Currency      string     `json:"currency"`
+	RuleID        uuid.UUID  `json:"rule_id"`
+	RuleName      string     `json:"rule_name"`
+	OldCategoryID *uuid.UUID `json:"old_category_id,omitempty"`
+	NewCategoryID *uuid.UUID `json:"new_category_id,omitempty"`
+	AddedTags     []string   `json:"added_tags,omitempty"`
+}
+
+type ApplyRulesResult struct {
+	DryRun bool `json:"dry_run"`
+	// Checked counts the transactions the rules ran over
+	Checked int         `json:"checked"`
+	Changes []RuleMatch `json:"changes"`
+}
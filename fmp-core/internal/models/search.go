@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of search results
+const (
+	SearchKindTransaction    = "transaction"
+	SearchKindPlannedExpense = "planned_expense"
+)
+
+// SearchResult is a transaction or a planned expense matching a search query.
+// Highlight is the matching text escaped as HTML, with the matches wrapped in
+// <mark> tags.
+type SearchResult struct {
+	Kind        string    `json:"kind"`
+	ID          uuid.UUID `json:"id"`
+	Date        time.Time `json:"date"`
+	Amount      Money     `json:"amount"`
+	Currency    string    `json:"currency"`
+	Description string    `json:"description"`
+	Highlight   string    `json:"highlight"`
+	Rank        float64   `json:"rank"`
+}
+
+type SearchFilters struct {
+	Query string `json:"q"`
+	// Kinds limits the results to some kinds, all of them when empty
+	Kinds     []string   `json:"kinds,omitempty"`
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+	Limit     int        `json:"limit"`
+}
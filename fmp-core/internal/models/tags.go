@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tag is a free-form label. Transactions and planned expenses refer to tags
+// by name, and unknown names create the tag on the fly.
+type Tag struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateTagRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// TagSummary totals the transactions carrying a tag, converted to the
+// reporting currency at the rate of each transaction's date
+type TagSummary struct {
+	TagID            uuid.UUID `json:"tag_id"`
+	TagName          string    `json:"tag_name"`
+	Amount           Money     `json:"amount"`
+	Income           Money     `json:"income"`
+	Net              Money     `json:"net"`
+	TransactionCount int       `json:"transaction_count"`
+}
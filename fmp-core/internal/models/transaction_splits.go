@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// TransactionSplit is one line of a transaction spread over several
+// categories. The lines of a transaction add up to its amount and share its
+// type, currency and date.
+type TransactionSplit struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	TransactionID uuid.UUID `json:"transaction_id" db:"transaction_id"`
+	CategoryID    uuid.UUID `json:"category_id" db:"category_id"`
+	Amount        Money     `json:"amount" db:"amount"`
+	Note          string    `json:"note" db:"note"`
+}
+
+type TransactionSplitRequest struct {
+	CategoryID uuid.UUID `json:"category_id" binding:"required"`
+	Amount     Money     `json:"amount" binding:"required,gt=0"`
+	Note       string    `json:"note"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transfer moves money between two accounts of the user. It is recorded as a
+// pair of transactions, an expense on the source account and an income on the
+// destination account, which the spending analytics and limits leave out.
+type Transfer struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	UserID        uuid.UUID `json:"user_id" db:"user_id"`
+	FromAccountID uuid.UUID `json:"from_account_id" db:"from_account_id"`
+	ToAccountID   uuid.UUID `json:"to_account_id" db:"to_account_id"`
+	// Amount leaves the source account in its currency
+	Amount   Money  `json:"amount" db:"amount"`
+	Currency string `json:"currency" db:"currency"`
+	// ToAmount arrives on the destination account in its currency
+	ToAmount    Money     `json:"to_amount" db:"to_amount"`
+	ToCurrency  string    `json:"to_currency" db:"to_currency"`
+	Description string    `json:"description" db:"description"`
+	Date        time.Time `json:"date" db:"date"`
+	// DebitTransactionID and CreditTransactionID are the two legs
+	DebitTransactionID  uuid.UUID `json:"debit_transaction_id"`
+	CreditTransactionID uuid.UUID `json:"credit_transaction_id"`
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateTransferRequest takes ToAmount for transfers between accounts in
+// different currencies. Without it the amount is converted at the rate of
+// the transfer date.
+type CreateTransferRequest struct {
+	FromAccountID uuid.UUID `json:"from_account_id" binding:"required"`
+	ToAccountID   uuid.UUID `json:"to_account_id" binding:"required,nefield=FromAccountID"`
+	Amount        Money     `json:"amount" binding:"required,gt=0"`
+	ToAmount      Money     `json:"to_amount" binding:"omitempty,gt=0"`
+	Description   string    `json:"description"`
+	Date          time.Time `json:"date"`
+}
+
+type TransferFilters struct {
+	AccountID *uuid.UUID `json:"account_id,omitempty"`
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of items in the trash
+const (
+	TrashKindCategory         = "category"
+	TrashKindAccount          = "account"
+	TrashKindTransaction      = "transaction"
+	TrashKindTransfer         = "transfer"
+	TrashKindPlannedExpense   = "planned_expense"
+	TrashKindRecurringExpense = "recurring_expense"
+	TrashKindPlannedIncome    = "planned_income"
+	TrashKindCategoryLimit    = "category_limit"
+	TrashKindGoal             = "goal"
+	TrashKindGoalContribution = "goal_contribution"
+	TrashKindDebt             = "debt"
+	TrashKindDebtPayment      = "debt_payment"
+	TrashKindPayee            = "payee"
+	TrashKindRule             = "rule"
+	TrashKindTag              = "tag"
+	TrashKindAttachment       = "attachment"
+)
+
+// TrashKinds lists every kind, parents first
+var TrashKinds = []string{
+	TrashKindCategory, TrashKindAccount, TrashKindPayee, TrashKindRule, TrashKindTag,
+	TrashKindTransaction, TrashKindTransfer, TrashKindPlannedExpense, TrashKindRecurringExpense,
+	TrashKindPlannedIncome, TrashKindCategoryLimit, TrashKindGoal, TrashKindGoalContribution,
+	TrashKindDebt, TrashKindDebtPayment, TrashKindAttachment,
+}
+
+// TrashItem is something deleted that can still be restored until PurgeAt.
+// Amount and Currency are only set for kinds that have an amount.
+type TrashItem struct {
+	Kind      string    `json:"kind"`
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Amount    *Money    `json:"amount,omitempty"`
+	Currency  *string   `json:"currency,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+type TrashFilters struct {
+	// Kinds limits the items to some kinds, all of them when empty
+	Kinds []string `json:"kinds,omitempty"`
+}
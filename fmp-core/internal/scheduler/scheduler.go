@@ -0,0 +1,22 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+)
+
+// Every runs job right away and then once per interval in the background,
+// logging failures instead of stopping
+func Every(interval time.Duration, name string, job func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := job(); err != nil {
+				log.Printf("Scheduled job %s failed: %v", name, err)
+			}
+			<-ticker.C
+		}
+	}()
+}
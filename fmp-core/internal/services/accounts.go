@@ -0,0 +1,173 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// getAccountCurrency makes sure the account exists and belongs to the user
+// and returns its currency
+func getAccountCurrency(userID, accountID uuid.UUID) (string, error) {
+	var currency string
+	err := db.QueryRow(`SELECT currency FROM accounts WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`, accountID, userID).Scan(&currency)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("account not found")
+		}
+		return "", err
+	}
+	return currency, nil
+}
+
+// Account services
+func GetAccounts(userID uuid.UUID) ([]models.Account, error) {
+	query := `SELECT id, user_id, name, type, currency, opening_balance, created_at, updated_at FROM accounts WHERE user_id = $1 AND deleted_at IS NULL ORDER BY name`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []models.Account
+	for rows.Next() {
+		var account models.Account
+		err := rows.Scan(&account.ID, &account.UserID, &account.Name, &account.Type, &account.Currency, &account.OpeningBalance, &account.CreatedAt, &account.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
+
+func CreateAccount(userID uuid.UUID, req models.CreateAccountRequest) (*models.Account, error) {
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+
+	account := &models.Account{
+		ID:             uuid.New(),
+		UserID:         userID,
+		Name:           req.Name,
+		Type:           req.Type,
+		Currency:       req.Currency,
+		OpeningBalance: req.OpeningBalance,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	query := `INSERT INTO accounts (id, user_id, name, type, currency, opening_balance, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := db.Exec(query, account.ID, account.UserID, account.Name, account.Type, account.Currency, account.OpeningBalance, account.CreatedAt, account.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func GetAccount(userID, id uuid.UUID) (*models.Account, error) {
+	account := &models.Account{}
+	query := `SELECT id, user_id, name, type, currency, opening_balance, created_at, updated_at FROM accounts WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	err := db.QueryRow(query, id, userID).Scan(&account.ID, &account.UserID, &account.Name, &account.Type, &account.Currency, &account.OpeningBalance, &account.CreatedAt, &account.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("account not found")
+		}
+		return nil, err
+	}
+	return account, nil
+}
+
+func UpdateAccount(userID, id uuid.UUID, req models.CreateAccountRequest) (*models.Account, error) {
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+
+	query := `UPDATE accounts SET name = $1, type = $2, currency = $3, opening_balance = $4, updated_at = $5 WHERE id = $6 AND user_id = $7 AND deleted_at IS NULL`
+	result, err := db.Exec(query, req.Name, req.Type, req.Currency, req.OpeningBalance, time.Now(), id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	return GetAccount(userID, id)
+}
+
+// DeleteAccount moves the account to the trash with its transfers. Its other
+// transactions stay.
+func DeleteAccount(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindAccount, userID, id)
+}
+
+// GetAccountBalances computes the balance of every account of the user from
+// its opening balance and the transactions dated up to asOf. Transactions in
+// another currency are converted to the account currency at their date's rate.
+func GetAccountBalances(userID uuid.UUID, asOf time.Time) ([]models.AccountBalance, error) {
+	return queryAccountBalances(userID, nil, asOf)
+}
+
+func GetAccountBalance(userID, id uuid.UUID, asOf time.Time) (*models.AccountBalance, error) {
+	balances, err := queryAccountBalances(userID, &id, asOf)
+	if err != nil {
+		return nil, err
+	}
+	if len(balances) == 0 {
+		return nil, fmt.Errorf("account not found")
+	}
+	return &balances[0], nil
+}
+
+func queryAccountBalances(userID uuid.UUID, accountID *uuid.UUID, asOf time.Time) ([]models.AccountBalance, error) {
+	query := `
+		SELECT
+			a.id,
+			a.name,
+			a.currency,
+			a.opening_balance,
+			a.opening_balance + COALESCE(SUM(convert_amount(a.user_id, CASE WHEN t.type = 'income' THEN t.amount ELSE -t.amount END, t.currency, a.currency, t.date::date)), 0) as balance
+		FROM accounts a
+		LEFT JOIN transactions t ON a.id = t.account_id
+			AND t.date <= $2
+			AND t.deleted_at IS NULL
+		WHERE a.user_id = $1 AND a.deleted_at IS NULL
+	`
+	args := []interface{}{userID, asOf}
+
+	if accountID != nil {
+		query += " AND a.id = $3"
+		args = append(args, *accountID)
+	}
+
+	query += " GROUP BY a.id, a.name, a.currency, a.opening_balance ORDER BY a.name"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []models.AccountBalance
+	for rows.Next() {
+		balance := models.AccountBalance{AsOf: asOf}
+		err := rows.Scan(&balance.AccountID, &balance.AccountName, &balance.Currency, &balance.OpeningBalance, &balance.Balance)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+
+	return balances, nil
+}
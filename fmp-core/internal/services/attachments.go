@@ -0,0 +1,220 @@
+package services
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"fmp-core/internal/models"
+	"fmp-core/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var blobs storage.Store
+
+// SetBlobStore sets the store attachment contents are kept in
+func SetBlobStore(store storage.Store) {
+	blobs = store
+}
+
+// AttachmentOwnerNotFoundError is returned when attaching a file to a
+// transaction or planned expense that does not exist
+type AttachmentOwnerNotFoundError struct {
+	Owner string
+}
+
+func (e *AttachmentOwnerNotFoundError) Error() string {
+	return e.Owner + " not found"
+}
+
+// UnsupportedAttachmentTypeError is returned for contents of a type that is
+// not accepted
+type UnsupportedAttachmentTypeError struct {
+	ContentType string
+}
+
+func (e *UnsupportedAttachmentTypeError) Error() string {
+	return fmt.Sprintf("unsupported attachment type %s", e.ContentType)
+}
+
+// attachmentContentTypes are the types accepted for upload, as sniffed from
+// the contents rather than taken from the client
+var attachmentContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+const attachmentColumns = `id, user_id, transaction_id, planned_expense_id, file_name, content_type, size, storage_key, created_at, updated_at`
+
+func scanAttachment(row rowScanner) (*models.Attachment, error) {
+	attachment := &models.Attachment{}
+	err := row.Scan(&attachment.ID, &attachment.UserID, &attachment.TransactionID, &attachment.PlannedExpenseID, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.StorageKey, &attachment.CreatedAt, &attachment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// Attachment services
+func GetTransactionAttachments(userID, transactionID uuid.UUID) ([]models.Attachment, error) {
+	return queryAttachments(`SELECT `+attachmentColumns+` FROM attachments WHERE user_id = $1 AND transaction_id = $2 AND deleted_at IS NULL ORDER BY created_at`, userID, transactionID)
+}
+
+func GetPlannedExpenseAttachments(userID, expenseID uuid.UUID) ([]models.Attachment, error) {
+	return queryAttachments(`SELECT `+attachmentColumns+` FROM attachments WHERE user_id = $1 AND planned_expense_id = $2 AND deleted_at IS NULL ORDER BY created_at`, userID, expenseID)
+}
+
+func queryAttachments(query string, args ...interface{}) ([]models.Attachment, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []models.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+
+	return attachments, nil
+}
+
+func GetAttachment(userID, id uuid.UUID) (*models.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	attachment, err := scanAttachment(db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// OpenAttachment returns the attachment with a reader of its contents, which
+// the caller must close
+func OpenAttachment(userID, id uuid.UUID) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := GetAttachment(userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	contents, err := blobs.Get(attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, contents, nil
+}
+
+func AddTransactionAttachment(userID, transactionID uuid.UUID, fileName string, size int64, r io.Reader) (*models.Attachment, error) {
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM transactions WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`, transactionID, userID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &AttachmentOwnerNotFoundError{Owner: "transaction"}
+	}
+
+	return createAttachment(&models.Attachment{UserID: userID, TransactionID: &transactionID}, fileName, size, r)
+}
+
+func AddPlannedExpenseAttachment(userID, expenseID uuid.UUID, fileName string, size int64, r io.Reader) (*models.Attachment, error) {
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM planned_expenses WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`, expenseID, userID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &AttachmentOwnerNotFoundError{Owner: "planned expense"}
+	}
+
+	return createAttachment(&models.Attachment{UserID: userID, PlannedExpenseID: &expenseID}, fileName, size, r)
+}
+
+// createAttachment stores the contents first and then records the metadata,
+// removing the stored contents again if that fails
+func createAttachment(attachment *models.Attachment, fileName string, size int64, r io.Reader) (*models.Attachment, error) {
+	contents := bufio.NewReaderSize(r, 512)
+	head, err := contents.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	contentType := strings.SplitN(http.DetectContentType(head), ";", 2)[0]
+	if !attachmentContentTypes[contentType] {
+		return nil, &UnsupportedAttachmentTypeError{ContentType: contentType}
+	}
+
+	attachment.ID = uuid.New()
+	attachment.FileName = path.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	if attachment.FileName == "." || attachment.FileName == "/" {
+		attachment.FileName = attachment.ID.String()
+	}
+	attachment.ContentType = contentType
+	attachment.Size = size
+	attachment.StorageKey = attachment.UserID.String() + "/" + attachment.ID.String()
+	attachment.CreatedAt = time.Now()
+	attachment.UpdatedAt = time.Now()
+
+	if err := blobs.Put(attachment.StorageKey, contents, size, contentType); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO attachments (id, user_id, transaction_id, planned_expense_id, file_name, content_type, size, storage_key, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err = db.Exec(query, attachment.ID, attachment.UserID, attachment.TransactionID, attachment.PlannedExpenseID, attachment.FileName, attachment.ContentType, attachment.Size, attachment.StorageKey, attachment.CreatedAt, attachment.UpdatedAt)
+	if err != nil {
+		deleteAttachmentBlobs([]string{attachment.StorageKey})
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// DeleteAttachment moves the attachment to the trash, its contents are only
+// removed once it is purged
+func DeleteAttachment(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindAttachment, userID, id)
+}
+
+// attachmentKeys returns the storage keys of the attachments matching the
+// condition, to clean up their contents once they are deleted
+func attachmentKeys(tx *sql.Tx, condition string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(`SELECT storage_key FROM attachments WHERE `+condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// deleteAttachmentBlobs removes stored contents whose metadata is already
+// gone. Failures only leave unreferenced blobs behind, so they are logged.
+func deleteAttachmentBlobs(keys []string) {
+	for _, key := range keys {
+		if err := blobs.Delete(key); err != nil {
+			log.Printf("Failed to delete attachment blob %s: %v", key, err)
+		}
+	}
+}
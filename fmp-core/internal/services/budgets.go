@@ -0,0 +1,435 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// inviteStartPrefix starts the /start parameter of invite links
+const inviteStartPrefix = "join_"
+
+// defaultInviteTTLDays is how long an invite stays valid unless told otherwise
+const defaultInviteTTLDays = 7
+
+// createPersonalBudget makes a new user the owner of a personal budget that
+// shares the user's id, so the user's own data stays where it is
+func createPersonalBudget(tx *sql.Tx, userID uuid.UUID) error {
+	if _, err := tx.Exec(`INSERT INTO budgets (id, name, is_personal) VALUES ($1, 'Personal', true)`, userID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO budget_members (budget_id, user_id, role) VALUES ($1, $1, 'owner')`, userID)
+	return err
+}
+
+// GetBudgetRole returns the role of the user in the budget, budgets the user
+// is not a member of are not found
+func GetBudgetRole(userID, budgetID uuid.UUID) (models.BudgetRole, error) {
+	var role models.BudgetRole
+	err := db.QueryRow(`SELECT role FROM budget_members WHERE budget_id = $1 AND user_id = $2`, budgetID, userID).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("budget not found")
+		}
+		return "", err
+	}
+	return role, nil
+}
+
+// requireBudgetRole makes sure the user has at least the required role
+func requireBudgetRole(userID, budgetID uuid.UUID, required models.BudgetRole) error {
+	role, err := GetBudgetRole(userID, budgetID)
+	if err != nil {
+		return err
+	}
+	if !role.Allows(required) {
+		return fmt.Errorf("only a budget %s can do this", required)
+	}
+	return nil
+}
+
+// Budget services
+func GetBudgets(userID uuid.UUID) ([]models.Budget, error) {
+	query := `
+		SELECT b.id, b.name, b.is_personal, m.role, b.created_at, b.updated_at
+		FROM budgets b
+		JOIN budget_members m ON m.budget_id = b.id
+		WHERE m.user_id = $1
+		ORDER BY b.is_personal DESC, b.name
+	`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []models.Budget
+	for rows.Next() {
+		var budget models.Budget
+		err := rows.Scan(&budget.ID, &budget.Name, &budget.IsPersonal, &budget.Role, &budget.CreatedAt, &budget.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, nil
+}
+
+func GetBudget(userID, id uuid.UUID) (*models.Budget, error) {
+	budget := &models.Budget{}
+	query := `
+		SELECT b.id, b.name, b.is_personal, m.role, b.created_at, b.updated_at
+		FROM budgets b
+		JOIN budget_members m ON m.budget_id = b.id
+		WHERE b.id = $1 AND m.user_id = $2
+	`
+	err := db.QueryRow(query, id, userID).Scan(&budget.ID, &budget.Name, &budget.IsPersonal, &budget.Role, &budget.CreatedAt, &budget.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("budget not found")
+		}
+		return nil, err
+	}
+	return budget, nil
+}
+
+// CreateBudget creates a shared budget owned by the user. Its data belongs to
+// a users row of its own.
+func CreateBudget(userID uuid.UUID, req models.CreateBudgetRequest) (*models.Budget, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id := uuid.New()
+	now := time.Now()
+	if _, err := tx.Exec(`INSERT INTO users (id, created_at, updated_at) VALUES ($1, $2, $2)`, id, now); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`INSERT INTO budgets (id, name, is_personal, created_at, updated_at) VALUES ($1, $2, false, $3, $3)`, id, req.Name, now); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`INSERT INTO budget_members (budget_id, user_id, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $4)`, id, userID, models.BudgetRoleOwner, now); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return GetBudget(userID, id)
+}
+
+func UpdateBudget(userID, id uuid.UUID, req models.CreateBudgetRequest) (*models.Budget, error) {
+	if err := requireBudgetRole(userID, id, models.BudgetRoleOwner); err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(`UPDATE budgets SET name = $1, updated_at = $2 WHERE id = $3`, req.Name, time.Now(), id); err != nil {
+		return nil, err
+	}
+
+	return GetBudget(userID, id)
+}
+
+// DeleteBudget deletes a shared budget together with all of its data
+func DeleteBudget(userID, id uuid.UUID) error {
+	budget, err := GetBudget(userID, id)
+	if err != nil {
+		return err
+	}
+	if budget.Role != models.BudgetRoleOwner {
+		return fmt.Errorf("only a budget owner can do this")
+	}
+	if budget.IsPersonal {
+		return fmt.Errorf("personal budget cannot be deleted")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	attachments, err := attachmentKeys(tx, `user_id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	// The budget, its members and its data go with its users row
+	if _, err := tx.Exec(`DELETE FROM users WHERE id = $1 AND telegram_id IS NULL`, id); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	deleteAttachmentBlobs(attachments)
+	return nil
+}
+
+// Budget member services
+func GetBudgetMembers(userID, budgetID uuid.UUID) ([]models.BudgetMember, error) {
+	if _, err := GetBudgetRole(userID, budgetID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT m.budget_id, m.user_id, u.telegram_id, m.role, m.created_at, m.updated_at
+		FROM budget_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.budget_id = $1
+		ORDER BY m.created_at
+	`
+	rows, err := db.Query(query, budgetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.BudgetMember
+	for rows.Next() {
+		var member models.BudgetMember
+		err := rows.Scan(&member.BudgetID, &member.UserID, &member.TelegramID, &member.Role, &member.CreatedAt, &member.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// UpdateBudgetMember changes the role of a member, a budget always keeps at
+// least one owner
+func UpdateBudgetMember(userID, budgetID, memberID uuid.UUID, req models.UpdateBudgetMemberRequest) (*models.BudgetMember, error) {
+	if err := requireBudgetRole(userID, budgetID, models.BudgetRoleOwner); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockLastOwner(tx, budgetID, memberID, req.Role != models.BudgetRoleOwner); err != nil {
+		return nil, err
+	}
+
+	member := &models.BudgetMember{}
+	query := `
+		UPDATE budget_members SET role = $1, updated_at = $2 WHERE budget_id = $3 AND user_id = $4
+		RETURNING budget_id, user_id, role, created_at, updated_at
+	`
+	err = tx.QueryRow(query, req.Role, time.Now(), budgetID, memberID).Scan(&member.BudgetID, &member.UserID, &member.Role, &member.CreatedAt, &member.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("budget member not found")
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// RemoveBudgetMember removes a member from a budget. Owners remove anyone,
+// other members can only leave.
+func RemoveBudgetMember(userID, budgetID, memberID uuid.UUID) error {
+	if memberID != userID {
+		if err := requireBudgetRole(userID, budgetID, models.BudgetRoleOwner); err != nil {
+			return err
+		}
+	} else if _, err := GetBudgetRole(userID, budgetID); err != nil {
+		return err
+	}
+	if memberID == budgetID {
+		return fmt.Errorf("personal budget cannot be left")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockLastOwner(tx, budgetID, memberID, true); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM budget_members WHERE budget_id = $1 AND user_id = $2`, budgetID, memberID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("budget member not found")
+	}
+
+	return tx.Commit()
+}
+
+// lockLastOwner locks the members of the budget and refuses to take the
+// owner role away from its last owner
+func lockLastOwner(tx *sql.Tx, budgetID, memberID uuid.UUID, losesOwner bool) error {
+	if !losesOwner {
+		return nil
+	}
+
+	var otherOwners int
+	query := `SELECT COUNT(*) FROM (SELECT 1 FROM budget_members WHERE budget_id = $1 AND role = 'owner' AND user_id <> $2 FOR UPDATE) owners`
+	if err := tx.QueryRow(query, budgetID, memberID).Scan(&otherOwners); err != nil {
+		return err
+	}
+	if otherOwners == 0 {
+		return fmt.Errorf("budget needs at least one owner")
+	}
+	return nil
+}
+
+// Budget invite services
+const budgetInviteColumns = `id, budget_id, token, role, created_by, expires_at, accepted_by, accepted_at, created_at`
+
+func scanBudgetInvite(row rowScanner) (*models.BudgetInvite, error) {
+	var invite models.BudgetInvite
+	err := row.Scan(&invite.ID, &invite.BudgetID, &invite.Token, &invite.Role, &invite.CreatedBy, &invite.ExpiresAt, &invite.AcceptedBy, &invite.AcceptedAt, &invite.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	invite.StartParameter = inviteStartPrefix + invite.Token
+	return &invite, nil
+}
+
+func GetBudgetInvites(userID, budgetID uuid.UUID) ([]models.BudgetInvite, error) {
+	if err := requireBudgetRole(userID, budgetID, models.BudgetRoleOwner); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT `+budgetInviteColumns+` FROM budget_invites WHERE budget_id = $1 ORDER BY created_at DESC`, budgetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []models.BudgetInvite{}
+	for rows.Next() {
+		invite, err := scanBudgetInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, *invite)
+	}
+
+	return invites, nil
+}
+
+// CreateBudgetInvite creates an invite to a shared budget, personal budgets
+// stay private
+func CreateBudgetInvite(userID, budgetID uuid.UUID, req models.CreateBudgetInviteRequest) (*models.BudgetInvite, error) {
+	budget, err := GetBudget(userID, budgetID)
+	if err != nil {
+		return nil, err
+	}
+	if budget.Role != models.BudgetRoleOwner {
+		return nil, fmt.Errorf("only a budget owner can do this")
+	}
+	if budget.IsPersonal {
+		return nil, fmt.Errorf("personal budget cannot be shared")
+	}
+	if req.Role == "" {
+		req.Role = models.BudgetRoleEditor
+	}
+	if req.TTLDays == 0 {
+		req.TTLDays = defaultInviteTTLDays
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	query := `
+		INSERT INTO budget_invites (id, budget_id, token, role, created_by, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + budgetInviteColumns
+	return scanBudgetInvite(db.QueryRow(query, uuid.New(), budgetID, hex.EncodeToString(token), req.Role, userID, now.AddDate(0, 0, req.TTLDays), now))
+}
+
+func DeleteBudgetInvite(userID, id uuid.UUID) error {
+	query := `
+		DELETE FROM budget_invites i
+		USING budget_members m
+		WHERE i.id = $1 AND m.budget_id = i.budget_id AND m.user_id = $2 AND m.role = 'owner'
+	`
+	result, err := db.Exec(query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("budget invite not found")
+	}
+
+	return nil
+}
+
+// AcceptBudgetInvite makes the user a member of the invite's budget. The token
+// may come with the join_ prefix of the /start parameter. Members keep their
+// role when they accept another invite.
+func AcceptBudgetInvite(userID uuid.UUID, token string) (*models.Budget, error) {
+	token = strings.TrimPrefix(strings.TrimSpace(token), inviteStartPrefix)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	invite, err := scanBudgetInvite(tx.QueryRow(`SELECT `+budgetInviteColumns+` FROM budget_invites WHERE token = $1 FOR UPDATE`, token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invite not found")
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if invite.AcceptedAt != nil || now.After(invite.ExpiresAt) {
+		return nil, fmt.Errorf("invite has expired")
+	}
+
+	query := `INSERT INTO budget_members (budget_id, user_id, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $4) ON CONFLICT (budget_id, user_id) DO NOTHING`
+	if _, err := tx.Exec(query, invite.BudgetID, userID, invite.Role, now); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE budget_invites SET accepted_by = $1, accepted_at = $2 WHERE id = $3`, userID, now, invite.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return GetBudget(userID, invite.BudgetID)
+}
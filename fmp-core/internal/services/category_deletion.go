@@ -0,0 +1,179 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Errors of deleting a category: the category or the one to reassign to is
+// not in the budget, or the reassignment would move it into itself
+var (
+	ErrCategoryNotFound         = errors.New("category not found")
+	ErrReassignTargetNotFound   = errors.New("category to reassign to not found")
+	ErrReassignToItself         = errors.New("category cannot be reassigned to itself")
+	ErrReassignToOwnSubcategory = errors.New("category cannot be reassigned to its own subcategory")
+)
+
+// CategoryInUseError is returned when deleting a category that is still in
+// use without saying where to move what uses it
+type CategoryInUseError struct {
+	Usage models.CategoryUsage
+}
+
+func (e *CategoryInUseError) Error() string {
+	return "category is in use, give reassign_to to move what uses it to another category"
+}
+
+// categoryReferences are the updates that move what refers to the category
+// in $1 to the category in $2. Rows in the trash are left where they are.
+var categoryReferences = []struct {
+	moved  func(*models.CategoryUsage) *int
+	update string
+}{
+	{func(u *models.CategoryUsage) *int { return &u.Subcategories },
+		`UPDATE categories SET parent_id = $2 WHERE parent_id = $1 AND deleted_at IS NULL`},
+	{func(u *models.CategoryUsage) *int { return &u.Transactions },
+		`UPDATE transactions SET category_id = $2 WHERE category_id = $1 AND deleted_at IS NULL`},
+	{func(u *models.CategoryUsage) *int { return &u.TransactionSplits },
+		`UPDATE transaction_splits s SET category_id = $2 FROM transactions t
+		 WHERE t.id = s.transaction_id AND s.category_id = $1 AND t.deleted_at IS NULL`},
+	{func(u *models.CategoryUsage) *int { return &u.PlannedExpenses },
+		`UPDATE planned_expenses SET category_id = $2 WHERE category_id = $1 AND deleted_at IS NULL`},
+	{func(u *models.CategoryUsage) *int { return &u.RecurringExpenses },
+		`UPDATE recurring_expenses SET category_id = $2 WHERE category_id = $1 AND deleted_at IS NULL`},
+	// A category has one limit a month, the target keeps its own
+	{func(u *models.CategoryUsage) *int { return &u.CategoryLimits },
+		`UPDATE category_limits l SET category_id = $2 WHERE l.category_id = $1 AND l.deleted_at IS NULL
+		 AND NOT EXISTS (SELECT 1 FROM category_limits o WHERE o.category_id = $2 AND o.month = l.month AND o.year = l.year AND o.deleted_at IS NULL)`},
+	{func(u *models.CategoryUsage) *int { return &u.LimitTemplates },
+		`UPDATE limit_templates SET category_id = $2 WHERE category_id = $1
+		 AND NOT EXISTS (SELECT 1 FROM limit_templates WHERE category_id = $2)`},
+	{func(u *models.CategoryUsage) *int { return &u.PlannedIncomes },
+		`UPDATE planned_incomes SET category_id = $2 WHERE category_id = $1 AND deleted_at IS NULL`},
+	{func(u *models.CategoryUsage) *int { return &u.Goals },
+		`UPDATE goals SET category_id = $2 WHERE category_id = $1 AND deleted_at IS NULL`},
+	{func(u *models.CategoryUsage) *int { return &u.Debts },
+		`UPDATE debts SET category_id = $2 WHERE category_id = $1 AND deleted_at IS NULL`},
+	{func(u *models.CategoryUsage) *int { return &u.Rules },
+		`UPDATE rules SET category_id = $2 WHERE category_id = $1 AND deleted_at IS NULL`},
+}
+
+func getCategoryUsage(q queryRower, id uuid.UUID) (models.CategoryUsage, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM categories WHERE parent_id = $1 AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM transactions WHERE category_id = $1 AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM transaction_splits s JOIN transactions t ON t.id = s.transaction_id WHERE s.category_id = $1 AND t.deleted_at IS NULL),
+			(SELECT COUNT(*) FROM planned_expenses WHERE category_id = $1 AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM recurring_expenses WHERE category_id = $1 AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM category_limits WHERE category_id = $1 AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM limit_templates WHERE category_id = $1),
+			(SELECT COUNT(*) FROM planned_incomes WHERE category_id = $1 AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM goals WHERE category_id = $1 AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM debts WHERE category_id = $1 AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM rules WHERE category_id = $1 AND deleted_at IS NULL)
+	`
+	var usage models.CategoryUsage
+	err := q.QueryRow(query, id).Scan(
+		&usage.Subcategories, &usage.Transactions, &usage.TransactionSplits, &usage.PlannedExpenses,
+		&usage.RecurringExpenses, &usage.CategoryLimits, &usage.LimitTemplates, &usage.PlannedIncomes,
+		&usage.Goals, &usage.Debts, &usage.Rules,
+	)
+	return usage, err
+}
+
+// DeleteCategory moves the category to the trash. A category that is still
+// in use is only deleted with reassignTo, everything using it is then moved
+// to that category first, in the same transaction. The reassignment is nil
+// without reassignTo.
+func DeleteCategory(userID, id uuid.UUID, reassignTo *uuid.UUID) (*models.CategoryReassignment, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the category so nothing starts using it while it is deleted
+	var locked uuid.UUID
+	err = tx.QueryRow(`SELECT id FROM categories WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE`, id, userID).Scan(&locked)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	var reassignment *models.CategoryReassignment
+	if reassignTo == nil {
+		usage, err := getCategoryUsage(tx, id)
+		if err != nil {
+			return nil, err
+		}
+		if usage.Total() > 0 {
+			return nil, &CategoryInUseError{Usage: usage}
+		}
+	} else {
+		reassignment, err = reassignCategory(tx, userID, id, *reassignTo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := trashItem(tx, models.TrashKindCategory, userID, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return reassignment, nil
+}
+
+// reassignCategory moves everything using the category to the target, which
+// takes over its subcategories too
+func reassignCategory(tx *sql.Tx, userID, id, target uuid.UUID) (*models.CategoryReassignment, error) {
+	if target == id {
+		return nil, ErrReassignToItself
+	}
+	var exists bool
+	err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`, target, userID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrReassignTargetNotFound
+	}
+	isSubcategory, err := isCategoryAncestor(tx, id, target)
+	if err != nil {
+		return nil, err
+	}
+	if isSubcategory {
+		return nil, ErrReassignToOwnSubcategory
+	}
+
+	reassignment := &models.CategoryReassignment{ReassignedTo: target}
+	for _, reference := range categoryReferences {
+		result, err := tx.Exec(reference.update, id, target)
+		if err != nil {
+			return nil, err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		*reference.moved(&reassignment.Moved) = int(rowsAffected)
+	}
+
+	// Whatever the target already had is left behind for the trash
+	reassignment.Trashed, err = getCategoryUsage(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return reassignment, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// checkCategoryParent makes sure the parent belongs to the user and that
+// putting the category under it does not create a cycle
+func checkCategoryParent(userID, categoryID uuid.UUID, parentID *uuid.UUID) error {
+	if parentID == nil {
+		return nil
+	}
+	if *parentID == categoryID {
+		return fmt.Errorf("category cannot be its own parent")
+	}
+	if err := checkCategoryOwner(userID, *parentID); err != nil {
+		return fmt.Errorf("parent category not found")
+	}
+
+	isAncestor, err := isCategoryAncestor(db, categoryID, *parentID)
+	if err != nil {
+		return err
+	}
+	if isAncestor {
+		return fmt.Errorf("category cannot be moved under its own subcategory")
+	}
+
+	return nil
+}
+
+// isCategoryAncestor tells whether categoryID is ancestorID or one of its
+// subcategories at any depth
+func isCategoryAncestor(q queryRower, ancestorID, categoryID uuid.UUID) (bool, error) {
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT EXISTS(SELECT 1 FROM ancestors WHERE id = $2)
+	`
+	var isAncestor bool
+	if err := q.QueryRow(query, categoryID, ancestorID).Scan(&isAncestor); err != nil {
+		return false, err
+	}
+	return isAncestor, nil
+}
+
+// buildCategoryTree nests categories under their parents, keeping the order
+// they came in. Categories whose parent is missing stay at the top level.
+func buildCategoryTree(categories []models.Category) []models.Category {
+	byParent := make(map[uuid.UUID][]models.Category)
+	known := make(map[uuid.UUID]bool)
+	for _, category := range categories {
+		known[category.ID] = true
+	}
+
+	var roots []models.Category
+	for _, category := range categories {
+		if category.ParentID != nil && known[*category.ParentID] {
+			byParent[*category.ParentID] = append(byParent[*category.ParentID], category)
+		} else {
+			roots = append(roots, category)
+		}
+	}
+
+	var attach func(nodes []models.Category) []models.Category
+	attach = func(nodes []models.Category) []models.Category {
+		for i := range nodes {
+			nodes[i].Children = attach(byParent[nodes[i].ID])
+		}
+		return nodes
+	}
+
+	return attach(roots)
+}
+
+// rollUpCategorySummaries adds every subcategory's totals to its ancestors.
+// A parent without a limit of its own gets the sum of its children's limits,
+// base and carried amounts included.
+// The summaries end up sorted by spending, largest first.
+func rollUpCategorySummaries(summaries []models.CategorySummary) {
+	index := make(map[uuid.UUID]int, len(summaries))
+	for i, summary := range summaries {
+		index[summary.CategoryID] = i
+	}
+
+	children := make(map[int][]int)
+	for i, summary := range summaries {
+		if summary.ParentID == nil {
+			continue
+		}
+		if parent, ok := index[*summary.ParentID]; ok {
+			children[parent] = append(children[parent], i)
+		}
+	}
+
+	visited := make(map[int]bool, len(summaries))
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+
+		var childLimits, childBaseLimits, childCarried models.Money
+		hasChildLimits := false
+		for _, child := range children[i] {
+			visit(child)
+			summaries[i].Amount += summaries[child].Amount
+			summaries[i].Income += summaries[child].Income
+			if summaries[child].Limit != nil {
+				childLimits += *summaries[child].Limit
+				hasChildLimits = true
+			}
+			if summaries[child].BaseLimit != nil {
+				childBaseLimits += *summaries[child].BaseLimit
+			}
+			if summaries[child].Carried != nil {
+				childCarried += *summaries[child].Carried
+			}
+		}
+
+		if summaries[i].Limit == nil && hasChildLimits {
+			summaries[i].Limit = &childLimits
+			summaries[i].BaseLimit = &childBaseLimits
+			summaries[i].Carried = &childCarried
+		}
+		summaries[i].Net = summaries[i].Income - summaries[i].Amount
+		summaries[i].IsExceeded = summaries[i].Limit != nil && summaries[i].Amount > *summaries[i].Limit
+	}
+
+	for i := range summaries {
+		visit(i)
+	}
+
+	sort.SliceStable(summaries, func(a, b int) bool {
+		return summaries[a].Amount > summaries[b].Amount
+	})
+}
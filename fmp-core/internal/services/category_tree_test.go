@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func moneyPtr(m models.Money) *models.Money {
+	return &m
+}
+
+func TestRollUpCategorySummaries(t *testing.T) {
+	food, groceries, cafes, snacks, rent := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	summaries := []models.CategorySummary{
+		{CategoryID: food, CategoryName: "Food", Amount: 1000},
+		{CategoryID: groceries, ParentID: &food, CategoryName: "Groceries", Amount: 30000, Limit: moneyPtr(40000), BaseLimit: moneyPtr(35000), Carried: moneyPtr(5000)},
+		{CategoryID: cafes, ParentID: &food, CategoryName: "Cafes", Amount: 5000, Limit: moneyPtr(10000), BaseLimit: moneyPtr(10000), Carried: moneyPtr(0)},
+		{CategoryID: snacks, ParentID: &cafes, CategoryName: "Snacks", Amount: 6000},
+		{CategoryID: rent, CategoryName: "Rent", Amount: 50000, Income: 2000, Limit: moneyPtr(45000), BaseLimit: moneyPtr(45000), Carried: moneyPtr(0)},
+	}
+
+	rollUpCategorySummaries(summaries)
+
+	byID := map[uuid.UUID]models.CategorySummary{}
+	for _, summary := range summaries {
+		byID[summary.CategoryID] = summary
+	}
+
+	tests := []struct {
+		id       uuid.UUID
+		amount   models.Money
+		limit    *models.Money
+		base     *models.Money
+		carried  *models.Money
+		exceeded bool
+	}{
+		// A parent without a limit gets the sum of its children's limits
+		{id: food, amount: 42000, limit: moneyPtr(50000), base: moneyPtr(45000), carried: moneyPtr(5000)},
+		{id: groceries, amount: 30000, limit: moneyPtr(40000), base: moneyPtr(35000), carried: moneyPtr(5000)},
+		// A limit of its own stays, spending includes the subcategories
+		{id: cafes, amount: 11000, limit: moneyPtr(10000), base: moneyPtr(10000), carried: moneyPtr(0), exceeded: true},
+		{id: snacks, amount: 6000},
+		{id: rent, amount: 50000, limit: moneyPtr(45000), base: moneyPtr(45000), carried: moneyPtr(0), exceeded: true},
+	}
+
+	for _, tt := range tests {
+		got := byID[tt.id]
+		if got.Amount != tt.amount || got.IsExceeded != tt.exceeded || got.Net != got.Income-got.Amount {
+			t.Errorf("%s: amount %v, net %v, exceeded %v, want %v, %v, %v", got.CategoryName, got.Amount, got.Net, got.IsExceeded, tt.amount, got.Income-tt.amount, tt.exceeded)
+		}
+		for _, limit := range []struct {
+			name      string
+			got, want *models.Money
+		}{{"limit", got.Limit, tt.limit}, {"base limit", got.BaseLimit, tt.base}, {"carried", got.Carried, tt.carried}} {
+			if (limit.got == nil) != (limit.want == nil) || (limit.got != nil && *limit.got != *limit.want) {
+				t.Errorf("%s: %s %v, want %v", got.CategoryName, limit.name, limit.got, limit.want)
+			}
+		}
+	}
+
+	for i := 1; i < len(summaries); i++ {
+		if summaries[i-1].Amount < summaries[i].Amount {
+			t.Errorf("summaries are not sorted by spending: %v before %v", summaries[i-1].Amount, summaries[i].Amount)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// missingExchangeRateCode is the SQLSTATE convert_amount raises when it has
+// no rate for a pair, with "from to date" as the detail
+const missingExchangeRateCode = "FX001"
+
+// MissingExchangeRateError is returned when an amount can not be converted
+// because no rate between the two currencies is known on the date
+type MissingExchangeRateError struct {
+	From string
+	To   string
+	Date string
+}
+
+func (e *MissingExchangeRateError) Error() string {
+	return fmt.Sprintf("no exchange rate from %s to %s on %s", e.From, e.To, e.Date)
+}
+
+// AsMissingExchangeRate tells whether the error comes from a missing
+// exchange rate and which one
+func AsMissingExchangeRate(err error) (*MissingExchangeRateError, bool) {
+	var missing *MissingExchangeRateError
+	if errors.As(err, &missing) {
+		return missing, true
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != missingExchangeRateCode {
+		return nil, false
+	}
+	fields := strings.Fields(pqErr.Detail)
+	if len(fields) != 3 {
+		return nil, false
+	}
+	return &MissingExchangeRateError{From: fields[0], To: fields[1], Date: fields[2]}, true
+}
+
+// ExchangeRateValidationError is returned for a rate that can not be stored
+// as given, or a rates file that can not be read
+type ExchangeRateValidationError struct {
+	Reason string
+}
+
+func (e *ExchangeRateValidationError) Error() string {
+	return e.Reason
+}
+
+var ratePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// upsertExchangeRateQuery replaces the rate when the pair already has one on that date
+const upsertExchangeRateQuery = `
+	INSERT INTO exchange_rates (id, user_id, base_currency, quote_currency, rate, date, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+	ON CONFLICT (user_id, base_currency, quote_currency, date) DO UPDATE SET rate = EXCLUDED.rate, updated_at = EXCLUDED.updated_at
+	RETURNING id, user_id, base_currency, quote_currency, rate, date, created_at, updated_at
+`
+
+func validateExchangeRate(req models.CreateExchangeRateRequest) error {
+	if !models.IsCurrencyCode(req.BaseCurrency) || !models.IsCurrencyCode(req.QuoteCurrency) {
+		return &ExchangeRateValidationError{Reason: "invalid currency code"}
+	}
+	if req.BaseCurrency == req.QuoteCurrency {
+		return &ExchangeRateValidationError{Reason: "base and quote currencies must differ"}
+	}
+	if !ratePattern.MatchString(req.Rate.String()) {
+		return &ExchangeRateValidationError{Reason: fmt.Sprintf("invalid exchange rate %q", req.Rate)}
+	}
+	if rate, _ := new(big.Rat).SetString(req.Rate.String()); rate.Sign() == 0 {
+		return &ExchangeRateValidationError{Reason: "exchange rate must be positive"}
+	}
+	return nil
+}
+
+// Exchange Rate services
+func GetExchangeRates(userID uuid.UUID, filters models.ExchangeRateFilters) ([]models.ExchangeRate, error) {
+	query := `SELECT id, user_id, base_currency, quote_currency, rate, date, created_at, updated_at FROM exchange_rates WHERE user_id = $1`
+	args := []interface{}{userID}
+	argIndex := 2
+
+	if filters.BaseCurrency != nil {
+		query += fmt.Sprintf(" AND base_currency = $%d", argIndex)
+		args = append(args, *filters.BaseCurrency)
+		argIndex++
+	}
+
+	if filters.QuoteCurrency != nil {
+		query += fmt.Sprintf(" AND quote_currency = $%d", argIndex)
+		args = append(args, *filters.QuoteCurrency)
+		argIndex++
+	}
+
+	if filters.StartDate != nil {
+		query += fmt.Sprintf(" AND date >= $%d", argIndex)
+		args = append(args, *filters.StartDate)
+		argIndex++
+	}
+
+	if filters.EndDate != nil {
+		query += fmt.Sprintf(" AND date <= $%d", argIndex)
+		args = append(args, *filters.EndDate)
+		argIndex++
+	}
+
+	query += " ORDER BY date DESC, base_currency, quote_currency"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.ExchangeRate
+	for rows.Next() {
+		var rate models.ExchangeRate
+		err := rows.Scan(&rate.ID, &rate.UserID, &rate.BaseCurrency, &rate.QuoteCurrency, &rate.Rate, &rate.Date, &rate.CreatedAt, &rate.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// CreateExchangeRate stores a manually entered rate, replacing the one the
+// pair already has on that date
+func CreateExchangeRate(userID uuid.UUID, req models.CreateExchangeRateRequest) (*models.ExchangeRate, error) {
+	if err := validateExchangeRate(req); err != nil {
+		return nil, err
+	}
+
+	rate := &models.ExchangeRate{}
+	err := db.QueryRow(upsertExchangeRateQuery, uuid.New(), userID, req.BaseCurrency, req.QuoteCurrency, req.Rate, req.Date, time.Now()).
+		Scan(&rate.ID, &rate.UserID, &rate.BaseCurrency, &rate.QuoteCurrency, &rate.Rate, &rate.Date, &rate.CreatedAt, &rate.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+func DeleteExchangeRate(userID, id uuid.UUID) error {
+	query := `DELETE FROM exchange_rates WHERE id = $1 AND user_id = $2`
+	result, err := db.Exec(query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("exchange rate not found")
+	}
+
+	return nil
+}
+
+// ParseExchangeRatesCSV reads "date,base_currency,quote_currency,rate" rows,
+// e.g. "2024-03-01,EUR,RUB,98.45". A header row is skipped.
+func ParseExchangeRatesCSV(r io.Reader) ([]models.CreateExchangeRateRequest, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var rates []models.CreateExchangeRateRequest
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &ExchangeRateValidationError{Reason: err.Error()}
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "date") {
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, &ExchangeRateValidationError{Reason: fmt.Sprintf("line %d: invalid date %q", line, record[0])}
+		}
+
+		rate := models.CreateExchangeRateRequest{
+			BaseCurrency:  strings.ToUpper(strings.TrimSpace(record[1])),
+			QuoteCurrency: strings.ToUpper(strings.TrimSpace(record[2])),
+			Rate:          json.Number(strings.TrimSpace(record[3])),
+			Date:          date,
+		}
+		if err := validateExchangeRate(rate); err != nil {
+			return nil, &ExchangeRateValidationError{Reason: fmt.Sprintf("line %d: %s", line, err)}
+		}
+		rates = append(rates, rate)
+	}
+
+	if len(rates) == 0 {
+		return nil, &ExchangeRateValidationError{Reason: "no exchange rates in file"}
+	}
+
+	return rates, nil
+}
+
+// ImportExchangeRates stores all rates in one database transaction, replacing
+// the existing rates of the same pairs and dates
+func ImportExchangeRates(userID uuid.UUID, rates []models.CreateExchangeRateRequest) (*models.ExchangeRateImportResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, rate := range rates {
+		if err := validateExchangeRate(rate); err != nil {
+			return nil, err
+		}
+		_, err := tx.Exec(upsertExchangeRateQuery, uuid.New(), userID, rate.BaseCurrency, rate.QuoteCurrency, rate.Rate, rate.Date, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.ExchangeRateImportResult{Imported: len(rates)}, nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"fmp-core/internal/models"
+)
+
+func TestValidateExchangeRate(t *testing.T) {
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		req     models.CreateExchangeRateRequest
+		wantErr bool
+	}{
+		{name: "valid", req: models.CreateExchangeRateRequest{BaseCurrency: "EUR", QuoteCurrency: "RUB", Rate: "98.45", Date: date}},
+		{name: "many decimals", req: models.CreateExchangeRateRequest{BaseCurrency: "RUB", QuoteCurrency: "USD", Rate: "0.0108695652", Date: date}},
+		{name: "unknown currency", req: models.CreateExchangeRateRequest{BaseCurrency: "EURO", QuoteCurrency: "RUB", Rate: "98.45", Date: date}, wantErr: true},
+		{name: "same currency", req: models.CreateExchangeRateRequest{BaseCurrency: "RUB", QuoteCurrency: "RUB", Rate: "1", Date: date}, wantErr: true},
+		{name: "zero rate", req: models.CreateExchangeRateRequest{BaseCurrency: "EUR", QuoteCurrency: "RUB", Rate: "0.00", Date: date}, wantErr: true},
+		{name: "negative rate", req: models.CreateExchangeRateRequest{BaseCurrency: "EUR", QuoteCurrency: "RUB", Rate: "-98.45", Date: date}, wantErr: true},
+		{name: "exponent", req: models.CreateExchangeRateRequest{BaseCurrency: "EUR", QuoteCurrency: "RUB", Rate: "1e2", Date: date}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := validateExchangeRate(tt.req)
+		if !tt.wantErr {
+			if err != nil {
+				t.Errorf("%s: validateExchangeRate() error = %v", tt.name, err)
+			}
+			continue
+		}
+		var invalid *ExchangeRateValidationError
+		if !errors.As(err, &invalid) {
+			t.Errorf("%s: validateExchangeRate() error = %v, want an ExchangeRateValidationError", tt.name, err)
+		}
+	}
+}
+
+func TestParseExchangeRatesCSV(t *testing.T) {
+	rates, err := ParseExchangeRatesCSV(strings.NewReader("date,base_currency,quote_currency,rate\n2024-03-01, eur ,RUB,98.45\n2024-03-02,USD,RUB,91.2\n"))
+	if err != nil {
+		t.Fatalf("ParseExchangeRatesCSV() error = %v", err)
+	}
+	want := []models.CreateExchangeRateRequest{
+		{BaseCurrency: "EUR", QuoteCurrency: "RUB", Rate: json.Number("98.45"), Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{BaseCurrency: "USD", QuoteCurrency: "RUB", Rate: json.Number("91.2"), Date: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	if len(rates) != len(want) {
+		t.Fatalf("ParseExchangeRatesCSV() = %+v, want %+v", rates, want)
+	}
+	for i := range want {
+		if rates[i].BaseCurrency != want[i].BaseCurrency || rates[i].QuoteCurrency != want[i].QuoteCurrency ||
+			rates[i].Rate != want[i].Rate || !rates[i].Date.Equal(want[i].Date) {
+			t.Errorf("rate %d = %+v, want %+v", i, rates[i], want[i])
+		}
+	}
+
+	for _, file := range []string{
+		"",
+		"date,base_currency,quote_currency,rate\n",
+		"2024-03-01,EUR,RUB\n",
+		"01.03.2024,EUR,RUB,98.45\n",
+		"2024-03-01,EUR,EUR,1\n",
+		"2024-03-01,EUR,RUB,0\n",
+	} {
+		_, err := ParseExchangeRatesCSV(strings.NewReader(file))
+		var invalid *ExchangeRateValidationError
+		if !errors.As(err, &invalid) {
+			t.Errorf("ParseExchangeRatesCSV(%q) error = %v, want an ExchangeRateValidationError", file, err)
+		}
+	}
+}
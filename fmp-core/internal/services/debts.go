@@ -0,0 +1,488 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// queryRower is implemented by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Counterparty services
+func GetCounterparties(userID uuid.UUID) ([]models.Counterparty, error) {
+	query := `SELECT id, user_id, name, note, created_at, updated_at FROM counterparties WHERE user_id = $1 ORDER BY name`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counterparties []models.Counterparty
+	for rows.Next() {
+		var counterparty models.Counterparty
+		err := rows.Scan(&counterparty.ID, &counterparty.UserID, &counterparty.Name, &counterparty.Note, &counterparty.CreatedAt, &counterparty.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		counterparties = append(counterparties, counterparty)
+	}
+
+	return counterparties, nil
+}
+
+func CreateCounterparty(userID uuid.UUID, req models.CreateCounterpartyRequest) (*models.Counterparty, error) {
+	counterparty := &models.Counterparty{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      req.Name,
+		Note:      req.Note,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	query := `INSERT INTO counterparties (id, user_id, name, note, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := db.Exec(query, counterparty.ID, counterparty.UserID, counterparty.Name, counterparty.Note, counterparty.CreatedAt, counterparty.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return counterparty, nil
+}
+
+func UpdateCounterparty(userID, id uuid.UUID, req models.CreateCounterpartyRequest) (*models.Counterparty, error) {
+	counterparty := &models.Counterparty{}
+	query := `UPDATE counterparties SET name = $1, note = $2, updated_at = $3 WHERE id = $4 AND user_id = $5 RETURNING id, user_id, name, note, created_at, updated_at`
+	err := db.QueryRow(query, req.Name, req.Note, time.Now(), id, userID).Scan(&counterparty.ID, &counterparty.UserID, &counterparty.Name, &counterparty.Note, &counterparty.CreatedAt, &counterparty.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("counterparty not found")
+		}
+		return nil, err
+	}
+	return counterparty, nil
+}
+
+// DeleteCounterparty refuses to delete counterparties that still have debts,
+// including debts in the trash
+func DeleteCounterparty(userID, id uuid.UUID) error {
+	var hasDebts, hasDeletedDebts bool
+	query := `SELECT COALESCE(bool_or(deleted_at IS NULL), false), COALESCE(bool_or(deleted_at IS NOT NULL), false) FROM debts WHERE counterparty_id = $1 AND user_id = $2`
+	if err := db.QueryRow(query, id, userID).Scan(&hasDebts, &hasDeletedDebts); err != nil {
+		return err
+	}
+	if hasDebts {
+		return fmt.Errorf("counterparty has debts, delete them first")
+	}
+	if hasDeletedDebts {
+		return fmt.Errorf("counterparty has debts in the trash, delete them there first")
+	}
+
+	result, err := db.Exec(`DELETE FROM counterparties WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("counterparty not found")
+	}
+
+	return nil
+}
+
+const debtColumns = `id, user_id, counterparty_id, direction, name, principal, currency, interest_rate, schedule, term_months, start_date, account_id, category_id, created_at, updated_at`
+
+func scanDebt(row rowScanner) (*models.Debt, error) {
+	var debt models.Debt
+	err := row.Scan(&debt.ID, &debt.UserID, &debt.CounterpartyID, &debt.Direction, &debt.Name, &debt.Principal, &debt.Currency, &debt.InterestRate, &debt.Schedule, &debt.TermMonths, &debt.StartDate, &debt.AccountID, &debt.CategoryID, &debt.CreatedAt, &debt.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &debt, nil
+}
+
+const debtPaymentColumns = `id, user_id, debt_id, amount, principal_amount, interest_amount, date, note, transaction_id, created_at, updated_at`
+
+func scanDebtPayment(row rowScanner) (*models.DebtPayment, error) {
+	var payment models.DebtPayment
+	err := row.Scan(&payment.ID, &payment.UserID, &payment.DebtID, &payment.Amount, &payment.PrincipalAmount, &payment.InterestAmount, &payment.Date, &payment.Note, &payment.TransactionID, &payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// checkDebtRequest fills in the defaults and makes sure everything the debt
+// refers to belongs to the user
+func checkDebtRequest(userID uuid.UUID, req *models.CreateDebtRequest) error {
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+	if req.Schedule == "" {
+		req.Schedule = models.DebtScheduleNone
+	}
+	if req.Schedule != models.DebtScheduleNone && req.TermMonths == nil {
+		return fmt.Errorf("term_months is required for a payment schedule")
+	}
+	if req.StartDate.IsZero() {
+		req.StartDate = time.Now()
+	}
+
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM counterparties WHERE id = $1 AND user_id = $2)`, req.CounterpartyID, userID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("counterparty not found")
+	}
+
+	if req.AccountID != nil {
+		if _, err := getAccountCurrency(userID, *req.AccountID); err != nil {
+			return err
+		}
+	}
+	if req.CategoryID != nil {
+		return checkCategoryOwner(userID, *req.CategoryID)
+	}
+	return nil
+}
+
+// Debt services
+func GetDebts(userID uuid.UUID, filters models.DebtFilters) ([]models.Debt, error) {
+	query := `SELECT ` + debtColumns + ` FROM debts WHERE user_id = $1 AND deleted_at IS NULL`
+	args := []interface{}{userID}
+	argIndex := 2
+
+	if filters.CounterpartyID != nil {
+		query += fmt.Sprintf(" AND counterparty_id = $%d", argIndex)
+		args = append(args, *filters.CounterpartyID)
+		argIndex++
+	}
+
+	if filters.Direction != nil {
+		query += fmt.Sprintf(" AND direction = $%d", argIndex)
+		args = append(args, *filters.Direction)
+		argIndex++
+	}
+
+	query += " ORDER BY start_date DESC, name"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var debts []models.Debt
+	for rows.Next() {
+		debt, err := scanDebt(rows)
+		if err != nil {
+			return nil, err
+		}
+		debts = append(debts, *debt)
+	}
+
+	return debts, nil
+}
+
+func CreateDebt(userID uuid.UUID, req models.CreateDebtRequest) (*models.Debt, error) {
+	if err := checkDebtRequest(userID, &req); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New()
+	query := `INSERT INTO debts (id, user_id, counterparty_id, direction, name, principal, currency, interest_rate, schedule, term_months, start_date, account_id, category_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $14)`
+	_, err := db.Exec(query, id, userID, req.CounterpartyID, req.Direction, req.Name, req.Principal, req.Currency, req.InterestRate, req.Schedule, req.TermMonths, req.StartDate, req.AccountID, req.CategoryID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return GetDebt(userID, id)
+}
+
+func GetDebt(userID, id uuid.UUID) (*models.Debt, error) {
+	debt, err := scanDebt(db.QueryRow(`SELECT `+debtColumns+` FROM debts WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("debt not found")
+		}
+		return nil, err
+	}
+	return debt, nil
+}
+
+func UpdateDebt(userID, id uuid.UUID, req models.CreateDebtRequest) (*models.Debt, error) {
+	if err := checkDebtRequest(userID, &req); err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE debts SET counterparty_id = $1, direction = $2, name = $3, principal = $4, currency = $5, interest_rate = $6, schedule = $7, term_months = $8, start_date = $9, account_id = $10, category_id = $11, updated_at = $12 WHERE id = $13 AND user_id = $14 AND deleted_at IS NULL`
+	result, err := db.Exec(query, req.CounterpartyID, req.Direction, req.Name, req.Principal, req.Currency, req.InterestRate, req.Schedule, req.TermMonths, req.StartDate, req.AccountID, req.CategoryID, time.Now(), id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("debt not found")
+	}
+
+	return GetDebt(userID, id)
+}
+
+// DeleteDebt moves the debt to the trash, its payments are only reachable
+// through it. The transactions recorded for the payments are kept.
+func DeleteDebt(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindDebt, userID, id)
+}
+
+// Debt payment services
+func GetDebtPayments(userID, debtID uuid.UUID) ([]models.DebtPayment, error) {
+	if _, err := GetDebt(userID, debtID); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT `+debtPaymentColumns+` FROM debt_payments WHERE debt_id = $1 AND deleted_at IS NULL ORDER BY date DESC, created_at DESC`, debtID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []models.DebtPayment{}
+	for rows.Next() {
+		payment, err := scanDebtPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+
+	return payments, nil
+}
+
+// CreateDebtPayment records a payment, and its transaction if asked, in one
+// database transaction
+func CreateDebtPayment(userID, debtID uuid.UUID, req models.CreateDebtPaymentRequest) (*models.DebtPayment, error) {
+	debt, err := GetDebt(userID, debtID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Date.IsZero() {
+		req.Date = time.Now()
+	}
+	if req.AccountID == nil {
+		req.AccountID = debt.AccountID
+	}
+	if req.CategoryID == nil {
+		req.CategoryID = debt.CategoryID
+	}
+	if req.CreateTransaction {
+		if req.CategoryID == nil {
+			return nil, fmt.Errorf("category is required to record the payment as a transaction")
+		}
+		if err := checkCategoryOwner(userID, *req.CategoryID); err != nil {
+			return nil, err
+		}
+		if req.AccountID != nil {
+			if _, err := getAccountCurrency(userID, *req.AccountID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Payments of the same debt are split one at a time
+	if _, err := tx.Exec(`SELECT id FROM debts WHERE id = $1 FOR UPDATE`, debtID); err != nil {
+		return nil, err
+	}
+
+	interest := models.Money(0)
+	if req.InterestAmount != nil {
+		interest = *req.InterestAmount
+	} else {
+		balance, err := debtBalance(tx, debt, req.Date)
+		if err != nil {
+			return nil, err
+		}
+		interest = balance.AccruedInterest
+		if interest > req.Amount {
+			interest = req.Amount
+		}
+	}
+
+	payment := &models.DebtPayment{
+		ID:              uuid.New(),
+		UserID:          userID,
+		DebtID:          debtID,
+		Amount:          req.Amount,
+		PrincipalAmount: req.Amount - interest,
+		InterestAmount:  interest,
+		Date:            req.Date,
+		Note:            req.Note,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+
+	if req.CreateTransaction {
+		transactionType := models.TransactionTypeExpense
+		if debt.Direction == models.DebtDirectionLent {
+			transactionType = models.TransactionTypeIncome
+		}
+		description := debt.Name
+		if req.Note != "" {
+			description = req.Note
+		}
+
+		transactionID := uuid.New()
+		query := `INSERT INTO transactions (id, user_id, category_id, account_id, type, amount, currency, description, date, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)`
+		_, err := tx.Exec(query, transactionID, userID, req.CategoryID, req.AccountID, transactionType, payment.Amount, debt.Currency, description, payment.Date, payment.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := matchTransactionPayees(tx, userID, &transactionID); err != nil {
+			return nil, err
+		}
+		payment.TransactionID = &transactionID
+	}
+
+	query := `INSERT INTO debt_payments (id, user_id, debt_id, amount, principal_amount, interest_amount, date, note, transaction_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err = tx.Exec(query, payment.ID, payment.UserID, payment.DebtID, payment.Amount, payment.PrincipalAmount, payment.InterestAmount, payment.Date, payment.Note, payment.TransactionID, payment.CreatedAt, payment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// DeleteDebtPayment moves the payment to the trash together with its transaction
+func DeleteDebtPayment(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindDebtPayment, userID, id)
+}
+
+// GetDebtBalances reports what is left of every debt of the user
+func GetDebtBalances(userID uuid.UUID, asOf time.Time) ([]models.DebtBalance, error) {
+	debts, err := GetDebts(userID, models.DebtFilters{})
+	if err != nil {
+		return nil, err
+	}
+
+	balances := []models.DebtBalance{}
+	for i := range debts {
+		balance, err := debtBalance(db, &debts[i], asOf)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, *balance)
+	}
+
+	return balances, nil
+}
+
+// GetDebtBalance reports the principal and interest paid off by asOf, what
+// is still owed including the interest accrued since the last payment, and
+// the next payment due by the schedule
+func GetDebtBalance(userID, id uuid.UUID, asOf time.Time) (*models.DebtBalance, error) {
+	debt, err := GetDebt(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return debtBalance(db, debt, asOf)
+}
+
+func debtBalance(q queryRower, debt *models.Debt, asOf time.Time) (*models.DebtBalance, error) {
+	balance := &models.DebtBalance{
+		DebtID:         debt.ID,
+		CounterpartyID: debt.CounterpartyID,
+		Name:           debt.Name,
+		Direction:      debt.Direction,
+		Currency:       debt.Currency,
+		Principal:      debt.Principal,
+		AsOf:           asOf,
+	}
+
+	query := `SELECT COALESCE(SUM(principal_amount), 0), COALESCE(SUM(interest_amount), 0), MAX(date) FROM debt_payments WHERE debt_id = $1 AND date <= $2::date AND deleted_at IS NULL`
+	err := q.QueryRow(query, debt.ID, asOf).Scan(&balance.PrincipalPaid, &balance.InterestPaid, &balance.LastPaymentDate)
+	if err != nil {
+		return nil, err
+	}
+
+	balance.OutstandingPrincipal = debt.Principal - balance.PrincipalPaid
+	if balance.OutstandingPrincipal <= 0 {
+		balance.OutstandingPrincipal = 0
+		balance.IsClosed = true
+	}
+
+	since := debt.StartDate
+	if balance.LastPaymentDate != nil {
+		since = *balance.LastPaymentDate
+	}
+	balance.AccruedInterest = debt.AccruedInterest(balance.OutstandingPrincipal, daysBetween(since, asOf))
+	balance.Outstanding = balance.OutstandingPrincipal + balance.AccruedInterest
+
+	if !balance.IsClosed {
+		for _, row := range debt.Amortize() {
+			if daysBetween(asOf, row.Date) > 0 {
+				next := row
+				balance.NextPayment = &next
+				break
+			}
+		}
+	}
+
+	return balance, nil
+}
+
+// daysBetween counts the calendar days from one date to another
+func daysBetween(from, to time.Time) int {
+	fromDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDate.Sub(fromDate).Hours() / 24)
+}
+
+// GetDebtAmortization lays out the payment schedule of a debt
+func GetDebtAmortization(userID, id uuid.UUID) (*models.Amortization, error) {
+	debt, err := GetDebt(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if debt.Schedule == models.DebtScheduleNone {
+		return nil, fmt.Errorf("debt has no payment schedule")
+	}
+
+	amortization := &models.Amortization{
+		DebtID:   debt.ID,
+		Schedule: debt.Schedule,
+		Currency: debt.Currency,
+		Rows:     debt.Amortize(),
+	}
+	for _, row := range amortization.Rows {
+		amortization.TotalPayment += row.Payment
+		amortization.TotalInterest += row.Interest
+	}
+
+	return amortization, nil
+}
@@ -0,0 +1,12 @@
+// Package services holds the business logic of fmp-core on top of the
+// database set with SetDB.
+//
+// Data belongs to budgets, but the tables and most services still name the
+// owner user_id and userID from before budgets existed. For categories,
+// transactions, limits and every other data service that userID is the
+// budget, which handlers pass with currentBudgetID: a personal budget has the
+// id of its user, a shared budget has a users row of its own without a
+// Telegram ID (see migration 022). Services that deal with people, like
+// GetOrCreateUser and the budget membership ones, take the user in userID
+// and the budget in a separate budgetID or id.
+package services
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const goalColumns = `id, user_id, name, target_amount, currency, start_date, deadline, account_id, category_id, created_at, updated_at`
+
+func scanGoal(row rowScanner) (*models.Goal, error) {
+	var goal models.Goal
+	err := row.Scan(&goal.ID, &goal.UserID, &goal.Name, &goal.TargetAmount, &goal.Currency, &goal.StartDate, &goal.Deadline, &goal.AccountID, &goal.CategoryID, &goal.CreatedAt, &goal.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+const goalContributionColumns = `id, user_id, goal_id, amount, currency, date, note, created_at, updated_at`
+
+func scanGoalContribution(row rowScanner) (*models.GoalContribution, error) {
+	var contribution models.GoalContribution
+	err := row.Scan(&contribution.ID, &contribution.UserID, &contribution.GoalID, &contribution.Amount, &contribution.Currency, &contribution.Date, &contribution.Note, &contribution.CreatedAt, &contribution.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &contribution, nil
+}
+
+// daysPerMonth is the average length of a month, used to turn a pace per day
+// into one per month and back
+const daysPerMonth = 365.25 / 12
+
+// checkGoalRequest fills in the defaults and makes sure the linked account or
+// category belongs to the user
+func checkGoalRequest(userID uuid.UUID, req *models.CreateGoalRequest) error {
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+	if req.StartDate.IsZero() {
+		req.StartDate = time.Now()
+	}
+	if req.Deadline != nil && req.Deadline.Before(req.StartDate) {
+		return fmt.Errorf("deadline is before the start date")
+	}
+	if req.AccountID != nil {
+		if _, err := getAccountCurrency(userID, *req.AccountID); err != nil {
+			return err
+		}
+	}
+	if req.CategoryID != nil {
+		return checkCategoryOwner(userID, *req.CategoryID)
+	}
+	return nil
+}
+
+// Goal services
+func GetGoals(userID uuid.UUID) ([]models.Goal, error) {
+	rows, err := db.Query(`SELECT `+goalColumns+` FROM goals WHERE user_id = $1 AND deleted_at IS NULL ORDER BY deadline NULLS LAST, name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var goals []models.Goal
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, *goal)
+	}
+
+	return goals, nil
+}
+
+func CreateGoal(userID uuid.UUID, req models.CreateGoalRequest) (*models.Goal, error) {
+	if err := checkGoalRequest(userID, &req); err != nil {
+		return nil, err
+	}
+
+	goal := &models.Goal{
+		ID:           uuid.New(),
+		UserID:       userID,
+		Name:         req.Name,
+		TargetAmount: req.TargetAmount,
+		Currency:     req.Currency,
+		StartDate:    req.StartDate,
+		Deadline:     req.Deadline,
+		AccountID:    req.AccountID,
+		CategoryID:   req.CategoryID,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	query := `INSERT INTO goals (id, user_id, name, target_amount, currency, start_date, deadline, account_id, category_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := db.Exec(query, goal.ID, goal.UserID, goal.Name, goal.TargetAmount, goal.Currency, goal.StartDate, goal.Deadline, goal.AccountID, goal.CategoryID, goal.CreatedAt, goal.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetGoal(userID, goal.ID)
+}
+
+func GetGoal(userID, id uuid.UUID) (*models.Goal, error) {
+	goal, err := scanGoal(db.QueryRow(`SELECT `+goalColumns+` FROM goals WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("goal not found")
+		}
+		return nil, err
+	}
+	return goal, nil
+}
+
+func UpdateGoal(userID, id uuid.UUID, req models.CreateGoalRequest) (*models.Goal, error) {
+	if err := checkGoalRequest(userID, &req); err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE goals SET name = $1, target_amount = $2, currency = $3, start_date = $4, deadline = $5, account_id = $6, category_id = $7, updated_at = $8 WHERE id = $9 AND user_id = $10 AND deleted_at IS NULL`
+	result, err := db.Exec(query, req.Name, req.TargetAmount, req.Currency, req.StartDate, req.Deadline, req.AccountID, req.CategoryID, time.Now(), id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("goal not found")
+	}
+
+	return GetGoal(userID, id)
+}
+
+func DeleteGoal(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindGoal, userID, id)
+}
+
+// Goal contribution services
+func GetGoalContributions(userID, goalID uuid.UUID) ([]models.GoalContribution, error) {
+	if _, err := GetGoal(userID, goalID); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT `+goalContributionColumns+` FROM goal_contributions WHERE goal_id = $1 AND deleted_at IS NULL ORDER BY date DESC, created_at DESC`, goalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contributions := []models.GoalContribution{}
+	for rows.Next() {
+		contribution, err := scanGoalContribution(rows)
+		if err != nil {
+			return nil, err
+		}
+		contributions = append(contributions, *contribution)
+	}
+
+	return contributions, nil
+}
+
+// CreateGoalContribution records money put into the goal, in the goal
+// currency unless told otherwise
+func CreateGoalContribution(userID, goalID uuid.UUID, req models.CreateGoalContributionRequest) (*models.GoalContribution, error) {
+	goal, err := GetGoal(userID, goalID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Currency == "" {
+		req.Currency = goal.Currency
+	}
+	if req.Date.IsZero() {
+		req.Date = time.Now()
+	}
+
+	contribution := &models.GoalContribution{
+		ID:        uuid.New(),
+		UserID:    userID,
+		GoalID:    goalID,
+		Amount:    req.Amount,
+		Currency:  req.Currency,
+		Date:      req.Date,
+		Note:      req.Note,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	query := `INSERT INTO goal_contributions (id, user_id, goal_id, amount, currency, date, note, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	_, err = db.Exec(query, contribution.ID, contribution.UserID, contribution.GoalID, contribution.Amount, contribution.Currency, contribution.Date, contribution.Note, contribution.CreatedAt, contribution.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return contribution, nil
+}
+
+func DeleteGoalContribution(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindGoalContribution, userID, id)
+}
+
+// GetGoalsProgress reports the progress of every goal of the user
+func GetGoalsProgress(userID uuid.UUID, asOf time.Time, months int) ([]models.GoalProgress, error) {
+	goals, err := GetGoals(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := []models.GoalProgress{}
+	for i := range goals {
+		goalProgress, err := computeGoalProgress(&goals[i], asOf, months)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, *goalProgress)
+	}
+
+	return progress, nil
+}
+
+// GetGoalProgress reports how much of the goal was saved by asOf, what has to
+// be saved every month to make the deadline and when the goal is reached at
+// the pace of the last months
+func GetGoalProgress(userID, id uuid.UUID, asOf time.Time, months int) (*models.GoalProgress, error) {
+	goal, err := GetGoal(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return computeGoalProgress(goal, asOf, months)
+}
+
+func computeGoalProgress(goal *models.Goal, asOf time.Time, months int) (*models.GoalProgress, error) {
+	if months < 1 {
+		months = 1
+	}
+	windowStart := asOf.AddDate(0, -months, 0)
+	if goal.StartDate.After(windowStart) {
+		windowStart = goal.StartDate
+	}
+
+	// Contributions, plus spending in the linked category or money coming into
+	// the linked account, all in the goal currency
+	query := `
+		SELECT
+			COALESCE(SUM(amount), 0),
+			COALESCE(SUM(amount) FILTER (WHERE date >= $5::date), 0)
+		FROM (
+			SELECT gc.date, convert_amount(gc.user_id, gc.amount, gc.currency, $2, gc.date) AS amount
+			FROM goal_contributions gc
+			WHERE gc.goal_id = $1 AND gc.deleted_at IS NULL
+			UNION ALL
+			SELECT t.date::date, convert_amount(t.user_id, CASE WHEN t.type = 'expense' THEN t.amount ELSE -t.amount END, t.currency, $2, t.date::date)
+			FROM transaction_lines t
+			WHERE t.user_id = $3 AND t.category_id = $6 AND t.date::date >= $7
+			UNION ALL
+			SELECT t.date::date, convert_amount(t.user_id, CASE WHEN t.type = 'income' THEN t.amount ELSE -t.amount END, t.currency, $2, t.date::date)
+			FROM transactions t
+			WHERE t.user_id = $3 AND t.account_id = $8 AND t.date::date >= $7 AND t.deleted_at IS NULL
+		) contributions
+		WHERE date <= $4::date
+	`
+	var saved, recent models.Money
+	err := db.QueryRow(query, goal.ID, goal.Currency, goal.UserID, asOf, windowStart, goal.CategoryID, goal.StartDate, goal.AccountID).Scan(&saved, &recent)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &models.GoalProgress{
+		GoalID:       goal.ID,
+		Name:         goal.Name,
+		Currency:     goal.Currency,
+		TargetAmount: goal.TargetAmount,
+		Saved:        saved,
+		Remaining:    goal.TargetAmount - saved,
+		Percent:      saved.Percent(goal.TargetAmount),
+		Deadline:     goal.Deadline,
+		Months:       months,
+		AsOf:         asOf,
+	}
+	if progress.Remaining <= 0 {
+		progress.Remaining = 0
+		progress.IsReached = true
+	}
+
+	windowDays := asOf.Sub(windowStart).Hours() / 24
+	if windowDays < 1 {
+		windowDays = 1
+	}
+	progress.AverageMonthly = models.Money(float64(recent) * daysPerMonth / windowDays)
+
+	if !progress.IsReached && progress.AverageMonthly > 0 {
+		monthsNeeded := float64(progress.Remaining) / float64(progress.AverageMonthly)
+		projected := asOf.AddDate(0, 0, int(monthsNeeded*daysPerMonth+0.5))
+		progress.ProjectedDate = &projected
+	}
+
+	if goal.Deadline != nil {
+		monthsLeft := (goal.Deadline.Year()*12 + int(goal.Deadline.Month())) - (asOf.Year()*12 + int(asOf.Month()))
+		if monthsLeft < 0 {
+			monthsLeft = 0
+		}
+		progress.MonthsLeft = &monthsLeft
+
+		// What is left has to be saved in the months up to the deadline, or at
+		// once when the deadline is this month or already passed
+		required := progress.Remaining
+		if monthsLeft > 1 {
+			required = (progress.Remaining + models.Money(monthsLeft) - 1) / models.Money(monthsLeft)
+		}
+		progress.RequiredMonthly = &required
+
+		onTrack := progress.IsReached || (progress.ProjectedDate != nil && !progress.ProjectedDate.After(*goal.Deadline))
+		progress.OnTrack = &onTrack
+	}
+
+	return progress, nil
+}
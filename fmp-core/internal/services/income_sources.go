@@ -0,0 +1,64 @@
+package services
+
+import (
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// GetPlannedIncomeSummary lists the month's planned income sources with the
+// income received in their categories, converted to currency
+func GetPlannedIncomeSummary(userID uuid.UUID, month, year int, currency string) (*models.PlannedIncomeSummary, error) {
+	summary := &models.PlannedIncomeSummary{
+		Month:    month,
+		Year:     year,
+		Currency: currency,
+		Sources:  []models.IncomeSourceSummary{},
+	}
+	monthEnd := time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC)
+
+	query := `
+		SELECT
+			pi.id,
+			pi.name,
+			pi.category_id,
+			COALESCE(c.name, ''),
+			convert_amount(pi.user_id, pi.amount, pi.currency, $4, $5) as planned,
+			CASE WHEN c.id IS NOT NULL THEN (
+				SELECT COALESCE(SUM(convert_amount(t.user_id, t.amount, t.currency, $4, t.date::date)), 0)
+				FROM transaction_lines t
+				WHERE t.category_id = c.id AND t.type = 'income'
+					AND EXTRACT(MONTH FROM t.date) = $2
+					AND EXTRACT(YEAR FROM t.date) = $3
+			) END as received
+		FROM planned_incomes pi
+		LEFT JOIN categories c ON c.id = pi.category_id AND c.deleted_at IS NULL
+		WHERE pi.user_id = $1 AND pi.month = $2 AND pi.year = $3 AND pi.deleted_at IS NULL
+		ORDER BY planned DESC, pi.name
+	`
+	rows, err := db.Query(query, userID, month, year, currency, monthEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Sources sharing a category share its income, count it once
+	counted := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var source models.IncomeSourceSummary
+		err := rows.Scan(&source.ID, &source.Name, &source.CategoryID, &source.CategoryName, &source.Planned, &source.Received)
+		if err != nil {
+			return nil, err
+		}
+		summary.Planned += source.Planned
+		if source.Received != nil && !counted[*source.CategoryID] {
+			counted[*source.CategoryID] = true
+			summary.Received += *source.Received
+		}
+		summary.Sources = append(summary.Sources, source)
+	}
+
+	return summary, rows.Err()
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Limit Template services
+func GetLimitTemplates(userID uuid.UUID) ([]models.LimitTemplate, error) {
+	query := `SELECT id, user_id, category_id, limit_amount, currency, rollover, created_at, updated_at FROM limit_templates WHERE user_id = $1 AND category_id IN (SELECT id FROM categories WHERE deleted_at IS NULL) ORDER BY created_at`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []models.LimitTemplate
+	for rows.Next() {
+		var template models.LimitTemplate
+		err := rows.Scan(&template.ID, &template.UserID, &template.CategoryID, &template.Limit, &template.Currency, &template.Rollover, &template.CreatedAt, &template.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
+
+// CreateLimitTemplate sets the template of a category, replacing the one it
+// had before
+func CreateLimitTemplate(userID uuid.UUID, req models.CreateLimitTemplateRequest) (*models.LimitTemplate, error) {
+	if err := checkCategoryOwner(userID, req.CategoryID); err != nil {
+		return nil, err
+	}
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+
+	template := &models.LimitTemplate{}
+	query := `
+		INSERT INTO limit_templates (id, user_id, category_id, limit_amount, currency, rollover, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (category_id) DO UPDATE SET limit_amount = EXCLUDED.limit_amount, currency = EXCLUDED.currency, rollover = EXCLUDED.rollover
+		RETURNING id, user_id, category_id, limit_amount, currency, rollover, created_at, updated_at
+	`
+	err := db.QueryRow(query, uuid.New(), userID, req.CategoryID, req.Limit, req.Currency, req.Rollover, time.Now()).Scan(&template.ID, &template.UserID, &template.CategoryID, &template.Limit, &template.Currency, &template.Rollover, &template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func UpdateLimitTemplate(userID, id uuid.UUID, req models.CreateLimitTemplateRequest) (*models.LimitTemplate, error) {
+	if err := checkCategoryOwner(userID, req.CategoryID); err != nil {
+		return nil, err
+	}
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+
+	template := &models.LimitTemplate{}
+	query := `
+		UPDATE limit_templates SET category_id = $1, limit_amount = $2, currency = $3, rollover = $4, updated_at = $5
+		WHERE id = $6 AND user_id = $7
+		RETURNING id, user_id, category_id, limit_amount, currency, rollover, created_at, updated_at
+	`
+	err := db.QueryRow(query, req.CategoryID, req.Limit, req.Currency, req.Rollover, time.Now(), id, userID).Scan(&template.ID, &template.UserID, &template.CategoryID, &template.Limit, &template.Currency, &template.Rollover, &template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("limit template not found")
+		}
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func DeleteLimitTemplate(userID, id uuid.UUID) error {
+	query := `DELETE FROM limit_templates WHERE id = $1 AND user_id = $2`
+	result, err := db.Exec(query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("limit template not found")
+	}
+
+	return nil
+}
+
+// GenerateCategoryLimits creates the limits of a month from the templates
+// and/or the previous month's limits, leaving categories that already have a
+// limit for the month alone
+func GenerateCategoryLimits(userID uuid.UUID, month, year int, source models.LimitSource) (*models.LimitGenerationResult, error) {
+	if month < 1 || month > 12 {
+		return nil, fmt.Errorf("invalid month")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := generateCategoryLimits(tx, userID, month, year, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GenerateAllCategoryLimits generates the month's limits for every user whose
+// limits for it have not been generated yet, for the scheduler. A user that
+// fails is logged and skipped, and retried on the next run.
+func GenerateAllCategoryLimits(month, year int) (int, error) {
+	rows, err := db.Query(`SELECT id FROM users u WHERE NOT EXISTS (SELECT 1 FROM limit_generations g WHERE g.user_id = u.id AND g.month = $1 AND g.year = $2)`, month, year)
+	if err != nil {
+		return 0, err
+	}
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	created, failed := 0, 0
+	for _, userID := range userIDs {
+		result, err := GenerateCategoryLimits(userID, month, year, models.LimitSourceAll)
+		if err != nil {
+			log.Printf("Failed to generate category limits of user %s: %v", userID, err)
+			failed++
+			continue
+		}
+		created += len(result.Created)
+	}
+
+	if failed > 0 {
+		return created, fmt.Errorf("%d of %d users failed", failed, len(userIDs))
+	}
+	return created, nil
+}
+
+func generateCategoryLimits(tx *sql.Tx, userID uuid.UUID, month, year int, source models.LimitSource) (*models.LimitGenerationResult, error) {
+	result := &models.LimitGenerationResult{Month: month, Year: year, Created: []models.CategoryLimit{}}
+	now := time.Now()
+
+	var queries []string
+	if source == models.LimitSourceTemplates || source == models.LimitSourceAll {
+		queries = append(queries, `
+			INSERT INTO category_limits (id, user_id, category_id, limit_amount, currency, rollover, month, year, created_at, updated_at)
+			SELECT uuid_generate_v4(), user_id, category_id, limit_amount, currency, rollover, $2, $3, $4, $4
+			FROM limit_templates WHERE user_id = $1 AND category_id IN (SELECT id FROM categories WHERE deleted_at IS NULL)
+			ON CONFLICT (category_id, month, year) WHERE deleted_at IS NULL DO NOTHING
+			RETURNING id, user_id, category_id, limit_amount, currency, rollover, month, year, created_at, updated_at
+		`)
+	}
+	if source == models.LimitSourcePrevious || source == models.LimitSourceAll {
+		queries = append(queries, `
+			INSERT INTO category_limits (id, user_id, category_id, limit_amount, currency, rollover, month, year, created_at, updated_at)
+			SELECT uuid_generate_v4(), user_id, category_id, limit_amount, currency, rollover, $2, $3, $4, $4
+			FROM category_limits
+			WHERE user_id = $1 AND (year * 12 + month) = ($3 * 12 + $2) - 1 AND deleted_at IS NULL
+			ON CONFLICT (category_id, month, year) WHERE deleted_at IS NULL DO NOTHING
+			RETURNING id, user_id, category_id, limit_amount, currency, rollover, month, year, created_at, updated_at
+		`)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("invalid limit source %q", source)
+	}
+
+	for _, query := range queries {
+		rows, err := tx.Query(query, userID, month, year, now)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var limit models.CategoryLimit
+			err := rows.Scan(&limit.ID, &limit.UserID, &limit.CategoryID, &limit.Limit, &limit.Currency, &limit.Rollover, &limit.Month, &limit.Year, &limit.CreatedAt, &limit.UpdatedAt)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			result.Created = append(result.Created, limit)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := tx.Exec(`INSERT INTO limit_generations (user_id, month, year) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, userID, month, year)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
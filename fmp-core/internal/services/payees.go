@@ -0,0 +1,406 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// payeeMatchQuery picks the payee whose name or alias is the longest one
+// contained in the description given by the %s placeholder. $1 is the user.
+const payeeMatchQuery = `
+	SELECT k.payee_id FROM (
+		SELECT id AS payee_id, name AS key FROM payees WHERE user_id = $1 AND deleted_at IS NULL
+		UNION ALL
+		SELECT a.payee_id, a.alias FROM payee_aliases a JOIN payees p ON p.id = a.payee_id WHERE a.user_id = $1 AND p.deleted_at IS NULL
+	) k
+	WHERE normalize_payee_text(k.key) <> '' AND position(normalize_payee_text(k.key) IN normalize_payee_text(%s)) > 0
+	ORDER BY length(normalize_payee_text(k.key)) DESC
+	LIMIT 1`
+
+// matchPayee returns the payee the description matches, or nil
+func matchPayee(q queryRower, userID uuid.UUID, description string) (*uuid.UUID, error) {
+	if strings.TrimSpace(description) == "" {
+		return nil, nil
+	}
+
+	var payeeID uuid.UUID
+	if err := q.QueryRow(fmt.Sprintf(payeeMatchQuery, "$2"), userID, description).Scan(&payeeID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payeeID, nil
+}
+
+// matchTransactionPayees sets the payee of transactions without one from their
+// description, only of the given transaction when transactionID is set.
+// Transfer legs have no payee.
+func matchTransactionPayees(tx *sql.Tx, userID uuid.UUID, transactionID *uuid.UUID) (int, error) {
+	query := `
+		UPDATE transactions t SET payee_id = m.payee_id
+		FROM (
+			SELECT c.id, (` + fmt.Sprintf(payeeMatchQuery, "c.description") + `) AS payee_id
+			FROM transactions c
+			WHERE c.user_id = $1 AND c.payee_id IS NULL AND c.transfer_id IS NULL AND c.description <> ''
+				AND ($2::uuid IS NULL OR c.id = $2)
+		) m
+		WHERE t.id = m.id AND m.payee_id IS NOT NULL`
+	result, err := tx.Exec(query, userID, transactionID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
+
+// checkPayeeOwner makes sure the payee exists and belongs to the user
+func checkPayeeOwner(userID, payeeID uuid.UUID) error {
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM payees WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`, payeeID, userID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("payee not found")
+	}
+	return nil
+}
+
+// PayeeConflictError is returned when a name or an alias is already taken by
+// another payee of the budget
+type PayeeConflictError struct {
+	Reason string
+}
+
+func (e *PayeeConflictError) Error() string {
+	return e.Reason
+}
+
+// payeeTextReplacer folds ё into е like normalize_payee_text
+var payeeTextReplacer = strings.NewReplacer("Ё", "Е", "ё", "е")
+
+// normalizePayeeText compares payee names and aliases the way
+// normalize_payee_text does in the database
+func normalizePayeeText(value string) string {
+	return strings.Join(strings.Fields(strings.ToLower(payeeTextReplacer.Replace(value))), " ")
+}
+
+// normalizePayeeAliases trims aliases and drops empty and repeated ones, the
+// name itself always matches so it is dropped too
+func normalizePayeeAliases(name string, aliases []string) []string {
+	result := []string{}
+	seen := map[string]bool{normalizePayeeText(name): true}
+	for _, alias := range aliases {
+		alias = strings.Join(strings.Fields(alias), " ")
+		key := normalizePayeeText(alias)
+		if alias == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, alias)
+	}
+	return result
+}
+
+// checkPayeeName makes sure no other payee of the budget goes by the name,
+// neither as its name nor as an alias
+func checkPayeeName(tx *sql.Tx, userID, payeeID uuid.UUID, name string) error {
+	var owner string
+	var isAlias bool
+	query := `
+		SELECT name, false FROM payees
+		WHERE user_id = $1 AND id <> $2 AND deleted_at IS NULL AND normalize_payee_text(name) = normalize_payee_text($3)
+		UNION ALL
+		SELECT p.name, true FROM payee_aliases a JOIN payees p ON p.id = a.payee_id
+		WHERE a.user_id = $1 AND a.payee_id <> $2 AND p.deleted_at IS NULL AND normalize_payee_text(a.alias) = normalize_payee_text($3)
+		LIMIT 1
+	`
+	err := tx.QueryRow(query, userID, payeeID, name).Scan(&owner, &isAlias)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if isAlias {
+		return &PayeeConflictError{Reason: fmt.Sprintf("name %q is an alias of payee %q", name, owner)}
+	}
+	return &PayeeConflictError{Reason: fmt.Sprintf("payee %q already exists", owner)}
+}
+
+// setPayeeAliases replaces the aliases of a payee, an alias can only name one
+// payee of the budget and can not be the name of another one
+func setPayeeAliases(tx *sql.Tx, userID, payeeID uuid.UUID, name string, aliases []string) error {
+	if _, err := tx.Exec(`DELETE FROM payee_aliases WHERE payee_id = $1`, payeeID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, alias := range normalizePayeeAliases(name, aliases) {
+		var owner string
+		var trashed, isName bool
+		query := `
+			SELECT p.name, p.deleted_at IS NOT NULL, false FROM payee_aliases a JOIN payees p ON p.id = a.payee_id
+			WHERE a.user_id = $1 AND normalize_payee_text(a.alias) = normalize_payee_text($2)
+			UNION ALL
+			SELECT name, false, true FROM payees
+			WHERE user_id = $1 AND id <> $3 AND deleted_at IS NULL AND normalize_payee_text(name) = normalize_payee_text($2)
+			LIMIT 1
+		`
+		err := tx.QueryRow(query, userID, alias, payeeID).Scan(&owner, &trashed, &isName)
+		if err == nil {
+			switch {
+			case isName:
+				return &PayeeConflictError{Reason: fmt.Sprintf("alias %q is the name of payee %q", alias, owner)}
+			case trashed:
+				return &PayeeConflictError{Reason: fmt.Sprintf("alias %q already belongs to payee %q in the trash", alias, owner)}
+			}
+			return &PayeeConflictError{Reason: fmt.Sprintf("alias %q already belongs to payee %q", alias, owner)}
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		query = `INSERT INTO payee_aliases (id, user_id, payee_id, alias, created_at) VALUES ($1, $2, $3, $4, $5)`
+		if _, err := tx.Exec(query, uuid.New(), userID, payeeID, alias, now); err != nil {
+			if isUniqueViolation(err) {
+				return &PayeeConflictError{Reason: fmt.Sprintf("alias %q is already taken", alias)}
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Payee services
+const payeeColumns = `id, user_id, name, ARRAY(SELECT a.alias FROM payee_aliases a WHERE a.payee_id = payees.id ORDER BY a.alias), created_at, updated_at`
+
+func scanPayee(row rowScanner) (*models.Payee, error) {
+	var payee models.Payee
+	err := row.Scan(&payee.ID, &payee.UserID, &payee.Name, pq.Array(&payee.Aliases), &payee.CreatedAt, &payee.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &payee, nil
+}
+
+func GetPayees(userID uuid.UUID) ([]models.Payee, error) {
+	rows, err := db.Query(`SELECT `+payeeColumns+` FROM payees WHERE user_id = $1 AND deleted_at IS NULL ORDER BY name`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payees []models.Payee
+	for rows.Next() {
+		payee, err := scanPayee(rows)
+		if err != nil {
+			return nil, err
+		}
+		payees = append(payees, *payee)
+	}
+
+	return payees, nil
+}
+
+func GetPayee(userID, id uuid.UUID) (*models.Payee, error) {
+	payee, err := scanPayee(db.QueryRow(`SELECT `+payeeColumns+` FROM payees WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payee not found")
+		}
+		return nil, err
+	}
+	return payee, nil
+}
+
+// CreatePayee creates a payee and matches the transactions without a payee to it
+func CreatePayee(userID uuid.UUID, req models.CreatePayeeRequest) (*models.Payee, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("payee name is required")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id := uuid.New()
+	if err := checkPayeeName(tx, userID, id, name); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	query := `INSERT INTO payees (id, user_id, name, created_at, updated_at) VALUES ($1, $2, $3, $4, $4)`
+	if _, err := tx.Exec(query, id, userID, name, now); err != nil {
+		if isUniqueViolation(err) {
+			return nil, &PayeeConflictError{Reason: fmt.Sprintf("payee %q already exists", name)}
+		}
+		return nil, err
+	}
+
+	if err := setPayeeAliases(tx, userID, id, name, req.Aliases); err != nil {
+		return nil, err
+	}
+
+	if _, err := matchTransactionPayees(tx, userID, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return GetPayee(userID, id)
+}
+
+// UpdatePayee renames a payee and replaces its aliases when given. Matched
+// transactions keep their payee, unmatched ones are matched again.
+func UpdatePayee(userID, id uuid.UUID, req models.CreatePayeeRequest) (*models.Payee, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("payee name is required")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkPayeeName(tx, userID, id, name); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`UPDATE payees SET name = $1, updated_at = $2 WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL`, name, time.Now(), id, userID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, &PayeeConflictError{Reason: fmt.Sprintf("payee %q already exists", name)}
+		}
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("payee not found")
+	}
+
+	if req.Aliases != nil {
+		if err := setPayeeAliases(tx, userID, id, name, req.Aliases); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := matchTransactionPayees(tx, userID, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return GetPayee(userID, id)
+}
+
+// DeletePayee moves a payee to the trash with the rules for it. Its
+// transactions keep it for when it is restored.
+func DeletePayee(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindPayee, userID, id)
+}
+
+// MatchPayees matches the transactions without a payee to the payees and
+// returns how many got one
+func MatchPayees(userID uuid.UUID) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	matched, err := matchTransactionPayees(tx, userID, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return matched, nil
+}
+
+// GetTopPayees ranks payees by the amount of their transactions of the given
+// type over the period, or by the number of those transactions
+func GetTopPayees(userID uuid.UUID, filters models.PayeeSummaryFilters, currency string) ([]models.PayeeSummary, error) {
+	query := `
+		SELECT
+			p.id,
+			p.name,
+			COALESCE(SUM(convert_amount($1, t.amount, t.currency, $2, t.date::date)), 0) as amount,
+			COUNT(t.id) as visits,
+			MAX(t.date) as last_date
+		FROM payees p
+		JOIN transactions t ON t.payee_id = p.id AND t.user_id = $1 AND t.type = $3 AND t.deleted_at IS NULL`
+	args := []interface{}{userID, currency, filters.Type}
+	argIndex := 4
+
+	if filters.StartDate != nil {
+		query += fmt.Sprintf(" AND t.date >= $%d", argIndex)
+		args = append(args, *filters.StartDate)
+		argIndex++
+	}
+
+	if filters.EndDate != nil {
+		query += fmt.Sprintf(" AND t.date <= $%d", argIndex)
+		args = append(args, *filters.EndDate)
+		argIndex++
+	}
+
+	query += `
+		WHERE p.user_id = $1 AND p.deleted_at IS NULL
+		GROUP BY p.id, p.name`
+	if filters.SortBy == "visits" {
+		query += " ORDER BY visits DESC, amount DESC, p.name"
+	} else {
+		query += " ORDER BY amount DESC, visits DESC, p.name"
+	}
+	query += fmt.Sprintf(" LIMIT $%d", argIndex)
+	args = append(args, filters.Limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []models.PayeeSummary
+	for rows.Next() {
+		var summary models.PayeeSummary
+		err := rows.Scan(&summary.PayeeID, &summary.PayeeName, &summary.Amount, &summary.Visits, &summary.LastDate)
+		if err != nil {
+			return nil, err
+		}
+		summary.AverageAmount = summary.Amount / models.Money(summary.Visits)
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizePayeeText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Coffee House", want: "coffee house"},
+		{in: "  Coffee \t House  ", want: "coffee house"},
+		{in: "Ёлка", want: "елка"},
+		{in: "ПЯТЁРОЧКА", want: "пятерочка"},
+		{in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizePayeeText(tt.in); got != tt.want {
+			t.Errorf("normalizePayeeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizePayeeAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases []string
+		want    []string
+	}{
+		{name: "Пятёрочка", aliases: nil, want: []string{}},
+		{name: "Пятёрочка", aliases: []string{"  5ka  shop ", "", "  "}, want: []string{"5ka shop"}},
+		{name: "Пятёрочка", aliases: []string{"ПЯТЕРОЧКА", "пятёрочка", "Pyaterochka"}, want: []string{"Pyaterochka"}},
+		{name: "Coffee", aliases: []string{"Beans", "beans", "BEANS "}, want: []string{"Beans"}},
+	}
+
+	for _, tt := range tests {
+		if got := normalizePayeeAliases(tt.name, tt.aliases); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("normalizePayeeAliases(%q, %q) = %q, want %q", tt.name, tt.aliases, got, tt.want)
+		}
+	}
+}
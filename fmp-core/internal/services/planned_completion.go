@@ -0,0 +1,141 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// CompletePlannedExpense marks a planned expense completed together with the
+// transaction it was paid with, in one database transaction. An existing
+// transaction is linked as it is, otherwise one is created from the planned
+// expense and its tags.
+func CompletePlannedExpense(userID, id uuid.UUID, req models.CompletePlannedExpenseRequest) (*models.PlannedExpense, error) {
+	if req.TransactionID != nil && (req.AccountID != nil || req.Amount != 0 || req.Date != nil) {
+		return nil, fmt.Errorf("account, amount and date only apply to a new transaction")
+	}
+	if req.AccountID != nil {
+		if _, err := getAccountCurrency(userID, *req.AccountID); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var expense models.PlannedExpense
+	query := `SELECT category_id, amount, currency, description, planned_date, is_completed FROM planned_expenses WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE`
+	err = tx.QueryRow(query, id, userID).Scan(&expense.CategoryID, &expense.Amount, &expense.Currency, &expense.Description, &expense.PlannedDate, &expense.IsCompleted)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("planned expense not found")
+		}
+		return nil, err
+	}
+	if expense.IsCompleted {
+		return nil, fmt.Errorf("planned expense is already completed")
+	}
+
+	now := time.Now()
+	transactionID := uuid.New()
+	created := req.TransactionID == nil
+	if created {
+		amount, date := expense.Amount, expense.PlannedDate
+		if req.Amount != 0 {
+			amount = req.Amount
+		}
+		if req.Date != nil {
+			date = *req.Date
+		}
+
+		query := `INSERT INTO transactions (id, user_id, category_id, account_id, type, amount, currency, description, date, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)`
+		_, err := tx.Exec(query, transactionID, userID, expense.CategoryID, req.AccountID, models.TransactionTypeExpense, amount, expense.Currency, expense.Description, date, now)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(`INSERT INTO transaction_tags (transaction_id, tag_id) SELECT $1, tag_id FROM planned_expense_tags WHERE planned_expense_id = $2`, transactionID, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := matchTransactionPayees(tx, userID, &transactionID); err != nil {
+			return nil, err
+		}
+	} else {
+		transactionID = *req.TransactionID
+
+		var linked bool
+		query := `SELECT EXISTS(SELECT 1 FROM planned_expenses WHERE transaction_id = t.id) FROM transactions t WHERE t.id = $1 AND t.user_id = $2 AND t.transfer_id IS NULL AND t.deleted_at IS NULL`
+		if err := tx.QueryRow(query, transactionID, userID).Scan(&linked); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("transaction not found")
+			}
+			return nil, err
+		}
+		if linked {
+			return nil, fmt.Errorf("transaction already completes another planned expense")
+		}
+	}
+
+	query = `UPDATE planned_expenses SET is_completed = true, transaction_id = $1, transaction_created = $2, updated_at = $3 WHERE id = $4`
+	if _, err := tx.Exec(query, transactionID, created, now, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return GetPlannedExpense(userID, id)
+}
+
+// UncompletePlannedExpense reopens a completed planned expense. A transaction
+// created on completion goes to the trash, a linked one is only unlinked.
+func UncompletePlannedExpense(userID, id uuid.UUID) (*models.PlannedExpense, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The created transaction may be in the trash already
+	var isCompleted, created bool
+	var transactionID *uuid.UUID
+	query := `
+		SELECT is_completed, transaction_id, transaction_created AND EXISTS(SELECT 1 FROM transactions t WHERE t.id = transaction_id AND t.deleted_at IS NULL)
+		FROM planned_expenses WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE`
+	if err := tx.QueryRow(query, id, userID).Scan(&isCompleted, &transactionID, &created); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("planned expense not found")
+		}
+		return nil, err
+	}
+	if !isCompleted {
+		return nil, fmt.Errorf("planned expense is not completed")
+	}
+
+	query = `UPDATE planned_expenses SET is_completed = false, transaction_id = NULL, transaction_created = false, updated_at = $1 WHERE id = $2`
+	if _, err := tx.Exec(query, time.Now(), id); err != nil {
+		return nil, err
+	}
+
+	if created && transactionID != nil {
+		if err := trashItem(tx, models.TrashKindTransaction, userID, *transactionID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return GetPlannedExpense(userID, id)
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// CopyPlannedIncome copies planned income, and planned expenses if asked,
+// from one month into another in one database transaction. Planned expenses
+// keep their day of month, clamped to the end of shorter months. Occurrences
+// of recurring expenses are left to their rule.
+func CopyPlannedIncome(userID uuid.UUID, req models.CopyPlannedIncomeRequest) (*models.PlannedCopyResult, error) {
+	if req.FromMonth == 0 {
+		from := time.Date(req.ToYear, time.Month(req.ToMonth)-1, 1, 0, 0, 0, 0, time.UTC)
+		req.FromMonth, req.FromYear = int(from.Month()), from.Year()
+	}
+	if req.FromYear == 0 {
+		req.FromYear = req.ToYear
+	}
+	if req.FromMonth == req.ToMonth && req.FromYear == req.ToYear {
+		return nil, fmt.Errorf("source and target month are the same")
+	}
+
+	result := &models.PlannedCopyResult{
+		FromMonth:    req.FromMonth,
+		FromYear:     req.FromYear,
+		ToMonth:      req.ToMonth,
+		ToYear:       req.ToYear,
+		Income:       []models.PlannedIncome{},
+		Expenses:     []models.PlannedExpense{},
+		IncomeTotals: map[string]models.Money{},
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `
+		INSERT INTO planned_incomes (id, user_id, name, category_id, amount, currency, description, month, year, copied_from_id, created_at, updated_at)
+		SELECT uuid_generate_v4(), user_id, name, category_id, amount, currency, description, $4, $5, id, $6, $6
+		FROM planned_incomes
+		WHERE user_id = $1 AND month = $2 AND year = $3 AND deleted_at IS NULL
+		ON CONFLICT DO NOTHING
+		RETURNING ` + plannedIncomeColumns
+	rows, err := tx.Query(query, userID, req.FromMonth, req.FromYear, req.ToMonth, req.ToYear, now)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		income, err := scanPlannedIncome(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result.Income = append(result.Income, *income)
+		result.IncomeTotals[income.Currency] += income.Amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if req.IncludeExpenses {
+		monthShift := (req.ToYear*12 + req.ToMonth) - (req.FromYear*12 + req.FromMonth)
+		query := `
+			INSERT INTO planned_expenses (id, user_id, category_id, amount, currency, description, planned_date, is_completed, copied_from_id, created_at, updated_at)
+			SELECT uuid_generate_v4(), user_id, category_id, amount, currency, description, planned_date + make_interval(months => $4), false, id, $5, $5
+			FROM planned_expenses
+			WHERE user_id = $1 AND recurring_expense_id IS NULL AND deleted_at IS NULL
+				AND EXTRACT(MONTH FROM planned_date) = $2
+				AND EXTRACT(YEAR FROM planned_date) = $3
+			ON CONFLICT DO NOTHING
+			RETURNING id, user_id, category_id, amount, currency, description, planned_date, is_completed, copied_from_id, created_at, updated_at
+		`
+		rows, err := tx.Query(query, userID, req.FromMonth, req.FromYear, monthShift, now)
+		if err != nil {
+			return nil, err
+		}
+
+		var sources []uuid.UUID
+		for rows.Next() {
+			var expense models.PlannedExpense
+			var source uuid.UUID
+			err := rows.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Amount, &expense.Currency, &expense.Description, &expense.PlannedDate, &expense.IsCompleted, &source, &expense.CreatedAt, &expense.UpdatedAt)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			result.Expenses = append(result.Expenses, expense)
+			sources = append(sources, source)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		// Copies keep the tags of their source
+		for i, expense := range result.Expenses {
+			_, err := tx.Exec(`INSERT INTO planned_expense_tags (planned_expense_id, tag_id) SELECT $1, tag_id FROM planned_expense_tags WHERE planned_expense_id = $2`, expense.ID, sources[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const recurringExpenseColumns = `id, user_id, category_id, account_id, amount, currency, description, frequency, repeat_interval, repeat_count, until, start_date, auto_post, generated_until, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRecurringExpense(row rowScanner) (*models.RecurringExpense, error) {
+	expense := &models.RecurringExpense{}
+	err := row.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.AccountID, &expense.Amount, &expense.Currency, &expense.Description, &expense.Frequency, &expense.Interval, &expense.Count, &expense.Until, &expense.StartDate, &expense.AutoPost, &expense.GeneratedUntil, &expense.CreatedAt, &expense.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	expense.RRule = expense.Recurrence.RRule()
+	return expense, nil
+}
+
+// toDate drops the time of day, occurrences are whole days
+func toDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// resolveRecurrence takes the recurrence from the RRULE string when there is
+// one and from the separate fields otherwise
+func resolveRecurrence(req models.CreateRecurringExpenseRequest) (models.Recurrence, error) {
+	if req.RRule != "" {
+		return models.ParseRRule(req.RRule)
+	}
+
+	recurrence := req.Recurrence
+	if recurrence.Interval == 0 {
+		recurrence.Interval = 1
+	}
+	if recurrence.Until != nil {
+		until := toDate(*recurrence.Until)
+		recurrence.Until = &until
+	}
+	if err := recurrence.Validate(); err != nil {
+		return models.Recurrence{}, err
+	}
+	return recurrence, nil
+}
+
+// prepareRecurringExpense checks ownership and fills in the defaults shared by create and update
+func prepareRecurringExpense(userID uuid.UUID, req *models.CreateRecurringExpenseRequest) error {
+	if err := checkCategoryOwner(userID, req.CategoryID); err != nil {
+		return err
+	}
+	if req.AccountID != nil {
+		accountCurrency, err := getAccountCurrency(userID, *req.AccountID)
+		if err != nil {
+			return err
+		}
+		if req.Currency == "" {
+			req.Currency = accountCurrency
+		}
+	}
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+
+	recurrence, err := resolveRecurrence(*req)
+	if err != nil {
+		return err
+	}
+	req.Recurrence = recurrence
+	req.StartDate = toDate(req.StartDate)
+	return nil
+}
+
+// Recurring Expense services
+func GetRecurringExpenses(userID uuid.UUID) ([]models.RecurringExpense, error) {
+	return listRecurringExpenses(&userID)
+}
+
+// listRecurringExpenses lists the rules of one user, or of everyone when userID is nil
+func listRecurringExpenses(userID *uuid.UUID) ([]models.RecurringExpense, error) {
+	query := `SELECT ` + recurringExpenseColumns + ` FROM recurring_expenses WHERE deleted_at IS NULL`
+	var args []interface{}
+	if userID != nil {
+		query += ` AND user_id = $1`
+		args = append(args, *userID)
+	}
+	query += ` ORDER BY start_date`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []models.RecurringExpense
+	for rows.Next() {
+		expense, err := scanRecurringExpense(rows)
+		if err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, *expense)
+	}
+
+	return expenses, nil
+}
+
+func CreateRecurringExpense(userID uuid.UUID, req models.CreateRecurringExpenseRequest) (*models.RecurringExpense, error) {
+	if err := prepareRecurringExpense(userID, &req); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO recurring_expenses (id, user_id, category_id, account_id, amount, currency, description, frequency, repeat_interval, repeat_count, until, start_date, auto_post, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $14) RETURNING ` + recurringExpenseColumns
+	return scanRecurringExpense(db.QueryRow(query, uuid.New(), userID, req.CategoryID, req.AccountID, req.Amount, req.Currency, req.Description, req.Frequency, req.Interval, req.Count, req.Until, req.StartDate, req.AutoPost, time.Now()))
+}
+
+func GetRecurringExpense(userID, id uuid.UUID) (*models.RecurringExpense, error) {
+	query := `SELECT ` + recurringExpenseColumns + ` FROM recurring_expenses WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	expense, err := scanRecurringExpense(db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("recurring expense not found")
+		}
+		return nil, err
+	}
+	return expense, nil
+}
+
+// UpdateRecurringExpense changes the rule and drops its upcoming occurrences
+// that are not completed yet, so that the next generation follows the new rule
+func UpdateRecurringExpense(userID, id uuid.UUID, req models.CreateRecurringExpenseRequest) (*models.RecurringExpense, error) {
+	if err := prepareRecurringExpense(userID, &req); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	today := toDate(time.Now())
+	query := `
+		UPDATE recurring_expenses SET category_id = $1, account_id = $2, amount = $3, currency = $4, description = $5, frequency = $6,
+			repeat_interval = $7, repeat_count = $8, until = $9, start_date = $10, auto_post = $11, generated_until = CASE WHEN generated_until > $12 THEN $12 ELSE generated_until END, updated_at = $13
+		WHERE id = $14 AND user_id = $15 AND deleted_at IS NULL
+	`
+	result, err := tx.Exec(query, req.CategoryID, req.AccountID, req.Amount, req.Currency, req.Description, req.Frequency, req.Interval, req.Count, req.Until, req.StartDate, req.AutoPost, today, time.Now(), id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("recurring expense not found")
+	}
+
+	if err := deleteUpcomingOccurrences(tx, id, today); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return GetRecurringExpense(userID, id)
+}
+
+// DeleteRecurringExpense moves the rule to the trash with its upcoming
+// occurrences, past ones stay as one-off planned expenses
+func DeleteRecurringExpense(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindRecurringExpense, userID, id)
+}
+
+func deleteUpcomingOccurrences(tx *sql.Tx, recurringExpenseID uuid.UUID, today time.Time) error {
+	query := `DELETE FROM planned_expenses WHERE recurring_expense_id = $1 AND planned_date > $2 AND is_completed = false AND transaction_id IS NULL`
+	_, err := tx.Exec(query, recurringExpenseID, today)
+	return err
+}
+
+// GenerateRecurringExpenses materializes the occurrences of the user's rules
+// up to horizon as planned expenses and posts the due ones of auto-posted rules
+func GenerateRecurringExpenses(userID uuid.UUID, horizon time.Time) (*models.RecurringGenerationResult, error) {
+	expenses, err := listRecurringExpenses(&userID)
+	if err != nil {
+		return nil, err
+	}
+	return generateOccurrences(expenses, horizon)
+}
+
+// GenerateAllRecurringExpenses does the same for every user, for the scheduler
+func GenerateAllRecurringExpenses(horizon time.Time) (*models.RecurringGenerationResult, error) {
+	expenses, err := listRecurringExpenses(nil)
+	if err != nil {
+		return nil, err
+	}
+	return generateOccurrences(expenses, horizon)
+}
+
+// generateOccurrences goes on past a rule that fails, so one broken rule does
+// not hold back everybody else's
+func generateOccurrences(expenses []models.RecurringExpense, horizon time.Time) (*models.RecurringGenerationResult, error) {
+	result := &models.RecurringGenerationResult{}
+	today := toDate(time.Now())
+	horizon = toDate(horizon)
+
+	for _, expense := range expenses {
+		planned, posted, err := generateRecurringExpense(expense, horizon, today)
+		if err != nil {
+			log.Printf("Failed to generate recurring expense %s: %v", expense.ID, err)
+			result.Failed++
+			continue
+		}
+		result.Planned += planned
+		result.Posted += posted
+	}
+
+	return result, nil
+}
+
+// generateRecurringExpense works in one database transaction with the rule
+// locked, so concurrent runs neither duplicate nor double-post occurrences
+func generateRecurringExpense(expense models.RecurringExpense, horizon, today time.Time) (int, int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	// The rule may have been deleted since it was listed
+	var generatedUntil *time.Time
+	err = tx.QueryRow(`SELECT generated_until FROM recurring_expenses WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, expense.ID).Scan(&generatedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+
+	start := toDate(expense.StartDate)
+	from := start.AddDate(0, 0, -1)
+	if generatedUntil != nil {
+		from = toDate(*generatedUntil)
+	}
+
+	planned := 0
+	now := time.Now()
+	for _, date := range expense.Recurrence.Between(start, from, horizon) {
+		query := `
+			INSERT INTO planned_expenses (id, user_id, category_id, amount, currency, description, planned_date, is_completed, recurring_expense_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, false, $8, $9, $9)
+			ON CONFLICT (recurring_expense_id, planned_date) DO NOTHING
+		`
+		result, err := tx.Exec(query, uuid.New(), expense.UserID, expense.CategoryID, expense.Amount, expense.Currency, expense.Description, date, expense.ID, now)
+		if err != nil {
+			return 0, 0, err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, 0, err
+		}
+		planned += int(rowsAffected)
+	}
+
+	if horizon.After(from) {
+		if _, err := tx.Exec(`UPDATE recurring_expenses SET generated_until = $1 WHERE id = $2`, horizon, expense.ID); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	posted := 0
+	if expense.AutoPost {
+		posted, err = postDueOccurrences(tx, expense, today)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+
+	return planned, posted, nil
+}
+
+// postDueOccurrences records a transaction for every occurrence due by today
+// and marks the occurrence completed. Occurrences keep their own category,
+// amount and description, which may have been edited after generation.
+func postDueOccurrences(tx *sql.Tx, expense models.RecurringExpense, today time.Time) (int, error) {
+	query := `
+		SELECT id, category_id, amount, currency, description, planned_date FROM planned_expenses
+		WHERE recurring_expense_id = $1 AND is_completed = false AND transaction_id IS NULL AND planned_date < $2 AND deleted_at IS NULL
+		ORDER BY planned_date
+	`
+	rows, err := tx.Query(query, expense.ID, today.AddDate(0, 0, 1))
+	if err != nil {
+		return 0, err
+	}
+
+	var due []models.PlannedExpense
+	for rows.Next() {
+		var occurrence models.PlannedExpense
+		err := rows.Scan(&occurrence.ID, &occurrence.CategoryID, &occurrence.Amount, &occurrence.Currency, &occurrence.Description, &occurrence.PlannedDate)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, occurrence)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for _, occurrence := range due {
+		transactionID := uuid.New()
+		query := `INSERT INTO transactions (id, user_id, category_id, account_id, type, amount, currency, description, date, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)`
+		_, err := tx.Exec(query, transactionID, expense.UserID, occurrence.CategoryID, expense.AccountID, models.TransactionTypeExpense, occurrence.Amount, occurrence.Currency, occurrence.Description, occurrence.PlannedDate, now)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`INSERT INTO transaction_tags (transaction_id, tag_id) SELECT $1, tag_id FROM planned_expense_tags WHERE planned_expense_id = $2`, transactionID, occurrence.ID)
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := matchTransactionPayees(tx, expense.UserID, &transactionID); err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`UPDATE planned_expenses SET is_completed = true, transaction_id = $1, transaction_created = true, updated_at = $2 WHERE id = $3`, transactionID, now, occurrence.ID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(due), nil
+}
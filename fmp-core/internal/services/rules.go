@@ -0,0 +1,351 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// normalizeRuleText makes description conditions case-insensitive, with ё
+// spelled as е and runs of spaces collapsed, the way payees are matched
+func normalizeRuleText(text string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(strings.ToLower(text), "ё", "е")), " ")
+}
+
+// compiledRule is a rule ready to be matched against transactions
+type compiledRule struct {
+	models.Rule
+	contains string
+	regex    *regexp.Regexp
+}
+
+func compileRule(rule models.Rule) (*compiledRule, error) {
+	compiled := &compiledRule{Rule: rule, contains: normalizeRuleText(rule.DescriptionContains)}
+	if rule.DescriptionRegex != "" {
+		regex, err := regexp.Compile("(?i)" + rule.DescriptionRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid description regex: %w", err)
+		}
+		compiled.regex = regex
+	}
+	return compiled, nil
+}
+
+// matches tells whether the transaction meets all conditions of the rule
+func (r *compiledRule) matches(transaction models.Transaction) bool {
+	if r.contains != "" && !strings.Contains(normalizeRuleText(transaction.Description), r.contains) {
+		return false
+	}
+	if r.regex != nil && !r.regex.MatchString(transaction.Description) {
+		return false
+	}
+	if r.MinAmount != nil && transaction.Amount < *r.MinAmount {
+		return false
+	}
+	if r.MaxAmount != nil && transaction.Amount > *r.MaxAmount {
+		return false
+	}
+	if r.PayeeID != nil && (transaction.PayeeID == nil || *transaction.PayeeID != *r.PayeeID) {
+		return false
+	}
+	if r.AccountID != nil && (transaction.AccountID == nil || *transaction.AccountID != *r.AccountID) {
+		return false
+	}
+	return true
+}
+
+// firstMatchingRule returns the first rule in priority order the transaction
+// meets, or nil
+func firstMatchingRule(rules []compiledRule, transaction models.Transaction) *compiledRule {
+	for i := range rules {
+		if rules[i].matches(transaction) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// loadRules loads the active rules of the user in priority order, or the
+// given ones when ruleIDs is not empty, active or not, so a rule can be tried
+// out before it is turned on. Rules setting a category that is in the trash
+// are left out.
+func loadRules(userID uuid.UUID, ruleIDs []uuid.UUID) ([]compiledRule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM rules WHERE user_id = $1 AND deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM categories c WHERE c.id = rules.category_id AND c.deleted_at IS NOT NULL)`
+	args := []interface{}{userID}
+	if len(ruleIDs) == 0 {
+		query += ` AND is_active`
+	} else {
+		ids := make([]string, len(ruleIDs))
+		for i, id := range ruleIDs {
+			ids[i] = id.String()
+		}
+		query += ` AND id = ANY($2::uuid[])`
+		args = append(args, pq.Array(ids))
+	}
+	query += ` ORDER BY priority, created_at`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []compiledRule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		compiled, err := compileRule(*rule)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *compiled)
+	}
+
+	return rules, nil
+}
+
+// applyRulesToRequest runs the rules over a new transaction. The first
+// matching rule sets the category when none is given and adds its tags.
+func applyRulesToRequest(userID uuid.UUID, req *models.CreateTransactionRequest) error {
+	rules, err := loadRules(userID, nil)
+	if err != nil {
+		return err
+	}
+
+	rule := firstMatchingRule(rules, models.Transaction{
+		Description: req.Description,
+		Amount:      req.Amount,
+		PayeeID:     req.PayeeID,
+		AccountID:   req.AccountID,
+	})
+	if rule == nil {
+		return nil
+	}
+
+	if req.CategoryID == uuid.Nil && rule.CategoryID != nil {
+		req.CategoryID = *rule.CategoryID
+	}
+	req.Tags = append(req.Tags, rule.Tags...)
+	return nil
+}
+
+// checkRuleRequest validates the conditions and actions of a rule
+func checkRuleRequest(userID uuid.UUID, req *models.CreateRuleRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("rule name is required")
+	}
+	req.DescriptionContains = strings.TrimSpace(req.DescriptionContains)
+	req.Tags = normalizeTags(req.Tags)
+
+	if req.DescriptionContains == "" && req.DescriptionRegex == "" && req.MinAmount == nil && req.MaxAmount == nil && req.PayeeID == nil && req.AccountID == nil {
+		return fmt.Errorf("rule needs at least one condition")
+	}
+	if req.CategoryID == nil && len(req.Tags) == 0 {
+		return fmt.Errorf("rule needs a category or tags")
+	}
+	if req.MinAmount != nil && req.MaxAmount != nil && *req.MinAmount > *req.MaxAmount {
+		return fmt.Errorf("min amount is greater than max amount")
+	}
+	if _, err := compileRule(models.Rule{DescriptionRegex: req.DescriptionRegex}); err != nil {
+		return err
+	}
+
+	if req.PayeeID != nil {
+		if err := checkPayeeOwner(userID, *req.PayeeID); err != nil {
+			return err
+		}
+	}
+	if req.AccountID != nil {
+		if _, err := getAccountCurrency(userID, *req.AccountID); err != nil {
+			return err
+		}
+	}
+	if req.CategoryID != nil {
+		if err := checkCategoryOwner(userID, *req.CategoryID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rule services
+const ruleColumns = `id, user_id, name, priority, is_active, description_contains, description_regex, min_amount, max_amount, payee_id, account_id, category_id, tags, created_at, updated_at`
+
+func scanRule(row rowScanner) (*models.Rule, error) {
+	var rule models.Rule
+	err := row.Scan(&rule.ID, &rule.UserID, &rule.Name, &rule.Priority, &rule.IsActive, &rule.DescriptionContains, &rule.DescriptionRegex, &rule.MinAmount, &rule.MaxAmount, &rule.PayeeID, &rule.AccountID, &rule.CategoryID, pq.Array(&rule.Tags), &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func GetRules(userID uuid.UUID) ([]models.Rule, error) {
+	rows, err := db.Query(`SELECT `+ruleColumns+` FROM rules WHERE user_id = $1 AND deleted_at IS NULL ORDER BY priority, created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.Rule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+
+	return rules, nil
+}
+
+func GetRule(userID, id uuid.UUID) (*models.Rule, error) {
+	rule, err := scanRule(db.QueryRow(`SELECT `+ruleColumns+` FROM rules WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("rule not found")
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+func CreateRule(userID uuid.UUID, req models.CreateRuleRequest) (*models.Rule, error) {
+	if err := checkRuleRequest(userID, &req); err != nil {
+		return nil, err
+	}
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	now := time.Now()
+	query := `
+		INSERT INTO rules (id, user_id, name, priority, is_active, description_contains, description_regex, min_amount, max_amount, payee_id, account_id, category_id, tags, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $14)
+		RETURNING ` + ruleColumns
+	return scanRule(db.QueryRow(query, uuid.New(), userID, req.Name, req.Priority, isActive, req.DescriptionContains, req.DescriptionRegex, req.MinAmount, req.MaxAmount, req.PayeeID, req.AccountID, req.CategoryID, pq.Array(req.Tags), now))
+}
+
+func UpdateRule(userID, id uuid.UUID, req models.CreateRuleRequest) (*models.Rule, error) {
+	if err := checkRuleRequest(userID, &req); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE rules SET name = $1, priority = $2, is_active = COALESCE($3, is_active), description_contains = $4, description_regex = $5,
+			min_amount = $6, max_amount = $7, payee_id = $8, account_id = $9, category_id = $10, tags = $11, updated_at = $12
+		WHERE id = $13 AND user_id = $14 AND deleted_at IS NULL
+		RETURNING ` + ruleColumns
+	rule, err := scanRule(db.QueryRow(query, req.Name, req.Priority, req.IsActive, req.DescriptionContains, req.DescriptionRegex, req.MinAmount, req.MaxAmount, req.PayeeID, req.AccountID, req.CategoryID, pq.Array(req.Tags), time.Now(), id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("rule not found")
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+func DeleteRule(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindRule, userID, id)
+}
+
+// ApplyRules runs the rules over the transactions of a period. The first
+// matching rule of a transaction moves it to the rule's category and adds the
+// rule's tags. A dry run only reports what would change.
+func ApplyRules(userID uuid.UUID, req models.ApplyRulesRequest, dryRun bool) (*models.ApplyRulesResult, error) {
+	rules, err := loadRules(userID, req.RuleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := GetTransactions(userID, models.TransactionFilters{StartDate: req.StartDate, EndDate: req.EndDate})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ApplyRulesResult{DryRun: dryRun, Changes: []models.RuleMatch{}}
+	var changed []models.Transaction
+	for _, transaction := range transactions {
+		if transaction.TransferID != nil || len(transaction.Splits) > 0 {
+			continue
+		}
+		result.Checked++
+
+		rule := firstMatchingRule(rules, transaction)
+		if rule == nil {
+			continue
+		}
+
+		change := models.RuleMatch{
+			TransactionID: transaction.ID,
+			Description:   transaction.Description,
+			Date:          transaction.Date,
+			Amount:        transaction.Amount,
+			Currency:      transaction.Currency,
+			RuleID:        rule.ID,
+			RuleName:      rule.Name,
+		}
+		if rule.CategoryID != nil && (transaction.CategoryID == nil || *transaction.CategoryID != *rule.CategoryID) {
+			change.OldCategoryID = transaction.CategoryID
+			change.NewCategoryID = rule.CategoryID
+			transaction.CategoryID = rule.CategoryID
+		}
+
+		has := make(map[string]bool, len(transaction.Tags))
+		for _, tag := range transaction.Tags {
+			has[tag] = true
+		}
+		for _, tag := range rule.Tags {
+			if !has[tag] {
+				change.AddedTags = append(change.AddedTags, tag)
+				transaction.Tags = append(transaction.Tags, tag)
+			}
+		}
+
+		if change.NewCategoryID == nil && len(change.AddedTags) == 0 {
+			continue
+		}
+		result.Changes = append(result.Changes, change)
+		changed = append(changed, transaction)
+	}
+
+	if dryRun || len(changed) == 0 {
+		return result, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, transaction := range changed {
+		query := `UPDATE transactions SET category_id = $1, updated_at = $2 WHERE id = $3 AND user_id = $4 AND transfer_id IS NULL AND deleted_at IS NULL`
+		if _, err := tx.Exec(query, transaction.CategoryID, now, transaction.ID, userID); err != nil {
+			return nil, err
+		}
+		if err := setTransactionTags(tx, userID, transaction.ID, transaction.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// searchHeadlineOptions wrap matches in control characters, which are turned
+// into <mark> tags once the text is escaped, and keep a couple of fragments
+// around them. The document has those characters taken out beforehand.
+const searchHeadlineOptions = `StartSel="` + searchMarkStart + `", StopSel="` + searchMarkStop + `", MaxFragments=2, MinWords=5, MaxWords=20`
+
+const (
+	searchMarkStart = "\x02"
+	searchMarkStop  = "\x03"
+)
+
+// searchHighlight escapes a headline as HTML and marks its matches
+func searchHighlight(headline string) string {
+	return strings.NewReplacer(searchMarkStart, "<mark>", searchMarkStop, "</mark>").Replace(html.EscapeString(headline))
+}
+
+// searchCondition matches the rows of the given kind found by the query
+// parameter with the given index, $1 is the user
+func searchCondition(kind string, argIndex int) string {
+	return fmt.Sprintf(" AND id IN (SELECT id FROM search_ids($1, $%d) WHERE kind = '%s')", argIndex, kind)
+}
+
+// searchOrder orders rows by how well their description matches the query
+// parameter with the given index, from the stored tsvector
+func searchOrder(argIndex int) string {
+	return fmt.Sprintf(" ORDER BY ts_rank_cd(search_tsv, search_query($%d)) + word_similarity($%d, COALESCE(description, '')) DESC", argIndex, argIndex)
+}
+
+// Search finds transactions and planned expenses by their description,
+// category names, payee and notes, best matches first. A row matches when
+// one of those matches on its own.
+func Search(userID uuid.UUID, filters models.SearchFilters) ([]models.SearchResult, error) {
+	query := `
+		SELECT d.kind, d.id, d.date, d.amount, d.currency, d.description,
+			ts_headline('russian', translate(d.document, chr(2) || chr(3), ''), search_query($2), '` + searchHeadlineOptions + `'),
+			search_rank(d.document, $2) AS rank
+		FROM search_ids($1, $2) m
+		JOIN search_documents d ON d.kind = m.kind AND d.id = m.id
+		WHERE d.user_id = $1`
+	args := []interface{}{userID, filters.Query}
+	argIndex := 3
+
+	if len(filters.Kinds) > 0 {
+		query += fmt.Sprintf(" AND d.kind = ANY($%d)", argIndex)
+		args = append(args, pq.Array(filters.Kinds))
+		argIndex++
+	}
+
+	if filters.StartDate != nil {
+		query += fmt.Sprintf(" AND d.date >= $%d", argIndex)
+		args = append(args, *filters.StartDate)
+		argIndex++
+	}
+
+	if filters.EndDate != nil {
+		query += fmt.Sprintf(" AND d.date <= $%d", argIndex)
+		args = append(args, *filters.EndDate)
+		argIndex++
+	}
+
+	query += fmt.Sprintf(" ORDER BY rank DESC, d.date DESC LIMIT $%d", argIndex)
+	args = append(args, filters.Limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.SearchResult{}
+	for rows.Next() {
+		var result models.SearchResult
+		err := rows.Scan(&result.Kind, &result.ID, &result.Date, &result.Amount, &result.Currency, &result.Description, &result.Highlight, &result.Rank)
+		if err != nil {
+			return nil, err
+		}
+		result.Highlight = searchHighlight(result.Highlight)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,26 @@
+package services
+
+import "testing"
+
+func TestSearchHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		headline string
+		want     string
+	}{
+		{name: "plain", headline: "coffee at the station", want: "coffee at the station"},
+		{name: "one match", headline: "\x02coffee\x03 at the station", want: "<mark>coffee</mark> at the station"},
+		{name: "several matches", headline: "\x02кофе\x03 и \x02круассан\x03", want: "<mark>кофе</mark> и <mark>круассан</mark>"},
+		{name: "markup in the text is escaped", headline: `<b>"Tom & Jerry's"</b>`, want: "&lt;b&gt;&#34;Tom &amp; Jerry&#39;s&#34;&lt;/b&gt;"},
+		{name: "script in a match is escaped", headline: "\x02<script>\x03alert(1)</script>", want: "<mark>&lt;script&gt;</mark>alert(1)&lt;/script&gt;"},
+		{name: "mark tags in the text stay text", headline: "<mark>fake</mark> \x02real\x03", want: "&lt;mark&gt;fake&lt;/mark&gt; <mark>real</mark>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchHighlight(tt.headline); got != tt.want {
+				t.Errorf("searchHighlight(%q) = %q, want %q", tt.headline, got, tt.want)
+			}
+		})
+	}
+}
@@ -3,23 +3,108 @@ package services
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"fmp-core/internal/models"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 var db *sql.DB
 
+// DefaultOwnerID owns every row that existed before users were introduced
+var DefaultOwnerID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
 func SetDB(database *sql.DB) {
 	db = database
 }
 
+// isUniqueViolation tells whether the statement hit a unique index
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// User services
+
+// GetOrCreateUser runs on every request, so a known user only costs a lookup
+func GetOrCreateUser(telegramID int64) (*models.User, error) {
+	user := &models.User{}
+	query := `SELECT id, telegram_id, created_at, updated_at FROM users WHERE telegram_id = $1`
+	err := db.QueryRow(query, telegramID).Scan(&user.ID, &user.TelegramID, &user.CreatedAt, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return createUser(telegramID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// createUser adds the user along with a personal budget. When a concurrent
+// request added the user first, that user is returned.
+func createUser(telegramID int64) (*models.User, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	user := &models.User{}
+	query := `
+		INSERT INTO users (id, telegram_id, created_at, updated_at) VALUES ($1, $2, $3, $3)
+		ON CONFLICT (telegram_id) DO NOTHING
+		RETURNING id, telegram_id, created_at, updated_at
+	`
+	err = tx.QueryRow(query, uuid.New(), telegramID, time.Now()).Scan(&user.ID, &user.TelegramID, &user.CreatedAt, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return GetOrCreateUser(telegramID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := createPersonalBudget(tx, user.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ClaimDefaultOwner hands the pre-existing data over to the given Telegram user,
+// unless the default owner is already claimed or that user has signed in before.
+func ClaimDefaultOwner(telegramID int64) error {
+	query := `
+		UPDATE users SET telegram_id = $1
+		WHERE id = $2 AND telegram_id IS NULL
+			AND NOT EXISTS (SELECT 1 FROM users WHERE telegram_id = $1)
+	`
+	_, err := db.Exec(query, telegramID, DefaultOwnerID)
+	return err
+}
+
+// checkCategoryOwner makes sure the category exists and belongs to the user
+func checkCategoryOwner(userID, categoryID uuid.UUID) error {
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`, categoryID, userID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("category not found")
+	}
+	return nil
+}
+
 // Category services
-func GetCategories() ([]models.Category, error) {
-	query := `SELECT id, name, description, created_at, updated_at FROM categories ORDER BY name`
-	rows, err := db.Query(query)
+func GetCategories(userID uuid.UUID) ([]models.Category, error) {
+	query := `SELECT id, user_id, parent_id, name, description, created_at, updated_at FROM categories WHERE user_id = $1 AND deleted_at IS NULL ORDER BY name`
+	rows, err := db.Query(query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -28,7 +113,7 @@ func GetCategories() ([]models.Category, error) {
 	var categories []models.Category
 	for rows.Next() {
 		var category models.Category
-		err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.CreatedAt, &category.UpdatedAt)
+		err := rows.Scan(&category.ID, &category.UserID, &category.ParentID, &category.Name, &category.Description, &category.CreatedAt, &category.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -38,17 +123,32 @@ func GetCategories() ([]models.Category, error) {
 	return categories, nil
 }
 
-func CreateCategory(req models.CreateCategoryRequest) (*models.Category, error) {
+// GetCategoryTree returns the top-level categories with their subcategories nested
+func GetCategoryTree(userID uuid.UUID) ([]models.Category, error) {
+	categories, err := GetCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+	return buildCategoryTree(categories), nil
+}
+
+func CreateCategory(userID uuid.UUID, req models.CreateCategoryRequest) (*models.Category, error) {
 	category := &models.Category{
 		ID:          uuid.New(),
+		UserID:      userID,
+		ParentID:    req.ParentID,
 		Name:        req.Name,
 		Description: req.Description,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	query := `INSERT INTO categories (id, name, description, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`
-	_, err := db.Exec(query, category.ID, category.Name, category.Description, category.CreatedAt, category.UpdatedAt)
+	if err := checkCategoryParent(userID, category.ID, category.ParentID); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO categories (id, user_id, parent_id, name, description, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := db.Exec(query, category.ID, category.UserID, category.ParentID, category.Name, category.Description, category.CreatedAt, category.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -56,10 +156,10 @@ func CreateCategory(req models.CreateCategoryRequest) (*models.Category, error)
 	return category, nil
 }
 
-func GetCategory(id uuid.UUID) (*models.Category, error) {
+func GetCategory(userID, id uuid.UUID) (*models.Category, error) {
 	category := &models.Category{}
-	query := `SELECT id, name, description, created_at, updated_at FROM categories WHERE id = $1`
-	err := db.QueryRow(query, id).Scan(&category.ID, &category.Name, &category.Description, &category.CreatedAt, &category.UpdatedAt)
+	query := `SELECT id, user_id, parent_id, name, description, created_at, updated_at FROM categories WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	err := db.QueryRow(query, id, userID).Scan(&category.ID, &category.UserID, &category.ParentID, &category.Name, &category.Description, &category.CreatedAt, &category.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("category not found")
@@ -69,16 +169,21 @@ func GetCategory(id uuid.UUID) (*models.Category, error) {
 	return category, nil
 }
 
-func UpdateCategory(id uuid.UUID, req models.CreateCategoryRequest) (*models.Category, error) {
+func UpdateCategory(userID, id uuid.UUID, req models.CreateCategoryRequest) (*models.Category, error) {
 	category := &models.Category{
 		ID:          id,
+		ParentID:    req.ParentID,
 		Name:        req.Name,
 		Description: req.Description,
 		UpdatedAt:   time.Now(),
 	}
 
-	query := `UPDATE categories SET name = $1, description = $2, updated_at = $3 WHERE id = $4`
-	result, err := db.Exec(query, category.Name, category.Description, category.UpdatedAt, category.ID)
+	if err := checkCategoryParent(userID, category.ID, category.ParentID); err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE categories SET parent_id = $1, name = $2, description = $3, updated_at = $4 WHERE id = $5 AND user_id = $6 AND deleted_at IS NULL`
+	result, err := db.Exec(query, category.ParentID, category.Name, category.Description, category.UpdatedAt, category.ID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -92,36 +197,43 @@ func UpdateCategory(id uuid.UUID, req models.CreateCategoryRequest) (*models.Cat
 	}
 
 	// Get the updated category
-	return GetCategory(id)
+	return GetCategory(userID, id)
 }
 
-func DeleteCategory(id uuid.UUID) error {
-	query := `DELETE FROM categories WHERE id = $1`
-	result, err := db.Exec(query, id)
-	if err != nil {
-		return err
-	}
+// Transaction services
+func GetTransactions(userID uuid.UUID, filters models.TransactionFilters) ([]models.Transaction, error) {
+	query := `SELECT id, user_id, category_id, account_id, type, amount, currency, description, payee_id, date, ARRAY(SELECT tg.name FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id AND tg.deleted_at IS NULL WHERE tt.transaction_id = transactions.id ORDER BY tg.name), transfer_id, created_at, updated_at FROM transactions WHERE user_id = $1 AND deleted_at IS NULL`
+	args := []interface{}{userID}
+	argIndex := 2
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
+	// Split transactions match any of their lines
+	if filters.CategoryID != nil {
+		query += fmt.Sprintf(" AND (category_id = $%d OR EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = transactions.id AND s.category_id = $%d))", argIndex, argIndex)
+		args = append(args, *filters.CategoryID)
+		argIndex++
 	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("category not found")
+
+	if filters.AccountID != nil {
+		query += fmt.Sprintf(" AND account_id = $%d", argIndex)
+		args = append(args, *filters.AccountID)
+		argIndex++
 	}
 
-	return nil
-}
+	if filters.Type != nil {
+		query += fmt.Sprintf(" AND type = $%d", argIndex)
+		args = append(args, *filters.Type)
+		argIndex++
+	}
 
-// Transaction services
-func GetTransactions(filters models.TransactionFilters) ([]models.Transaction, error) {
-	query := `SELECT id, category_id, amount, description, date, created_at, updated_at FROM transactions WHERE 1=1`
-	args := []interface{}{}
-	argIndex := 1
+	if filters.PayeeID != nil {
+		query += fmt.Sprintf(" AND payee_id = $%d", argIndex)
+		args = append(args, *filters.PayeeID)
+		argIndex++
+	}
 
-	if filters.CategoryID != nil {
-		query += fmt.Sprintf(" AND category_id = $%d", argIndex)
-		args = append(args, *filters.CategoryID)
+	for _, tag := range filters.Tags {
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id AND tg.deleted_at IS NULL WHERE tt.transaction_id = transactions.id AND tg.name = $%d)", argIndex)
+		args = append(args, tag)
 		argIndex++
 	}
 
@@ -137,7 +249,28 @@ func GetTransactions(filters models.TransactionFilters) ([]models.Transaction, e
 		argIndex++
 	}
 
-	query += " ORDER BY date DESC"
+	if filters.ExcludeTransfers {
+		query += " AND transfer_id IS NULL"
+	}
+
+	order := "date DESC"
+	if filters.Sort == models.TransactionSortCreatedAt {
+		order = "created_at DESC"
+	}
+	if filters.Query != "" {
+		query += searchCondition(models.SearchKindTransaction, argIndex)
+		query += searchOrder(argIndex) + ", " + order
+		args = append(args, filters.Query)
+		argIndex++
+	} else {
+		query += " ORDER BY " + order
+	}
+
+	if filters.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argIndex)
+		args = append(args, filters.Limit)
+		argIndex++
+	}
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
@@ -148,61 +281,189 @@ func GetTransactions(filters models.TransactionFilters) ([]models.Transaction, e
 	var transactions []models.Transaction
 	for rows.Next() {
 		var transaction models.Transaction
-		err := rows.Scan(&transaction.ID, &transaction.CategoryID, &transaction.Amount, &transaction.Description, &transaction.Date, &transaction.CreatedAt, &transaction.UpdatedAt)
+		err := rows.Scan(&transaction.ID, &transaction.UserID, &transaction.CategoryID, &transaction.AccountID, &transaction.Type, &transaction.Amount, &transaction.Currency, &transaction.Description, &transaction.PayeeID, &transaction.Date, pq.Array(&transaction.Tags), &transaction.TransferID, &transaction.CreatedAt, &transaction.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
 		transactions = append(transactions, transaction)
 	}
 
+	if err := loadTransactionSplits(transactions); err != nil {
+		return nil, err
+	}
+
 	return transactions, nil
 }
 
-func CreateTransaction(req models.CreateTransactionRequest) (*models.Transaction, error) {
+// TransactionValidationError is returned when a transaction can not be saved
+// as given, like one without a category that no rule fills in
+type TransactionValidationError struct {
+	Reason string
+}
+
+func (e *TransactionValidationError) Error() string {
+	return e.Reason
+}
+
+// CreateTransaction matches the payee from the description when none is
+// given and then runs the rules, which may fill in the category
+func CreateTransaction(userID uuid.UUID, req models.CreateTransactionRequest) (*models.Transaction, error) {
+	if err := checkTransactionSplits(userID, &req); err != nil {
+		return nil, err
+	}
+	if req.PayeeID != nil {
+		if err := checkPayeeOwner(userID, *req.PayeeID); err != nil {
+			return nil, err
+		}
+	} else {
+		payeeID, err := matchPayee(db, userID, req.Description)
+		if err != nil {
+			return nil, err
+		}
+		req.PayeeID = payeeID
+	}
+	if err := applyRulesToRequest(userID, &req); err != nil {
+		return nil, err
+	}
+	if req.CategoryID == uuid.Nil {
+		return nil, &TransactionValidationError{Reason: "category is required, no rule matched the transaction"}
+	}
+	if err := checkCategoryOwner(userID, req.CategoryID); err != nil {
+		return nil, err
+	}
+	if req.AccountID != nil {
+		accountCurrency, err := getAccountCurrency(userID, *req.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if req.Currency == "" {
+			req.Currency = accountCurrency
+		}
+	}
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+
+	if req.Type == "" {
+		req.Type = models.TransactionTypeExpense
+	}
+
 	transaction := &models.Transaction{
 		ID:          uuid.New(),
-		CategoryID:  req.CategoryID,
+		UserID:      userID,
+		CategoryID:  &req.CategoryID,
+		AccountID:   req.AccountID,
+		Type:        req.Type,
 		Amount:      req.Amount,
+		Currency:    req.Currency,
 		Description: req.Description,
+		PayeeID:     req.PayeeID,
 		Date:        req.Date,
+		Tags:        normalizeTags(req.Tags),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	query := `INSERT INTO transactions (id, category_id, amount, description, date, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := db.Exec(query, transaction.ID, transaction.CategoryID, transaction.Amount, transaction.Description, transaction.Date, transaction.CreatedAt, transaction.UpdatedAt)
+	tx, err := db.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return transaction, nil
+	query := `INSERT INTO transactions (id, user_id, category_id, account_id, type, amount, currency, description, payee_id, date, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	_, err = tx.Exec(query, transaction.ID, transaction.UserID, transaction.CategoryID, transaction.AccountID, transaction.Type, transaction.Amount, transaction.Currency, transaction.Description, transaction.PayeeID, transaction.Date, transaction.CreatedAt, transaction.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setTransactionTags(tx, userID, transaction.ID, transaction.Tags); err != nil {
+		return nil, err
+	}
+
+	transaction.Splits, err = setTransactionSplits(tx, transaction.ID, req.Splits)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return GetTransaction(userID, transaction.ID)
 }
 
-func GetTransaction(id uuid.UUID) (*models.Transaction, error) {
+func GetTransaction(userID, id uuid.UUID) (*models.Transaction, error) {
 	transaction := &models.Transaction{}
-	query := `SELECT id, category_id, amount, description, date, created_at, updated_at FROM transactions WHERE id = $1`
-	err := db.QueryRow(query, id).Scan(&transaction.ID, &transaction.CategoryID, &transaction.Amount, &transaction.Description, &transaction.Date, &transaction.CreatedAt, &transaction.UpdatedAt)
+	query := `SELECT id, user_id, category_id, account_id, type, amount, currency, description, payee_id, date, ARRAY(SELECT tg.name FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id AND tg.deleted_at IS NULL WHERE tt.transaction_id = transactions.id ORDER BY tg.name), transfer_id, created_at, updated_at FROM transactions WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	err := db.QueryRow(query, id, userID).Scan(&transaction.ID, &transaction.UserID, &transaction.CategoryID, &transaction.AccountID, &transaction.Type, &transaction.Amount, &transaction.Currency, &transaction.Description, &transaction.PayeeID, &transaction.Date, pq.Array(&transaction.Tags), &transaction.TransferID, &transaction.CreatedAt, &transaction.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("transaction not found")
 		}
 		return nil, err
 	}
-	return transaction, nil
+
+	transactions := []models.Transaction{*transaction}
+	if err := loadTransactionSplits(transactions); err != nil {
+		return nil, err
+	}
+	return &transactions[0], nil
 }
 
-func UpdateTransaction(id uuid.UUID, req models.CreateTransactionRequest) (*models.Transaction, error) {
+func UpdateTransaction(userID, id uuid.UUID, req models.CreateTransactionRequest) (*models.Transaction, error) {
+	if err := checkTransactionSplits(userID, &req); err != nil {
+		return nil, err
+	}
+	if req.CategoryID == uuid.Nil {
+		return nil, &TransactionValidationError{Reason: "category is required"}
+	}
+	if err := checkCategoryOwner(userID, req.CategoryID); err != nil {
+		return nil, err
+	}
+	if req.PayeeID != nil {
+		if err := checkPayeeOwner(userID, *req.PayeeID); err != nil {
+			return nil, err
+		}
+	}
+	if req.AccountID != nil {
+		accountCurrency, err := getAccountCurrency(userID, *req.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if req.Currency == "" {
+			req.Currency = accountCurrency
+		}
+	}
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+
+	if req.Type == "" {
+		req.Type = models.TransactionTypeExpense
+	}
+
 	transaction := &models.Transaction{
 		ID:          id,
-		CategoryID:  req.CategoryID,
+		CategoryID:  &req.CategoryID,
+		AccountID:   req.AccountID,
+		Type:        req.Type,
 		Amount:      req.Amount,
+		Currency:    req.Currency,
 		Description: req.Description,
+		PayeeID:     req.PayeeID,
 		Date:        req.Date,
 		UpdatedAt:   time.Now(),
 	}
 
-	query := `UPDATE transactions SET category_id = $1, amount = $2, description = $3, date = $4, updated_at = $5 WHERE id = $6`
-	result, err := db.Exec(query, transaction.CategoryID, transaction.Amount, transaction.Description, transaction.Date, transaction.UpdatedAt, transaction.ID)
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Transfer legs only change together with their transfer
+	query := `UPDATE transactions SET category_id = $1, account_id = $2, type = $3, amount = $4, currency = $5, description = $6, payee_id = $7, date = $8, updated_at = $9 WHERE id = $10 AND user_id = $11 AND transfer_id IS NULL AND deleted_at IS NULL`
+	result, err := tx.Exec(query, transaction.CategoryID, transaction.AccountID, transaction.Type, transaction.Amount, transaction.Currency, transaction.Description, transaction.PayeeID, transaction.Date, transaction.UpdatedAt, transaction.ID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -215,32 +476,40 @@ func UpdateTransaction(id uuid.UUID, req models.CreateTransactionRequest) (*mode
 		return nil, fmt.Errorf("transaction not found")
 	}
 
-	return GetTransaction(id)
-}
+	if transaction.PayeeID == nil {
+		if _, err := matchTransactionPayees(tx, userID, &id); err != nil {
+			return nil, err
+		}
+	}
 
-func DeleteTransaction(id uuid.UUID) error {
-	query := `DELETE FROM transactions WHERE id = $1`
-	result, err := db.Exec(query, id)
-	if err != nil {
-		return err
+	if req.Tags != nil {
+		if err := setTransactionTags(tx, userID, id, req.Tags); err != nil {
+			return nil, err
+		}
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
+	if _, err := setTransactionSplits(tx, id, req.Splits); err != nil {
+		return nil, err
 	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("transaction not found")
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return GetTransaction(userID, id)
+}
+
+// DeleteTransaction moves the transaction to the trash. Transfer legs are
+// left alone, they go with their transfer.
+func DeleteTransaction(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindTransaction, userID, id)
 }
 
 // Planned Expense services
-func GetPlannedExpenses(filters models.PlannedExpenseFilters) ([]models.PlannedExpense, error) {
-	query := `SELECT id, category_id, amount, description, planned_date, is_completed, created_at, updated_at FROM planned_expenses WHERE 1=1`
-	args := []interface{}{}
-	argIndex := 1
+func GetPlannedExpenses(userID uuid.UUID, filters models.PlannedExpenseFilters) ([]models.PlannedExpense, error) {
+	query := `SELECT id, user_id, category_id, amount, currency, description, planned_date, is_completed, ARRAY(SELECT tg.name FROM planned_expense_tags pt JOIN tags tg ON tg.id = pt.tag_id AND tg.deleted_at IS NULL WHERE pt.planned_expense_id = planned_expenses.id ORDER BY tg.name), recurring_expense_id, transaction_id, created_at, updated_at FROM planned_expenses WHERE user_id = $1 AND deleted_at IS NULL`
+	args := []interface{}{userID}
+	argIndex := 2
 
 	if filters.CategoryID != nil {
 		query += fmt.Sprintf(" AND category_id = $%d", argIndex)
@@ -248,6 +517,12 @@ func GetPlannedExpenses(filters models.PlannedExpenseFilters) ([]models.PlannedE
 		argIndex++
 	}
 
+	if filters.RecurringExpenseID != nil {
+		query += fmt.Sprintf(" AND recurring_expense_id = $%d", argIndex)
+		args = append(args, *filters.RecurringExpenseID)
+		argIndex++
+	}
+
 	if filters.StartDate != nil {
 		query += fmt.Sprintf(" AND planned_date >= $%d", argIndex)
 		args = append(args, *filters.StartDate)
@@ -266,7 +541,14 @@ func GetPlannedExpenses(filters models.PlannedExpenseFilters) ([]models.PlannedE
 		argIndex++
 	}
 
-	query += " ORDER BY planned_date ASC"
+	if filters.Query != "" {
+		query += searchCondition(models.SearchKindPlannedExpense, argIndex)
+		query += searchOrder(argIndex) + ", planned_date ASC"
+		args = append(args, filters.Query)
+		argIndex++
+	} else {
+		query += " ORDER BY planned_date ASC"
+	}
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
@@ -277,7 +559,7 @@ func GetPlannedExpenses(filters models.PlannedExpenseFilters) ([]models.PlannedE
 	var expenses []models.PlannedExpense
 	for rows.Next() {
 		var expense models.PlannedExpense
-		err := rows.Scan(&expense.ID, &expense.CategoryID, &expense.Amount, &expense.Description, &expense.PlannedDate, &expense.IsCompleted, &expense.CreatedAt, &expense.UpdatedAt)
+		err := rows.Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Amount, &expense.Currency, &expense.Description, &expense.PlannedDate, &expense.IsCompleted, pq.Array(&expense.Tags), &expense.RecurringExpenseID, &expense.TransactionID, &expense.CreatedAt, &expense.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -287,31 +569,55 @@ func GetPlannedExpenses(filters models.PlannedExpenseFilters) ([]models.PlannedE
 	return expenses, nil
 }
 
-func CreatePlannedExpense(req models.CreatePlannedExpenseRequest) (*models.PlannedExpense, error) {
+func CreatePlannedExpense(userID uuid.UUID, req models.CreatePlannedExpenseRequest) (*models.PlannedExpense, error) {
+	if err := checkCategoryOwner(userID, req.CategoryID); err != nil {
+		return nil, err
+	}
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+
 	expense := &models.PlannedExpense{
 		ID:          uuid.New(),
+		UserID:      userID,
 		CategoryID:  req.CategoryID,
 		Amount:      req.Amount,
+		Currency:    req.Currency,
 		Description: req.Description,
 		PlannedDate: req.PlannedDate,
 		IsCompleted: false,
+		Tags:        normalizeTags(req.Tags),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	query := `INSERT INTO planned_expenses (id, category_id, amount, description, planned_date, is_completed, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-	_, err := db.Exec(query, expense.ID, expense.CategoryID, expense.Amount, expense.Description, expense.PlannedDate, expense.IsCompleted, expense.CreatedAt, expense.UpdatedAt)
+	tx, err := db.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO planned_expenses (id, user_id, category_id, amount, currency, description, planned_date, is_completed, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err = tx.Exec(query, expense.ID, expense.UserID, expense.CategoryID, expense.Amount, expense.Currency, expense.Description, expense.PlannedDate, expense.IsCompleted, expense.CreatedAt, expense.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setPlannedExpenseTags(tx, userID, expense.ID, expense.Tags); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
 	return expense, nil
 }
 
-func GetPlannedExpense(id uuid.UUID) (*models.PlannedExpense, error) {
+func GetPlannedExpense(userID, id uuid.UUID) (*models.PlannedExpense, error) {
 	expense := &models.PlannedExpense{}
-	query := `SELECT id, category_id, amount, description, planned_date, is_completed, created_at, updated_at FROM planned_expenses WHERE id = $1`
-	err := db.QueryRow(query, id).Scan(&expense.ID, &expense.CategoryID, &expense.Amount, &expense.Description, &expense.PlannedDate, &expense.IsCompleted, &expense.CreatedAt, &expense.UpdatedAt)
+	query := `SELECT id, user_id, category_id, amount, currency, description, planned_date, is_completed, ARRAY(SELECT tg.name FROM planned_expense_tags pt JOIN tags tg ON tg.id = pt.tag_id AND tg.deleted_at IS NULL WHERE pt.planned_expense_id = planned_expenses.id ORDER BY tg.name), recurring_expense_id, transaction_id, created_at, updated_at FROM planned_expenses WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	err := db.QueryRow(query, id, userID).Scan(&expense.ID, &expense.UserID, &expense.CategoryID, &expense.Amount, &expense.Currency, &expense.Description, &expense.PlannedDate, &expense.IsCompleted, pq.Array(&expense.Tags), &expense.RecurringExpenseID, &expense.TransactionID, &expense.CreatedAt, &expense.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("planned expense not found")
@@ -321,18 +627,32 @@ func GetPlannedExpense(id uuid.UUID) (*models.PlannedExpense, error) {
 	return expense, nil
 }
 
-func UpdatePlannedExpense(id uuid.UUID, req models.CreatePlannedExpenseRequest) (*models.PlannedExpense, error) {
+func UpdatePlannedExpense(userID, id uuid.UUID, req models.CreatePlannedExpenseRequest) (*models.PlannedExpense, error) {
+	if err := checkCategoryOwner(userID, req.CategoryID); err != nil {
+		return nil, err
+	}
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+
 	expense := &models.PlannedExpense{
 		ID:          id,
 		CategoryID:  req.CategoryID,
 		Amount:      req.Amount,
+		Currency:    req.Currency,
 		Description: req.Description,
 		PlannedDate: req.PlannedDate,
 		UpdatedAt:   time.Now(),
 	}
 
-	query := `UPDATE planned_expenses SET category_id = $1, amount = $2, description = $3, planned_date = $4, updated_at = $5 WHERE id = $6`
-	result, err := db.Exec(query, expense.CategoryID, expense.Amount, expense.Description, expense.PlannedDate, expense.UpdatedAt, expense.ID)
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE planned_expenses SET category_id = $1, amount = $2, currency = $3, description = $4, planned_date = $5, updated_at = $6 WHERE id = $7 AND user_id = $8 AND deleted_at IS NULL`
+	result, err := tx.Exec(query, expense.CategoryID, expense.Amount, expense.Currency, expense.Description, expense.PlannedDate, expense.UpdatedAt, expense.ID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -345,32 +665,68 @@ func UpdatePlannedExpense(id uuid.UUID, req models.CreatePlannedExpenseRequest)
 		return nil, fmt.Errorf("planned expense not found")
 	}
 
-	return GetPlannedExpense(id)
+	if req.Tags != nil {
+		if err := setPlannedExpenseTags(tx, userID, id, req.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return GetPlannedExpense(userID, id)
 }
 
-func DeletePlannedExpense(id uuid.UUID) error {
-	query := `DELETE FROM planned_expenses WHERE id = $1`
-	result, err := db.Exec(query, id)
+func DeletePlannedExpense(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindPlannedExpense, userID, id)
+}
+
+// Planned Income services
+const plannedIncomeColumns = `id, user_id, name, category_id, amount, currency, description, month, year, created_at, updated_at`
+
+func scanPlannedIncome(row rowScanner) (*models.PlannedIncome, error) {
+	var income models.PlannedIncome
+	err := row.Scan(&income.ID, &income.UserID, &income.Name, &income.CategoryID, &income.Amount, &income.Currency, &income.Description, &income.Month, &income.Year, &income.CreatedAt, &income.UpdatedAt)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return &income, nil
+}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
+// checkPlannedIncomeRequest names unnamed sources after their description and
+// makes sure the linked category belongs to the user
+func checkPlannedIncomeRequest(userID uuid.UUID, req *models.CreatePlannedIncomeRequest) error {
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
 	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("planned expense not found")
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		req.Name = strings.TrimSpace(req.Description)
+	}
+	if req.Name == "" {
+		req.Name = "Income"
+	}
+	if len([]rune(req.Name)) > 255 {
+		req.Name = string([]rune(req.Name)[:255])
+	}
+	if req.CategoryID != nil {
+		return checkCategoryOwner(userID, *req.CategoryID)
 	}
-
 	return nil
 }
 
-// Planned Income services
-func GetPlannedIncome(filters models.PlannedIncomeFilters) ([]models.PlannedIncome, error) {
-	query := `SELECT id, amount, description, month, year, created_at, updated_at FROM planned_incomes WHERE 1=1`
-	args := []interface{}{}
-	argIndex := 1
+// GetPlannedIncome lists the planned income sources, newest month first
+func GetPlannedIncome(userID uuid.UUID, filters models.PlannedIncomeFilters) ([]models.PlannedIncome, error) {
+	query := `SELECT ` + plannedIncomeColumns + ` FROM planned_incomes WHERE user_id = $1 AND deleted_at IS NULL`
+	args := []interface{}{userID}
+	argIndex := 2
+
+	if filters.CategoryID != nil {
+		query += fmt.Sprintf(" AND category_id = $%d", argIndex)
+		args = append(args, *filters.CategoryID)
+		argIndex++
+	}
 
 	if filters.Month != nil {
 		query += fmt.Sprintf(" AND month = $%d", argIndex)
@@ -384,7 +740,7 @@ func GetPlannedIncome(filters models.PlannedIncomeFilters) ([]models.PlannedInco
 		argIndex++
 	}
 
-	query += " ORDER BY year DESC, month DESC"
+	query += " ORDER BY year DESC, month DESC, amount DESC, name"
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
@@ -394,21 +750,28 @@ func GetPlannedIncome(filters models.PlannedIncomeFilters) ([]models.PlannedInco
 
 	var incomes []models.PlannedIncome
 	for rows.Next() {
-		var income models.PlannedIncome
-		err := rows.Scan(&income.ID, &income.Amount, &income.Description, &income.Month, &income.Year, &income.CreatedAt, &income.UpdatedAt)
+		income, err := scanPlannedIncome(rows)
 		if err != nil {
 			return nil, err
 		}
-		incomes = append(incomes, income)
+		incomes = append(incomes, *income)
 	}
 
 	return incomes, nil
 }
 
-func CreatePlannedIncome(req models.CreatePlannedIncomeRequest) (*models.PlannedIncome, error) {
+func CreatePlannedIncome(userID uuid.UUID, req models.CreatePlannedIncomeRequest) (*models.PlannedIncome, error) {
+	if err := checkPlannedIncomeRequest(userID, &req); err != nil {
+		return nil, err
+	}
+
 	income := &models.PlannedIncome{
 		ID:          uuid.New(),
+		UserID:      userID,
+		Name:        req.Name,
+		CategoryID:  req.CategoryID,
 		Amount:      req.Amount,
+		Currency:    req.Currency,
 		Description: req.Description,
 		Month:       req.Month,
 		Year:        req.Year,
@@ -416,8 +779,8 @@ func CreatePlannedIncome(req models.CreatePlannedIncomeRequest) (*models.Planned
 		UpdatedAt:   time.Now(),
 	}
 
-	query := `INSERT INTO planned_incomes (id, amount, description, month, year, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := db.Exec(query, income.ID, income.Amount, income.Description, income.Month, income.Year, income.CreatedAt, income.UpdatedAt)
+	query := `INSERT INTO planned_incomes (id, user_id, name, category_id, amount, currency, description, month, year, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := db.Exec(query, income.ID, income.UserID, income.Name, income.CategoryID, income.Amount, income.Currency, income.Description, income.Month, income.Year, income.CreatedAt, income.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -425,18 +788,25 @@ func CreatePlannedIncome(req models.CreatePlannedIncomeRequest) (*models.Planned
 	return income, nil
 }
 
-func UpdatePlannedIncome(id uuid.UUID, req models.CreatePlannedIncomeRequest) (*models.PlannedIncome, error) {
+func UpdatePlannedIncome(userID, id uuid.UUID, req models.CreatePlannedIncomeRequest) (*models.PlannedIncome, error) {
+	if err := checkPlannedIncomeRequest(userID, &req); err != nil {
+		return nil, err
+	}
+
 	income := &models.PlannedIncome{
 		ID:          id,
+		Name:        req.Name,
+		CategoryID:  req.CategoryID,
 		Amount:      req.Amount,
+		Currency:    req.Currency,
 		Description: req.Description,
 		Month:       req.Month,
 		Year:        req.Year,
 		UpdatedAt:   time.Now(),
 	}
 
-	query := `UPDATE planned_incomes SET amount = $1, description = $2, month = $3, year = $4, updated_at = $5 WHERE id = $6`
-	result, err := db.Exec(query, income.Amount, income.Description, income.Month, income.Year, income.UpdatedAt, income.ID)
+	query := `UPDATE planned_incomes SET name = $1, category_id = $2, amount = $3, currency = $4, description = $5, month = $6, year = $7, updated_at = $8 WHERE id = $9 AND user_id = $10 AND deleted_at IS NULL`
+	result, err := db.Exec(query, income.Name, income.CategoryID, income.Amount, income.Currency, income.Description, income.Month, income.Year, income.UpdatedAt, income.ID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -450,38 +820,18 @@ func UpdatePlannedIncome(id uuid.UUID, req models.CreatePlannedIncomeRequest) (*
 	}
 
 	// Get the updated income
-	query = `SELECT id, amount, description, month, year, created_at, updated_at FROM planned_incomes WHERE id = $1`
-	err = db.QueryRow(query, id).Scan(&income.ID, &income.Amount, &income.Description, &income.Month, &income.Year, &income.CreatedAt, &income.UpdatedAt)
-	if err != nil {
-		return nil, err
-	}
-
-	return income, nil
+	return scanPlannedIncome(db.QueryRow(`SELECT `+plannedIncomeColumns+` FROM planned_incomes WHERE id = $1`, id))
 }
 
-func DeletePlannedIncome(id uuid.UUID) error {
-	query := `DELETE FROM planned_incomes WHERE id = $1`
-	result, err := db.Exec(query, id)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("planned income not found")
-	}
-
-	return nil
+func DeletePlannedIncome(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindPlannedIncome, userID, id)
 }
 
 // Category Limit services
-func GetCategoryLimits(filters models.CategoryLimitFilters) ([]models.CategoryLimit, error) {
-	query := `SELECT id, category_id, limit_amount, month, year, created_at, updated_at FROM category_limits WHERE 1=1`
-	args := []interface{}{}
-	argIndex := 1
+func GetCategoryLimits(userID uuid.UUID, filters models.CategoryLimitFilters) ([]models.CategoryLimit, error) {
+	query := `SELECT id, user_id, category_id, limit_amount, currency, rollover, month, year, created_at, updated_at FROM category_limits WHERE user_id = $1 AND deleted_at IS NULL`
+	args := []interface{}{userID}
+	argIndex := 2
 
 	if filters.CategoryID != nil {
 		query += fmt.Sprintf(" AND category_id = $%d", argIndex)
@@ -512,7 +862,7 @@ func GetCategoryLimits(filters models.CategoryLimitFilters) ([]models.CategoryLi
 	var limits []models.CategoryLimit
 	for rows.Next() {
 		var limit models.CategoryLimit
-		err := rows.Scan(&limit.ID, &limit.CategoryID, &limit.Limit, &limit.Month, &limit.Year, &limit.CreatedAt, &limit.UpdatedAt)
+		err := rows.Scan(&limit.ID, &limit.UserID, &limit.CategoryID, &limit.Limit, &limit.Currency, &limit.Rollover, &limit.Month, &limit.Year, &limit.CreatedAt, &limit.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -522,19 +872,29 @@ func GetCategoryLimits(filters models.CategoryLimitFilters) ([]models.CategoryLi
 	return limits, nil
 }
 
-func CreateCategoryLimit(req models.CreateCategoryLimitRequest) (*models.CategoryLimit, error) {
+func CreateCategoryLimit(userID uuid.UUID, req models.CreateCategoryLimitRequest) (*models.CategoryLimit, error) {
+	if err := checkCategoryOwner(userID, req.CategoryID); err != nil {
+		return nil, err
+	}
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+
 	limit := &models.CategoryLimit{
 		ID:         uuid.New(),
+		UserID:     userID,
 		CategoryID: req.CategoryID,
 		Limit:      req.Limit,
+		Currency:   req.Currency,
+		Rollover:   req.Rollover,
 		Month:      req.Month,
 		Year:       req.Year,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
 
-	query := `INSERT INTO category_limits (id, category_id, limit_amount, month, year, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := db.Exec(query, limit.ID, limit.CategoryID, limit.Limit, limit.Month, limit.Year, limit.CreatedAt, limit.UpdatedAt)
+	query := `INSERT INTO category_limits (id, user_id, category_id, limit_amount, currency, rollover, month, year, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err := db.Exec(query, limit.ID, limit.UserID, limit.CategoryID, limit.Limit, limit.Currency, limit.Rollover, limit.Month, limit.Year, limit.CreatedAt, limit.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -542,18 +902,27 @@ func CreateCategoryLimit(req models.CreateCategoryLimitRequest) (*models.Categor
 	return limit, nil
 }
 
-func UpdateCategoryLimit(id uuid.UUID, req models.CreateCategoryLimitRequest) (*models.CategoryLimit, error) {
+func UpdateCategoryLimit(userID, id uuid.UUID, req models.CreateCategoryLimitRequest) (*models.CategoryLimit, error) {
+	if err := checkCategoryOwner(userID, req.CategoryID); err != nil {
+		return nil, err
+	}
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+
 	limit := &models.CategoryLimit{
 		ID:         id,
 		CategoryID: req.CategoryID,
 		Limit:      req.Limit,
+		Currency:   req.Currency,
+		Rollover:   req.Rollover,
 		Month:      req.Month,
 		Year:       req.Year,
 		UpdatedAt:  time.Now(),
 	}
 
-	query := `UPDATE category_limits SET category_id = $1, limit_amount = $2, month = $3, year = $4, updated_at = $5 WHERE id = $6`
-	result, err := db.Exec(query, limit.CategoryID, limit.Limit, limit.Month, limit.Year, limit.UpdatedAt, limit.ID)
+	query := `UPDATE category_limits SET category_id = $1, limit_amount = $2, currency = $3, rollover = $4, month = $5, year = $6, updated_at = $7 WHERE id = $8 AND user_id = $9 AND deleted_at IS NULL`
+	result, err := db.Exec(query, limit.CategoryID, limit.Limit, limit.Currency, limit.Rollover, limit.Month, limit.Year, limit.UpdatedAt, limit.ID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -567,8 +936,8 @@ func UpdateCategoryLimit(id uuid.UUID, req models.CreateCategoryLimitRequest) (*
 	}
 
 	// Get the updated limit
-	query = `SELECT id, category_id, limit_amount, month, year, created_at, updated_at FROM category_limits WHERE id = $1`
-	err = db.QueryRow(query, id).Scan(&limit.ID, &limit.CategoryID, &limit.Limit, &limit.Month, &limit.Year, &limit.CreatedAt, &limit.UpdatedAt)
+	query = `SELECT id, user_id, category_id, limit_amount, currency, rollover, month, year, created_at, updated_at FROM category_limits WHERE id = $1`
+	err = db.QueryRow(query, id).Scan(&limit.ID, &limit.UserID, &limit.CategoryID, &limit.Limit, &limit.Currency, &limit.Rollover, &limit.Month, &limit.Year, &limit.CreatedAt, &limit.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -576,97 +945,101 @@ func UpdateCategoryLimit(id uuid.UUID, req models.CreateCategoryLimitRequest) (*
 	return limit, nil
 }
 
-func DeleteCategoryLimit(id uuid.UUID) error {
-	query := `DELETE FROM category_limits WHERE id = $1`
-	result, err := db.Exec(query, id)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("category limit not found")
-	}
-
-	return nil
+func DeleteCategoryLimit(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindCategoryLimit, userID, id)
 }
 
 // Analytics services
-func GetMonthlySummary(month, year int) (*models.MonthlySummary, error) {
+// GetMonthlySummary converts transactions to currency at the rate of their
+// date and limits at the rate of the last day of the month. Split transactions
+// count each line in its own category.
+func GetMonthlySummary(userID uuid.UUID, month, year int, currency string) (*models.MonthlySummary, error) {
 	summary := &models.MonthlySummary{
-		Month: month,
-		Year:  year,
+		Month:    month,
+		Year:     year,
+		Currency: currency,
 	}
+	monthEnd := time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC)
 
 	// Get category summaries for the month
 	query := `
 		SELECT 
 			c.id as category_id,
+			c.parent_id as parent_id,
 			c.name as category_name,
-			COALESCE(SUM(t.amount), 0) as amount,
-			cl.limit_amount as limit_amount
+			COALESCE(SUM(convert_amount(c.user_id, t.amount, t.currency, $4, t.date::date)) FILTER (WHERE t.type = 'expense'), 0) as amount,
+			COALESCE(SUM(convert_amount(c.user_id, t.amount, t.currency, $4, t.date::date)) FILTER (WHERE t.type = 'income'), 0) as income,
+			convert_amount(c.user_id, cl.limit_amount, cl.currency, $4, $5) as base_limit,
+			CASE WHEN cl.id IS NOT NULL THEN convert_amount(c.user_id, limit_carry_over(cl.id), cl.currency, $4, $5) END as carried
 		FROM categories c
-		LEFT JOIN transactions t ON c.id = t.category_id 
+		LEFT JOIN transaction_lines t ON c.id = t.category_id 
 			AND EXTRACT(MONTH FROM t.date) = $1 
 			AND EXTRACT(YEAR FROM t.date) = $2
 		LEFT JOIN category_limits cl ON c.id = cl.category_id 
 			AND cl.month = $1 
 			AND cl.year = $2
-		GROUP BY c.id, c.name, cl.limit_amount
-		ORDER BY amount DESC
+			AND cl.deleted_at IS NULL
+		WHERE c.user_id = $3 AND c.deleted_at IS NULL
+		GROUP BY c.id, c.parent_id, c.name, cl.id, cl.limit_amount, cl.currency
 	`
 
-	rows, err := db.Query(query, month, year)
+	rows, err := db.Query(query, month, year, userID, currency, monthEnd)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var total float64
 	for rows.Next() {
 		var categorySummary models.CategorySummary
-		var limitAmount sql.NullFloat64
 
-		err := rows.Scan(&categorySummary.CategoryID, &categorySummary.CategoryName, &categorySummary.Amount, &limitAmount)
+		err := rows.Scan(&categorySummary.CategoryID, &categorySummary.ParentID, &categorySummary.CategoryName, &categorySummary.Amount, &categorySummary.Income, &categorySummary.BaseLimit, &categorySummary.Carried)
 		if err != nil {
 			return nil, err
 		}
+		if categorySummary.BaseLimit != nil {
+			effective := *categorySummary.BaseLimit + *categorySummary.Carried
+			categorySummary.Limit = &effective
+		}
+		summary.Categories = append(summary.Categories, categorySummary)
+	}
 
-		if limitAmount.Valid {
-			categorySummary.Limit = &limitAmount.Float64
-			categorySummary.IsExceeded = categorySummary.Amount > limitAmount.Float64
+	// Subcategories are included in their parents, so only top-level ones add up to the totals
+	rollUpCategorySummaries(summary.Categories)
+	for _, categorySummary := range summary.Categories {
+		if categorySummary.ParentID == nil {
+			summary.Expenses += categorySummary.Amount
+			summary.Income += categorySummary.Income
 		}
+	}
 
-		summary.Categories = append(summary.Categories, categorySummary)
-		total += categorySummary.Amount
+	summary.Total = summary.Expenses
+	summary.Net = summary.Income - summary.Expenses
+
+	query = `SELECT COALESCE(SUM(convert_amount(user_id, amount, currency, $4, $5)), 0) FROM planned_incomes WHERE user_id = $1 AND month = $2 AND year = $3 AND deleted_at IS NULL`
+	if err := db.QueryRow(query, userID, month, year, currency, monthEnd).Scan(&summary.PlannedIncome); err != nil {
+		return nil, err
 	}
 
-	summary.Total = total
 	return summary, nil
 }
 
-func GetCategorySummary(filters models.TransactionFilters) ([]models.CategorySummary, error) {
+// GetCategorySummary converts transactions to currency at the rate of their date.
+// Split transactions count each line in its own category.
+func GetCategorySummary(userID uuid.UUID, filters models.TransactionFilters, currency string) ([]models.CategorySummary, error) {
 	query := `
 		SELECT 
 			c.id as category_id,
+			c.parent_id as parent_id,
 			c.name as category_name,
-			COALESCE(SUM(t.amount), 0) as amount
+			COALESCE(SUM(convert_amount(c.user_id, t.amount, t.currency, $2, t.date::date)) FILTER (WHERE t.type = 'expense'), 0) as amount,
+			COALESCE(SUM(convert_amount(c.user_id, t.amount, t.currency, $2, t.date::date)) FILTER (WHERE t.type = 'income'), 0) as income
 		FROM categories c
-		LEFT JOIN transactions t ON c.id = t.category_id
-		WHERE 1=1
+		LEFT JOIN transaction_lines t ON c.id = t.category_id
 	`
-	args := []interface{}{}
-	argIndex := 1
-
-	if filters.CategoryID != nil {
-		query += fmt.Sprintf(" AND c.id = $%d", argIndex)
-		args = append(args, *filters.CategoryID)
-		argIndex++
-	}
+	args := []interface{}{userID, currency}
+	argIndex := 3
 
+	// Date filters belong to the join so that categories without transactions still show up
 	if filters.StartDate != nil {
 		query += fmt.Sprintf(" AND t.date >= $%d", argIndex)
 		args = append(args, *filters.StartDate)
@@ -679,7 +1052,7 @@ func GetCategorySummary(filters models.TransactionFilters) ([]models.CategorySum
 		argIndex++
 	}
 
-	query += " GROUP BY c.id, c.name ORDER BY amount DESC"
+	query += " WHERE c.user_id = $1 AND c.deleted_at IS NULL GROUP BY c.id, c.parent_id, c.name"
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
@@ -687,22 +1060,33 @@ func GetCategorySummary(filters models.TransactionFilters) ([]models.CategorySum
 	}
 	defer rows.Close()
 
-	var summaries []models.CategorySummary
+	summaries := []models.CategorySummary{}
 	for rows.Next() {
 		var summary models.CategorySummary
-		err := rows.Scan(&summary.CategoryID, &summary.CategoryName, &summary.Amount)
+		err := rows.Scan(&summary.CategoryID, &summary.ParentID, &summary.CategoryName, &summary.Amount, &summary.Income)
 		if err != nil {
 			return nil, err
 		}
 		summaries = append(summaries, summary)
 	}
 
+	// Roll up over all categories first, the filtered one needs its subcategories
+	rollUpCategorySummaries(summaries)
+	if filters.CategoryID != nil {
+		for _, summary := range summaries {
+			if summary.CategoryID == *filters.CategoryID {
+				return []models.CategorySummary{summary}, nil
+			}
+		}
+		return []models.CategorySummary{}, nil
+	}
+
 	return summaries, nil
 }
 
-func GetLimitExceeded() ([]models.LimitExceeded, error) {
-	query := `SELECT id, category_id, limit_amount, actual_amount, month, year, created_at FROM limit_exceeded ORDER BY created_at DESC`
-	rows, err := db.Query(query)
+func GetLimitExceeded(userID uuid.UUID) ([]models.LimitExceeded, error) {
+	query := `SELECT id, user_id, category_id, limit_amount, actual_amount, month, year, created_at FROM limit_exceeded WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := db.Query(query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -711,7 +1095,7 @@ func GetLimitExceeded() ([]models.LimitExceeded, error) {
 	var records []models.LimitExceeded
 	for rows.Next() {
 		var record models.LimitExceeded
-		err := rows.Scan(&record.ID, &record.CategoryID, &record.Limit, &record.Actual, &record.Month, &record.Year, &record.CreatedAt)
+		err := rows.Scan(&record.ID, &record.UserID, &record.CategoryID, &record.Limit, &record.Actual, &record.Month, &record.Year, &record.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -722,9 +1106,9 @@ func GetLimitExceeded() ([]models.LimitExceeded, error) {
 }
 
 // Notification services
-func GetNotifications() ([]models.Notification, error) {
-	query := `SELECT id, type, title, message, is_read, created_at FROM notifications ORDER BY created_at DESC`
-	rows, err := db.Query(query)
+func GetNotifications(userID uuid.UUID) ([]models.Notification, error) {
+	query := `SELECT id, user_id, type, title, message, is_read, created_at FROM notifications WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := db.Query(query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -733,7 +1117,7 @@ func GetNotifications() ([]models.Notification, error) {
 	var notifications []models.Notification
 	for rows.Next() {
 		var notification models.Notification
-		err := rows.Scan(&notification.ID, &notification.Type, &notification.Title, &notification.Message, &notification.IsRead, &notification.CreatedAt)
+		err := rows.Scan(&notification.ID, &notification.UserID, &notification.Type, &notification.Title, &notification.Message, &notification.IsRead, &notification.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -743,9 +1127,10 @@ func GetNotifications() ([]models.Notification, error) {
 	return notifications, nil
 }
 
-func CreateNotification(req models.CreateNotificationRequest) (*models.Notification, error) {
+func CreateNotification(userID uuid.UUID, req models.CreateNotificationRequest) (*models.Notification, error) {
 	notification := &models.Notification{
 		ID:        uuid.New(),
+		UserID:    userID,
 		Type:      req.Type,
 		Title:     req.Title,
 		Message:   req.Message,
@@ -753,8 +1138,8 @@ func CreateNotification(req models.CreateNotificationRequest) (*models.Notificat
 		CreatedAt: time.Now(),
 	}
 
-	query := `INSERT INTO notifications (id, type, title, message, is_read, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := db.Exec(query, notification.ID, notification.Type, notification.Title, notification.Message, notification.IsRead, notification.CreatedAt)
+	query := `INSERT INTO notifications (id, user_id, type, title, message, is_read, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := db.Exec(query, notification.ID, notification.UserID, notification.Type, notification.Title, notification.Message, notification.IsRead, notification.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -762,23 +1147,23 @@ func CreateNotification(req models.CreateNotificationRequest) (*models.Notificat
 	return notification, nil
 }
 
-func MarkNotificationAsRead(id uuid.UUID) error {
-	query := `UPDATE notifications SET is_read = true WHERE id = $1`
-	_, err := db.Exec(query, id)
+func MarkNotificationAsRead(userID, id uuid.UUID) error {
+	query := `UPDATE notifications SET is_read = true WHERE id = $1 AND user_id = $2`
+	_, err := db.Exec(query, id, userID)
 	return err
 }
 
-func GetNotificationStats() (*models.NotificationStats, error) {
+func GetNotificationStats(userID uuid.UUID) (*models.NotificationStats, error) {
 	var stats models.NotificationStats
 
 	// Get unread count
-	err := db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE is_read = false`).Scan(&stats.UnreadCount)
+	err := db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND is_read = false`, userID).Scan(&stats.UnreadCount)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get total count
-	err = db.QueryRow(`SELECT COUNT(*) FROM notifications`).Scan(&stats.TotalCount)
+	err = db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1`, userID).Scan(&stats.TotalCount)
 	if err != nil {
 		return nil, err
 	}
@@ -786,18 +1171,18 @@ func GetNotificationStats() (*models.NotificationStats, error) {
 	return &stats, nil
 }
 
-func CheckDailyReminder() error {
+func CheckDailyReminder(userID uuid.UUID) error {
 	// Check if user has entered any transactions today
 	today := time.Now().Format("2006-01-02")
 	var count int
-	err := db.QueryRow(`SELECT COUNT(*) FROM transactions WHERE DATE(date) = $1`, today).Scan(&count)
+	err := db.QueryRow(`SELECT COUNT(*) FROM transactions WHERE user_id = $1 AND DATE(date) = $2 AND deleted_at IS NULL`, userID, today).Scan(&count)
 	if err != nil {
 		return err
 	}
 
 	// If no transactions today, create a reminder
 	if count == 0 {
-		_, err = CreateNotification(models.CreateNotificationRequest{
+		_, err = CreateNotification(userID, models.CreateNotificationRequest{
 			Type:    models.NotificationTypeDailyReminder,
 			Title:   "Daily Reminder",
 			Message: "Don't forget to log your expenses for today! 💰",
@@ -808,44 +1193,29 @@ func CheckDailyReminder() error {
 	return nil
 }
 
-func CheckLimitWarnings() error {
+// CheckLimitWarnings notifies about the categories that have spent 80% or
+// more of their limit this month, as the monthly summary in currency shows
+// them: spending includes the subcategories and a parent without a limit of
+// its own has the sum of theirs. A limit of zero or less, as when overspending
+// rolled over, never warns.
+func CheckLimitWarnings(userID uuid.UUID, currency string) error {
 	now := time.Now()
-	month := int(now.Month())
-	year := now.Year()
-
-	// Get categories with limits and their current spending
-	query := `
-		SELECT cl.category_id, cl.limit_amount, c.name,
-		       COALESCE(SUM(t.amount), 0) as current_spending
-		FROM category_limits cl
-		JOIN categories c ON cl.category_id = c.id
-		LEFT JOIN transactions t ON cl.category_id = t.category_id 
-			AND EXTRACT(MONTH FROM t.date) = $1 
-			AND EXTRACT(YEAR FROM t.date) = $2
-		WHERE cl.month = $1 AND cl.year = $2
-		GROUP BY cl.category_id, cl.limit_amount, c.name
-		HAVING COALESCE(SUM(t.amount), 0) >= cl.limit_amount * 0.8
-	`
-
-	rows, err := db.Query(query, month, year)
+	summary, err := GetMonthlySummary(userID, int(now.Month()), now.Year(), currency)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var categoryID uuid.UUID
-		var limitAmount, currentSpending float64
-		var categoryName string
-
-		err := rows.Scan(&categoryID, &limitAmount, &currentSpending, &categoryName)
-		if err != nil {
+	var failed []string
+	for _, category := range summary.Categories {
+		if category.Limit == nil || *category.Limit <= 0 {
 			continue
 		}
+		limitAmount := *category.Limit
+		currentSpending := category.Amount
 
 		// Check if we're at 80% or more of the limit
-		if currentSpending >= limitAmount*0.8 {
-			percentage := int((currentSpending / limitAmount) * 100)
+		if currentSpending*5 >= limitAmount*4 {
+			percentage := currentSpending.Percent(limitAmount)
 
 			var notificationType models.NotificationType
 			if currentSpending >= limitAmount {
@@ -854,17 +1224,20 @@ func CheckLimitWarnings() error {
 				notificationType = models.NotificationTypeLimitWarning
 			}
 
-			_, err = CreateNotification(models.CreateNotificationRequest{
+			_, err = CreateNotification(userID, models.CreateNotificationRequest{
 				Type:  notificationType,
 				Title: fmt.Sprintf("Limit %s", string(notificationType)),
-				Message: fmt.Sprintf("Category '%s' has reached %d%% of its limit (%.2f/%.2f)",
-					categoryName, percentage, currentSpending, limitAmount),
+				Message: fmt.Sprintf("Category '%s' has reached %d%% of its limit (%s/%s %s)",
+					category.CategoryName, percentage, currentSpending, limitAmount, currency),
 			})
 			if err != nil {
-				continue
+				failed = append(failed, fmt.Sprintf("%s: %v", category.CategoryName, err))
 			}
 		}
 	}
 
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to notify about %d limits: %s", len(failed), strings.Join(failed, "; "))
+	}
 	return nil
 }
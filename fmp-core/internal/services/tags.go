@@ -0,0 +1,207 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// TagExistsError is returned when creating or renaming a tag to the name of
+// another tag of the budget
+type TagExistsError struct {
+	Name string
+}
+
+func (e *TagExistsError) Error() string {
+	return fmt.Sprintf("tag %q already exists", e.Name)
+}
+
+// normalizeTags trims tag names and drops empty and repeated ones
+func normalizeTags(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	result := []string{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// setTags replaces the tags linked to an owner row, creating unknown tags.
+// linkTable and ownerColumn name the link table and its owner column. Links
+// to tags in the trash are kept for when they are restored.
+func setTags(tx *sql.Tx, userID uuid.UUID, linkTable, ownerColumn string, ownerID uuid.UUID, names []string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE %s = $1 AND tag_id IN (SELECT id FROM tags WHERE deleted_at IS NULL)`, linkTable, ownerColumn)
+	if _, err := tx.Exec(query, ownerID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, name := range normalizeTags(names) {
+		var tagID uuid.UUID
+		query := `
+			INSERT INTO tags (id, user_id, name, created_at, updated_at) VALUES ($1, $2, $3, $4, $4)
+			ON CONFLICT (user_id, name) WHERE deleted_at IS NULL DO UPDATE SET name = EXCLUDED.name
+			RETURNING id
+		`
+		if err := tx.QueryRow(query, uuid.New(), userID, name, now).Scan(&tagID); err != nil {
+			return err
+		}
+
+		query = fmt.Sprintf(`INSERT INTO %s (%s, tag_id) VALUES ($1, $2)`, linkTable, ownerColumn)
+		if _, err := tx.Exec(query, ownerID, tagID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setTransactionTags(tx *sql.Tx, userID, transactionID uuid.UUID, names []string) error {
+	return setTags(tx, userID, "transaction_tags", "transaction_id", transactionID, names)
+}
+
+func setPlannedExpenseTags(tx *sql.Tx, userID, expenseID uuid.UUID, names []string) error {
+	return setTags(tx, userID, "planned_expense_tags", "planned_expense_id", expenseID, names)
+}
+
+// Tag services
+func GetTags(userID uuid.UUID) ([]models.Tag, error) {
+	query := `SELECT id, user_id, name, created_at, updated_at FROM tags WHERE user_id = $1 AND deleted_at IS NULL ORDER BY name`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt, &tag.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+func CreateTag(userID uuid.UUID, req models.CreateTagRequest) (*models.Tag, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("tag name is required")
+	}
+
+	tag := &models.Tag{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	query := `INSERT INTO tags (id, user_id, name, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := db.Exec(query, tag.ID, tag.UserID, tag.Name, tag.CreatedAt, tag.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, &TagExistsError{Name: name}
+		}
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// UpdateTag renames a tag, the new name shows up on everything tagged with it
+func UpdateTag(userID, id uuid.UUID, req models.CreateTagRequest) (*models.Tag, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("tag name is required")
+	}
+
+	tag := &models.Tag{}
+	query := `UPDATE tags SET name = $1, updated_at = $2 WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL RETURNING id, user_id, name, created_at, updated_at`
+	err := db.QueryRow(query, name, time.Now(), id, userID).Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt, &tag.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tag not found")
+		}
+		if isUniqueViolation(err) {
+			return nil, &TagExistsError{Name: name}
+		}
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// DeleteTag moves the tag to the trash, what is tagged with it keeps the
+// link for when it is restored
+func DeleteTag(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindTag, userID, id)
+}
+
+// GetTagSummary totals spending and income per tag over the filtered period.
+// A transaction with several tags counts towards each of them.
+func GetTagSummary(userID uuid.UUID, filters models.TransactionFilters, currency string) ([]models.TagSummary, error) {
+	query := `
+		SELECT 
+			tg.id,
+			tg.name,
+			COALESCE(SUM(convert_amount($1, t.amount, t.currency, $2, t.date::date)) FILTER (WHERE t.type = 'expense'), 0) as amount,
+			COALESCE(SUM(convert_amount($1, t.amount, t.currency, $2, t.date::date)) FILTER (WHERE t.type = 'income'), 0) as income,
+			COUNT(t.id) as transaction_count
+		FROM tags tg
+		LEFT JOIN transaction_tags tt ON tt.tag_id = tg.id
+		LEFT JOIN transactions t ON t.id = tt.transaction_id AND t.user_id = $1 AND t.deleted_at IS NULL`
+	args := []interface{}{userID, currency}
+	argIndex := 3
+
+	if filters.StartDate != nil {
+		query += fmt.Sprintf(" AND t.date >= $%d", argIndex)
+		args = append(args, *filters.StartDate)
+		argIndex++
+	}
+
+	if filters.EndDate != nil {
+		query += fmt.Sprintf(" AND t.date <= $%d", argIndex)
+		args = append(args, *filters.EndDate)
+		argIndex++
+	}
+
+	query += `
+		WHERE tg.user_id = $1 AND tg.deleted_at IS NULL
+		GROUP BY tg.id, tg.name
+		ORDER BY amount DESC, tg.name`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []models.TagSummary
+	for rows.Next() {
+		var summary models.TagSummary
+		err := rows.Scan(&summary.TagID, &summary.TagName, &summary.Amount, &summary.Income, &summary.TransactionCount)
+		if err != nil {
+			return nil, err
+		}
+		summary.Net = summary.Income - summary.Amount
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// checkTransactionSplits makes sure the split lines are in the user's
+// categories and add up to the transaction amount. Without a category of its
+// own the transaction takes the one of the first line.
+func checkTransactionSplits(userID uuid.UUID, req *models.CreateTransactionRequest) error {
+	if len(req.Splits) == 0 {
+		return nil
+	}
+
+	var total models.Money
+	for _, split := range req.Splits {
+		if err := checkCategoryOwner(userID, split.CategoryID); err != nil {
+			return err
+		}
+		total += split.Amount
+	}
+	if total != req.Amount {
+		return &TransactionValidationError{Reason: fmt.Sprintf("split amounts add up to %s, expected %s", total, req.Amount)}
+	}
+
+	if req.CategoryID == uuid.Nil {
+		req.CategoryID = req.Splits[0].CategoryID
+	}
+	return nil
+}
+
+// setTransactionSplits replaces the split lines of a transaction
+func setTransactionSplits(tx *sql.Tx, transactionID uuid.UUID, splits []models.TransactionSplitRequest) ([]models.TransactionSplit, error) {
+	if _, err := tx.Exec(`DELETE FROM transaction_splits WHERE transaction_id = $1`, transactionID); err != nil {
+		return nil, err
+	}
+
+	var result []models.TransactionSplit
+	for _, req := range splits {
+		split := models.TransactionSplit{
+			ID:            uuid.New(),
+			TransactionID: transactionID,
+			CategoryID:    req.CategoryID,
+			Amount:        req.Amount,
+			Note:          req.Note,
+		}
+
+		query := `INSERT INTO transaction_splits (id, transaction_id, category_id, amount, note) VALUES ($1, $2, $3, $4, $5)`
+		_, err := tx.Exec(query, split.ID, split.TransactionID, split.CategoryID, split.Amount, split.Note)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, split)
+	}
+
+	return result, nil
+}
+
+// loadTransactionSplits fills in the split lines of the given transactions
+func loadTransactionSplits(transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	index := make(map[uuid.UUID]int, len(transactions))
+	ids := make([]string, len(transactions))
+	for i, transaction := range transactions {
+		index[transaction.ID] = i
+		ids[i] = transaction.ID.String()
+	}
+
+	query := `SELECT id, transaction_id, category_id, amount, COALESCE(note, '') FROM transaction_splits WHERE transaction_id = ANY($1::uuid[]) ORDER BY created_at, id`
+	rows, err := db.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var split models.TransactionSplit
+		err := rows.Scan(&split.ID, &split.TransactionID, &split.CategoryID, &split.Amount, &split.Note)
+		if err != nil {
+			return err
+		}
+		i := index[split.TransactionID]
+		transactions[i].Splits = append(transactions[i].Splits, split)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const transferColumns = `
+	tr.id, tr.user_id, tr.from_account_id, tr.to_account_id, tr.amount, tr.currency, tr.to_amount, tr.to_currency, COALESCE(tr.description, ''), tr.date,
+	(SELECT t.id FROM transactions t WHERE t.transfer_id = tr.id AND t.type = 'expense'),
+	(SELECT t.id FROM transactions t WHERE t.transfer_id = tr.id AND t.type = 'income'),
+	tr.created_at, tr.updated_at`
+
+func scanTransfer(row rowScanner) (*models.Transfer, error) {
+	transfer := &models.Transfer{}
+	err := row.Scan(&transfer.ID, &transfer.UserID, &transfer.FromAccountID, &transfer.ToAccountID, &transfer.Amount, &transfer.Currency, &transfer.ToAmount, &transfer.ToCurrency, &transfer.Description, &transfer.Date, &transfer.DebitTransactionID, &transfer.CreditTransactionID, &transfer.CreatedAt, &transfer.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// Transfer services
+func GetTransfers(userID uuid.UUID, filters models.TransferFilters) ([]models.Transfer, error) {
+	query := `SELECT ` + transferColumns + ` FROM transfers tr WHERE tr.user_id = $1 AND tr.deleted_at IS NULL`
+	args := []interface{}{userID}
+	argIndex := 2
+
+	if filters.AccountID != nil {
+		query += fmt.Sprintf(" AND (tr.from_account_id = $%d OR tr.to_account_id = $%d)", argIndex, argIndex)
+		args = append(args, *filters.AccountID)
+		argIndex++
+	}
+
+	if filters.StartDate != nil {
+		query += fmt.Sprintf(" AND tr.date >= $%d", argIndex)
+		args = append(args, *filters.StartDate)
+		argIndex++
+	}
+
+	if filters.EndDate != nil {
+		query += fmt.Sprintf(" AND tr.date <= $%d", argIndex)
+		args = append(args, *filters.EndDate)
+		argIndex++
+	}
+
+	query += " ORDER BY tr.date DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []models.Transfer
+	for rows.Next() {
+		transfer, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, *transfer)
+	}
+
+	return transfers, nil
+}
+
+func GetTransfer(userID, id uuid.UUID) (*models.Transfer, error) {
+	query := `SELECT ` + transferColumns + ` FROM transfers tr WHERE tr.id = $1 AND tr.user_id = $2 AND tr.deleted_at IS NULL`
+	transfer, err := scanTransfer(db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("transfer not found")
+		}
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// CreateTransfer records the transfer and both of its legs in one database
+// transaction, so either the money moves on both accounts or on neither
+func CreateTransfer(userID uuid.UUID, req models.CreateTransferRequest) (*models.Transfer, error) {
+	fromCurrency, err := getAccountCurrency(userID, req.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	toCurrency, err := getAccountCurrency(userID, req.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Date.IsZero() {
+		req.Date = time.Now()
+	}
+
+	if req.ToAmount == 0 {
+		if fromCurrency == toCurrency {
+			req.ToAmount = req.Amount
+		} else {
+			err := db.QueryRow(`SELECT convert_amount($1, $2, $3, $4, $5::date)`, userID, req.Amount, fromCurrency, toCurrency, req.Date).Scan(&req.ToAmount)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	transfer := &models.Transfer{
+		ID:                  uuid.New(),
+		UserID:              userID,
+		FromAccountID:       req.FromAccountID,
+		ToAccountID:         req.ToAccountID,
+		Amount:              req.Amount,
+		Currency:            fromCurrency,
+		ToAmount:            req.ToAmount,
+		ToCurrency:          toCurrency,
+		Description:         req.Description,
+		Date:                req.Date,
+		DebitTransactionID:  uuid.New(),
+		CreditTransactionID: uuid.New(),
+		CreatedAt:           time.Now(),
+		UpdatedAt:           time.Now(),
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO transfers (id, user_id, from_account_id, to_account_id, amount, currency, to_amount, to_currency, description, date, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	_, err = tx.Exec(query, transfer.ID, transfer.UserID, transfer.FromAccountID, transfer.ToAccountID, transfer.Amount, transfer.Currency, transfer.ToAmount, transfer.ToCurrency, transfer.Description, transfer.Date, transfer.CreatedAt, transfer.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `INSERT INTO transactions (id, user_id, account_id, type, amount, currency, description, date, transfer_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)`
+	_, err = tx.Exec(query, transfer.DebitTransactionID, userID, transfer.FromAccountID, models.TransactionTypeExpense, transfer.Amount, transfer.Currency, transfer.Description, transfer.Date, transfer.ID, transfer.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(query, transfer.CreditTransactionID, userID, transfer.ToAccountID, models.TransactionTypeIncome, transfer.ToAmount, transfer.ToCurrency, transfer.Description, transfer.Date, transfer.ID, transfer.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// DeleteTransfer moves the transfer to the trash, its legs go with it
+func DeleteTransfer(userID, id uuid.UUID) error {
+	return moveToTrash(models.TrashKindTransfer, userID, id)
+}
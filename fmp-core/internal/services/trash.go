@@ -0,0 +1,651 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"fmp-core/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// trashRetentionDays is how long deleted items stay in the trash
+var trashRetentionDays = 30
+
+func SetTrashRetention(days int) {
+	trashRetentionDays = days
+}
+
+// trashKind describes how an entity goes to the trash. Deleting an item sets
+// deleted_at on its row and on the rows that go with it, which mirror what
+// the foreign keys would delete. Those rows share the item's deleted_at, which
+// is how restoring and purging the item finds them again.
+type trashKind struct {
+	kind  string
+	table string
+	title string
+	// name, amount and currency are the SQL expressions, over the row x, the
+	// item is listed with
+	name     string
+	amount   string
+	currency string
+	// only narrows the rows that can be deleted on their own
+	only string
+	// cascade lists what is deleted along with the item
+	cascade []trashCascade
+	// parents are the rows the item belongs to
+	parents []trashParent
+}
+
+type trashCascade struct {
+	kind string
+	// match selects the rows depending on the items whose ids are in $1
+	match string
+	// only narrows the rows that are deleted along with the items
+	only string
+}
+
+// trashParent is a row the item belongs to. The item can not be restored
+// while its parent is in the trash, and purging the parent purges the item
+// first unless the item outlives it.
+type trashParent struct {
+	kind  string
+	title string
+	// ref holds when the row p is the parent of the row x
+	ref string
+	// outlives is set where the foreign key sets null instead of cascading
+	// or restricting
+	outlives bool
+}
+
+func parentColumn(kind, title, column string) trashParent {
+	return trashParent{kind: kind, title: title, ref: "p.id = x." + column}
+}
+
+func categoryParent(column string) trashParent {
+	return parentColumn(models.TrashKindCategory, "category", column)
+}
+
+// trashKinds are listed parents first. Limit templates, exchange rates and
+// counterparties are not among them and are deleted right away: templates
+// only shape limits generated later, rates can be entered again and a
+// counterparty can only be deleted once no debt names it.
+var trashKinds = []trashKind{
+	{
+		kind: models.TrashKindCategory, table: "categories", title: "category",
+		name: "x.name", amount: "NULL", currency: "NULL",
+		cascade: []trashCascade{
+			{kind: models.TrashKindCategory, match: "parent_id = ANY($1::uuid[])"},
+			{kind: models.TrashKindTransaction, match: "category_id = ANY($1::uuid[]) OR id IN (SELECT transaction_id FROM transaction_splits WHERE category_id = ANY($1::uuid[]))"},
+			{kind: models.TrashKindPlannedExpense, match: "category_id = ANY($1::uuid[])"},
+			{kind: models.TrashKindRecurringExpense, match: "category_id = ANY($1::uuid[])"},
+			{kind: models.TrashKindCategoryLimit, match: "category_id = ANY($1::uuid[])"},
+		},
+		parents: []trashParent{{kind: models.TrashKindCategory, title: "parent category", ref: "p.id = x.parent_id", outlives: true}},
+	},
+	{
+		kind: models.TrashKindAccount, table: "accounts", title: "account",
+		name: "x.name", amount: "NULL", currency: "x.currency",
+		cascade: []trashCascade{
+			{kind: models.TrashKindTransfer, match: "from_account_id = ANY($1::uuid[]) OR to_account_id = ANY($1::uuid[])"},
+			{kind: models.TrashKindRule, match: "account_id = ANY($1::uuid[])"},
+		},
+	},
+	{
+		kind: models.TrashKindPayee, table: "payees", title: "payee",
+		name: "x.name", amount: "NULL", currency: "NULL",
+		cascade: []trashCascade{
+			{kind: models.TrashKindRule, match: "payee_id = ANY($1::uuid[])"},
+		},
+	},
+	{
+		kind: models.TrashKindRule, table: "rules", title: "rule",
+		name: "x.name", amount: "NULL", currency: "NULL",
+		parents: []trashParent{
+			categoryParent("category_id"),
+			parentColumn(models.TrashKindAccount, "account", "account_id"),
+			parentColumn(models.TrashKindPayee, "payee", "payee_id"),
+		},
+	},
+	{
+		kind: models.TrashKindTag, table: "tags", title: "tag",
+		name: "x.name", amount: "NULL", currency: "NULL",
+	},
+	{
+		kind: models.TrashKindTransfer, table: "transfers", title: "transfer",
+		name: "x.description", amount: "x.amount", currency: "x.currency",
+		cascade: []trashCascade{
+			{kind: models.TrashKindTransaction, match: "transfer_id = ANY($1::uuid[])"},
+		},
+		parents: []trashParent{parentColumn(models.TrashKindAccount, "account", "from_account_id"), parentColumn(models.TrashKindAccount, "account", "to_account_id")},
+	},
+	{
+		kind: models.TrashKindRecurringExpense, table: "recurring_expenses", title: "recurring expense",
+		name: "COALESCE(NULLIF(x.description, ''), (SELECT c.name FROM categories c WHERE c.id = x.category_id))", amount: "x.amount", currency: "x.currency",
+		cascade: []trashCascade{
+			{kind: models.TrashKindPlannedExpense, match: "recurring_expense_id = ANY($1::uuid[])", only: "planned_date > CURRENT_DATE AND is_completed = false AND transaction_id IS NULL"},
+		},
+		parents: []trashParent{categoryParent("category_id")},
+	},
+	{
+		kind: models.TrashKindPlannedExpense, table: "planned_expenses", title: "planned expense",
+		name: "COALESCE(NULLIF(x.description, ''), (SELECT c.name FROM categories c WHERE c.id = x.category_id))", amount: "x.amount", currency: "x.currency",
+		parents: []trashParent{categoryParent("category_id")},
+	},
+	{
+		kind: models.TrashKindPlannedIncome, table: "planned_incomes", title: "planned income",
+		name: "x.name", amount: "x.amount", currency: "x.currency",
+	},
+	{
+		kind: models.TrashKindCategoryLimit, table: "category_limits", title: "category limit",
+		name: "(SELECT c.name FROM categories c WHERE c.id = x.category_id)", amount: "x.limit_amount", currency: "x.currency",
+		parents: []trashParent{categoryParent("category_id")},
+	},
+	{
+		kind: models.TrashKindGoal, table: "goals", title: "goal",
+		name: "x.name", amount: "x.target_amount", currency: "x.currency",
+	},
+	{
+		kind: models.TrashKindGoalContribution, table: "goal_contributions", title: "goal contribution",
+		name: "COALESCE(NULLIF(x.note, ''), (SELECT g.name FROM goals g WHERE g.id = x.goal_id))", amount: "x.amount", currency: "x.currency",
+		parents: []trashParent{parentColumn(models.TrashKindGoal, "goal", "goal_id")},
+	},
+	{
+		kind: models.TrashKindDebt, table: "debts", title: "debt",
+		name: "x.name", amount: "x.principal", currency: "x.currency",
+	},
+	{
+		kind: models.TrashKindDebtPayment, table: "debt_payments", title: "debt payment",
+		name: "COALESCE(NULLIF(x.note, ''), (SELECT d.name FROM debts d WHERE d.id = x.debt_id))", amount: "x.amount", currency: "(SELECT d.currency FROM debts d WHERE d.id = x.debt_id)",
+		cascade: []trashCascade{
+			{kind: models.TrashKindTransaction, match: "id IN (SELECT transaction_id FROM debt_payments WHERE id = ANY($1::uuid[]))"},
+		},
+		parents: []trashParent{parentColumn(models.TrashKindDebt, "debt", "debt_id")},
+	},
+	{
+		kind: models.TrashKindTransaction, table: "transactions", title: "transaction",
+		name: "COALESCE(NULLIF(x.description, ''), (SELECT c.name FROM categories c WHERE c.id = x.category_id))", amount: "x.amount", currency: "x.currency",
+		// Transfer legs only go with their transfer
+		only: "transfer_id IS NULL",
+		parents: []trashParent{
+			categoryParent("category_id"),
+			{kind: models.TrashKindCategory, title: "category", ref: "p.id IN (SELECT s.category_id FROM transaction_splits s WHERE s.transaction_id = x.id)"},
+		},
+	},
+	{
+		kind: models.TrashKindAttachment, table: "attachments", title: "attachment",
+		name: "x.file_name", amount: "NULL", currency: "NULL",
+		parents: []trashParent{
+			parentColumn(models.TrashKindTransaction, "transaction", "transaction_id"),
+			parentColumn(models.TrashKindPlannedExpense, "planned expense", "planned_expense_id"),
+		},
+	},
+}
+
+func findTrashKind(kind string) (*trashKind, error) {
+	for i := range trashKinds {
+		if trashKinds[i].kind == kind {
+			return &trashKinds[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown trash kind %q", kind)
+}
+
+// moveToTrash deletes an item with everything that goes with it
+func moveToTrash(kind string, userID, id uuid.UUID) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := trashItem(tx, kind, userID, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func trashItem(tx *sql.Tx, kind string, userID, id uuid.UUID) error {
+	k, err := findTrashKind(kind)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := time.Now()
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = $1 WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL`, k.table)
+	if k.only != "" {
+		query += " AND " + k.only
+	}
+	result, err := tx.Exec(query, deletedAt, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s not found", k.title)
+	}
+
+	group, err := trashGroup(tx, k, id, nil)
+	if err != nil {
+		return err
+	}
+	if err := setDeletedAt(tx, group, &deletedAt); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO trash (user_id, kind, item_id, deleted_at) VALUES ($1, $2, $3, $4)`, userID, kind, id, deletedAt)
+	return err
+}
+
+// trashGroup collects the ids of the item and the rows that go with it, by
+// kind. Without deletedAt these are the rows still to be deleted, otherwise
+// the ones deleted along with the item.
+func trashGroup(tx *sql.Tx, k *trashKind, id uuid.UUID, deletedAt *time.Time) (map[string][]string, error) {
+	group := map[string][]string{k.kind: {id.String()}}
+	seen := map[string]bool{id.String(): true}
+
+	var collect func(k *trashKind, ids []string) error
+	collect = func(k *trashKind, ids []string) error {
+		for _, cascade := range k.cascade {
+			dependent, err := findTrashKind(cascade.kind)
+			if err != nil {
+				return err
+			}
+
+			query := fmt.Sprintf(`SELECT id FROM %s WHERE (%s)`, dependent.table, cascade.match)
+			args := []interface{}{pq.Array(ids)}
+			if deletedAt == nil {
+				query += " AND deleted_at IS NULL"
+				if cascade.only != "" {
+					query += " AND " + cascade.only
+				}
+			} else {
+				query += " AND deleted_at = $2"
+				args = append(args, *deletedAt)
+			}
+
+			rows, err := tx.Query(query, args...)
+			if err != nil {
+				return err
+			}
+			var found []string
+			for rows.Next() {
+				var dependentID string
+				if err := rows.Scan(&dependentID); err != nil {
+					rows.Close()
+					return err
+				}
+				if !seen[dependentID] {
+					seen[dependentID] = true
+					found = append(found, dependentID)
+				}
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			if len(found) > 0 {
+				group[dependent.kind] = append(group[dependent.kind], found...)
+				if err := collect(dependent, found); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := collect(k, []string{id.String()}); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// setDeletedAt deletes the rows of the group, or restores them when deletedAt is nil
+func setDeletedAt(tx *sql.Tx, group map[string][]string, deletedAt *time.Time) error {
+	for _, k := range trashKinds {
+		if ids := group[k.kind]; len(ids) > 0 {
+			query := fmt.Sprintf(`UPDATE %s SET deleted_at = $1 WHERE id = ANY($2::uuid[])`, k.table)
+			if _, err := tx.Exec(query, deletedAt, pq.Array(ids)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetTrash lists what was deleted, most recent first. Rows deleted along with
+// an item are not listed on their own.
+func GetTrash(userID uuid.UUID, filters models.TrashFilters) ([]models.TrashItem, error) {
+	query := ""
+	for _, k := range trashKinds {
+		if len(filters.Kinds) > 0 && !containsString(filters.Kinds, k.kind) {
+			continue
+		}
+		if query != "" {
+			query += " UNION ALL "
+		}
+		query += fmt.Sprintf(`
+			SELECT tr.kind, tr.item_id, COALESCE(%s, ''), %s::numeric, %s::varchar, tr.deleted_at
+			FROM trash tr
+			JOIN %s x ON x.id = tr.item_id
+			WHERE tr.user_id = $1 AND tr.kind = '%s'`, k.name, k.amount, k.currency, k.table, k.kind)
+	}
+	items := []models.TrashItem{}
+	if query == "" {
+		return items, nil
+	}
+	query += " ORDER BY deleted_at DESC"
+
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.TrashItem
+		err := rows.Scan(&item.Kind, &item.ID, &item.Name, &item.Amount, &item.Currency, &item.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		item.PurgeAt = item.DeletedAt.AddDate(0, 0, trashRetentionDays)
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// RestoreTrashItem brings back a deleted item with everything deleted along
+// with it
+func RestoreTrashItem(userID uuid.UUID, kind string, id uuid.UUID) error {
+	k, err := findTrashKind(kind)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deletedAt, err := takeFromTrash(tx, k, userID, id)
+	if err != nil {
+		return err
+	}
+
+	for _, parent := range k.parents {
+		parentKind, err := findTrashKind(parent.kind)
+		if err != nil {
+			return err
+		}
+
+		var blocked bool
+		query := fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM %s x JOIN %s p ON %s WHERE x.id = $1 AND p.deleted_at IS NOT NULL)`,
+			k.table, parentKind.table, parent.ref)
+		if err := tx.QueryRow(query, id).Scan(&blocked); err != nil {
+			return err
+		}
+		if blocked {
+			return fmt.Errorf("%s is in the trash, restore it first", parent.title)
+		}
+	}
+
+	group, err := trashGroup(tx, k, id, &deletedAt)
+	if err != nil {
+		return err
+	}
+	if err := setDeletedAt(tx, group, nil); err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s can not be restored, another one has taken its place", k.title)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PurgeTrashItem permanently deletes an item in the trash with everything
+// deleted along with it
+func PurgeTrashItem(userID uuid.UUID, kind string, id uuid.UUID) error {
+	k, err := findTrashKind(kind)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deletedAt, err := takeFromTrash(tx, k, userID, id)
+	if err != nil {
+		return err
+	}
+
+	attachments, err := purgeTrashEntry(tx, userID, k, id, deletedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	deleteAttachmentBlobs(attachments)
+	return nil
+}
+
+// purgeTrashEntry deletes the rows of an entry already taken from the trash.
+// Entries in the trash on their own whose rows can not outlive these are
+// purged before them. It returns the storage keys of the attachments to
+// remove once the transaction is committed.
+func purgeTrashEntry(tx *sql.Tx, userID uuid.UUID, k *trashKind, id uuid.UUID, deletedAt time.Time) ([]string, error) {
+	group, err := trashGroup(tx, k, id, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := attachmentKeys(tx, `id = ANY($1::uuid[]) OR transaction_id = ANY($2::uuid[]) OR planned_expense_id = ANY($3::uuid[])`,
+		pq.Array(group[models.TrashKindAttachment]), pq.Array(group[models.TrashKindTransaction]), pq.Array(group[models.TrashKindPlannedExpense]))
+	if err != nil {
+		return nil, err
+	}
+
+	dependents, err := takeDependentEntries(tx, userID, group, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, dependent := range dependents {
+		dependentKind, err := findTrashKind(dependent.Kind)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := purgeTrashEntry(tx, userID, dependentKind, dependent.ID, dependent.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, keys...)
+	}
+
+	// Limit templates of the purged categories go with them
+	if categories := group[models.TrashKindCategory]; len(categories) > 0 {
+		if _, err := tx.Exec(`DELETE FROM limit_templates WHERE category_id = ANY($1::uuid[])`, pq.Array(categories)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Children first, the foreign keys to categories restrict
+	for i := len(trashKinds) - 1; i >= 0; i-- {
+		if ids := group[trashKinds[i].kind]; len(ids) > 0 {
+			if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE id = ANY($1::uuid[])`, trashKinds[i].table), pq.Array(ids)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return attachments, nil
+}
+
+// takeDependentEntries takes from the trash the entries with rows that belong
+// to the rows of the group and would not outlive them
+func takeDependentEntries(tx *sql.Tx, userID uuid.UUID, group map[string][]string, deletedAt time.Time) ([]models.TrashItem, error) {
+	var entries []models.TrashItem
+	for _, dependent := range trashKinds {
+		for _, parent := range dependent.parents {
+			ids := group[parent.kind]
+			if parent.outlives || len(ids) == 0 {
+				continue
+			}
+			parentKind, err := findTrashKind(parent.kind)
+			if err != nil {
+				return nil, err
+			}
+
+			query := fmt.Sprintf(`
+				DELETE FROM trash WHERE user_id = $1 AND deleted_at IN (
+					SELECT x.deleted_at FROM %s x JOIN %s p ON %s
+					WHERE p.id = ANY($2::uuid[]) AND x.deleted_at IS NOT NULL AND x.deleted_at <> $3
+				)
+				RETURNING kind, item_id, deleted_at`, dependent.table, parentKind.table, parent.ref)
+			rows, err := tx.Query(query, userID, pq.Array(ids), deletedAt)
+			if err != nil {
+				return nil, err
+			}
+			for rows.Next() {
+				var entry models.TrashItem
+				if err := rows.Scan(&entry.Kind, &entry.ID, &entry.DeletedAt); err != nil {
+					rows.Close()
+					return nil, err
+				}
+				entries = append(entries, entry)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return entries, nil
+}
+
+// takeFromTrash removes the trash entry of an item and returns when it was deleted
+func takeFromTrash(tx *sql.Tx, k *trashKind, userID, id uuid.UUID) (time.Time, error) {
+	var deletedAt time.Time
+	err := tx.QueryRow(`DELETE FROM trash WHERE user_id = $1 AND kind = $2 AND item_id = $3 RETURNING deleted_at`, userID, k.kind, id).Scan(&deletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return deletedAt, fmt.Errorf("%s not found in the trash", k.title)
+		}
+		return deletedAt, err
+	}
+	return deletedAt, nil
+}
+
+// PurgeTrash permanently deletes everything that has been in the trash for
+// longer than the retention, for every user. It is run by the scheduler.
+// Every entry is purged in its own transaction, one that fails is logged and
+// left for the next run.
+func PurgeTrash() (int, error) {
+	if trashRetentionDays <= 0 {
+		return 0, fmt.Errorf("trash retention must be positive, got %d days", trashRetentionDays)
+	}
+	cutoff := time.Now().AddDate(0, 0, -trashRetentionDays)
+
+	rows, err := db.Query(`SELECT user_id, kind, item_id FROM trash WHERE deleted_at < $1 ORDER BY deleted_at`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	type expiredEntry struct {
+		userID uuid.UUID
+		kind   string
+		id     uuid.UUID
+	}
+	var expired []expiredEntry
+	for rows.Next() {
+		var entry expiredEntry
+		if err := rows.Scan(&entry.userID, &entry.kind, &entry.id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		expired = append(expired, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	purged, failed := 0, 0
+	for _, entry := range expired {
+		done, err := purgeExpiredEntry(entry.userID, entry.kind, entry.id)
+		if err != nil {
+			log.Printf("Failed to purge %s %s from the trash: %v", entry.kind, entry.id, err)
+			failed++
+			continue
+		}
+		if done {
+			purged++
+		}
+	}
+
+	if failed > 0 {
+		return purged, fmt.Errorf("%d of %d expired trash entries failed", failed, len(expired))
+	}
+	return purged, nil
+}
+
+// purgeExpiredEntry purges one expired trash entry, it is false when the
+// entry went along with an earlier one. The attachment blobs are deleted once
+// their rows are.
+func purgeExpiredEntry(userID uuid.UUID, kind string, id uuid.UUID) (bool, error) {
+	k, err := findTrashKind(kind)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var deletedAt time.Time
+	err = tx.QueryRow(`DELETE FROM trash WHERE kind = $1 AND item_id = $2 RETURNING deleted_at`, kind, id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	attachments, err := purgeTrashEntry(tx, userID, k, id, deletedAt)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	deleteAttachmentBlobs(attachments)
+	return true, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
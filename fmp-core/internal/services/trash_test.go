@@ -0,0 +1,94 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"fmp-core/internal/models"
+)
+
+// trashKindIndex is where each kind is in trashKinds
+func trashKindIndex(t *testing.T) map[string]int {
+	t.Helper()
+	index := map[string]int{}
+	for i, k := range trashKinds {
+		if _, ok := index[k.kind]; ok {
+			t.Fatalf("trash kind %q is described twice", k.kind)
+		}
+		index[k.kind] = i
+	}
+	return index
+}
+
+func TestTrashKindsCoverModelKinds(t *testing.T) {
+	index := trashKindIndex(t)
+	if len(index) != len(models.TrashKinds) {
+		t.Errorf("%d trash kinds are described, models list %d", len(index), len(models.TrashKinds))
+	}
+	for _, kind := range models.TrashKinds {
+		if _, ok := index[kind]; !ok {
+			t.Errorf("trash kind %q is not described", kind)
+		}
+	}
+}
+
+// Restoring sets deleted_at in trashKinds order and purging deletes in the
+// reverse order, so a row must come after the rows it references
+func TestTrashKindsListParentsFirst(t *testing.T) {
+	index := trashKindIndex(t)
+	for i, k := range trashKinds {
+		for _, parent := range k.parents {
+			p, ok := index[parent.kind]
+			if !ok {
+				t.Errorf("%s: unknown parent kind %q", k.kind, parent.kind)
+				continue
+			}
+			if p > i || (p == i && !parent.outlives) {
+				t.Errorf("%s: parent %s is listed after it", k.kind, parent.kind)
+			}
+		}
+		for _, cascade := range k.cascade {
+			c, ok := index[cascade.kind]
+			if !ok {
+				t.Errorf("%s: unknown cascaded kind %q", k.kind, cascade.kind)
+				continue
+			}
+			if c < i {
+				t.Errorf("%s: cascaded %s is listed before it", k.kind, cascade.kind)
+			}
+		}
+	}
+}
+
+func TestTrashKindsSQL(t *testing.T) {
+	for _, k := range trashKinds {
+		if k.table == "" || k.title == "" || k.name == "" || k.amount == "" || k.currency == "" {
+			t.Errorf("%s: table, title, name, amount and currency are all needed", k.kind)
+		}
+		for _, cascade := range k.cascade {
+			if !strings.Contains(cascade.match, "$1") {
+				t.Errorf("%s: cascade to %s does not match on the ids in $1: %s", k.kind, cascade.kind, cascade.match)
+			}
+		}
+		for _, parent := range k.parents {
+			if parent.title == "" || !strings.Contains(parent.ref, "p.") || !strings.Contains(parent.ref, "x.") {
+				t.Errorf("%s: parent %s does not join p to x: %q", k.kind, parent.kind, parent.ref)
+			}
+		}
+	}
+}
+
+func TestFindTrashKind(t *testing.T) {
+	for _, kind := range models.TrashKinds {
+		k, err := findTrashKind(kind)
+		if err != nil || k.kind != kind {
+			t.Errorf("findTrashKind(%q) = %v, %v", kind, k, err)
+		}
+	}
+
+	for _, kind := range []string{"", "exchange_rate", "limit_template", "Category"} {
+		if k, err := findTrashKind(kind); err == nil {
+			t.Errorf("findTrashKind(%q) = %v, want an error", kind, k)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotFound is returned by Get for keys the store does not have
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps the contents of attachments. Keys are slash separated paths
+// chosen by the caller, metadata lives in the database.
+type Store interface {
+	Put(key string, r io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// Options select and configure a store, see New
+type Options struct {
+	// Backend is "local" or "s3"
+	Backend  string
+	LocalDir string
+
+	S3Endpoint        string
+	S3Bucket          string
+	S3Region          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+}
+
+// New opens the store chosen by opts.Backend
+func New(opts Options) (Store, error) {
+	switch opts.Backend {
+	case "", "local":
+		return NewLocalStore(opts.LocalDir)
+	case "s3":
+		return NewS3Store(opts.S3Endpoint, opts.S3Bucket, opts.S3Region, opts.S3AccessKeyID, opts.S3SecretAccessKey)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", opts.Backend)
+	}
+}
@@ -3,12 +3,15 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"fmp-core/internal/api"
 	"fmp-core/internal/config"
 	"fmp-core/internal/database"
 	"fmp-core/internal/migrations"
+	"fmp-core/internal/scheduler"
 	"fmp-core/internal/services"
+	"fmp-core/internal/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,6 +27,10 @@ func main() {
 	log.Printf("Configuration loaded: Environment=%s, Port=%s", cfg.Environment, cfg.Port)
 	log.Printf("Database URL: %s", cfg.DatabaseURL)
 
+	if cfg.JWTSecret == "" {
+		log.Fatal("JWT_SECRET is required to verify session tokens")
+	}
+
 	// Initialize database
 	log.Println("Initializing database connection...")
 	db, err := database.Initialize(cfg.DatabaseURL)
@@ -55,8 +62,52 @@ func main() {
 	services.SetDB(db)
 	log.Println("Services initialized with database")
 
+	// Initialize attachment storage
+	blobStore, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal("Failed to initialize attachment storage:", err)
+	}
+	services.SetBlobStore(blobStore)
+	log.Printf("Attachment storage initialized: %s", cfg.Storage.Backend)
+
+	// Deleted items stay in the trash for the retention period
+	services.SetTrashRetention(cfg.TrashRetentionDays)
+
+	// Hand data created before multi-user support to its owner
+	if cfg.DefaultOwnerTelegramID != 0 {
+		if err := services.ClaimDefaultOwner(cfg.DefaultOwnerTelegramID); err != nil {
+			log.Fatal("Failed to claim default owner:", err)
+		}
+		log.Printf("Default owner claimed by Telegram user %d", cfg.DefaultOwnerTelegramID)
+	}
+
+	// Start background jobs
+	scheduler.Every(cfg.SchedulerInterval, "recurring expenses", func() error {
+		result, err := services.GenerateAllRecurringExpenses(time.Now().AddDate(0, 0, cfg.RecurringHorizonDays))
+		if err == nil && (result.Planned > 0 || result.Posted > 0 || result.Failed > 0) {
+			log.Printf("Recurring expenses: %d planned, %d posted, %d failed", result.Planned, result.Posted, result.Failed)
+		}
+		return err
+	})
+	scheduler.Every(cfg.SchedulerInterval, "category limits", func() error {
+		now := time.Now()
+		created, err := services.GenerateAllCategoryLimits(int(now.Month()), now.Year())
+		if created > 0 {
+			log.Printf("Category limits: %d created for %02d.%d", created, now.Month(), now.Year())
+		}
+		return err
+	})
+	scheduler.Every(cfg.SchedulerInterval, "trash", func() error {
+		purged, err := services.PurgeTrash()
+		if purged > 0 {
+			log.Printf("Trash: %d items purged", purged)
+		}
+		return err
+	})
+	log.Printf("Background jobs scheduled every %s", cfg.SchedulerInterval)
+
 	// Setup API routes
-	api.SetupRoutes(router, db)
+	api.SetupRoutes(router, db, cfg)
 	log.Println("API routes configured")
 
 	// Start server
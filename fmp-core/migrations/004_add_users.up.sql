@@ -0,0 +1,41 @@
+CREATE TABLE users (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    telegram_id BIGINT UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TRIGGER update_users_updated_at BEFORE UPDATE ON users FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Default owner for rows created before users existed. It has no Telegram ID
+-- until claimed via DEFAULT_OWNER_TELEGRAM_ID.
+INSERT INTO users (id) VALUES ('00000000-0000-0000-0000-000000000001');
+
+ALTER TABLE categories ADD COLUMN user_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE transactions ADD COLUMN user_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE planned_expenses ADD COLUMN user_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE planned_incomes ADD COLUMN user_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE category_limits ADD COLUMN user_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE limit_exceeded ADD COLUMN user_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE notifications ADD COLUMN user_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES users(id) ON DELETE CASCADE;
+
+-- The backfill is done, new rows must always name their owner
+ALTER TABLE categories ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE transactions ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE planned_expenses ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE planned_incomes ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE category_limits ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE limit_exceeded ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE notifications ALTER COLUMN user_id DROP DEFAULT;
+
+-- Planned income is unique per month for each user, not globally
+ALTER TABLE planned_incomes DROP CONSTRAINT planned_incomes_month_year_key;
+ALTER TABLE planned_incomes ADD CONSTRAINT planned_incomes_user_id_month_year_key UNIQUE (user_id, month, year);
+
+-- Indexes for better performance
+CREATE INDEX idx_categories_user_id ON categories(user_id);
+CREATE INDEX idx_transactions_user_id ON transactions(user_id);
+CREATE INDEX idx_planned_expenses_user_id ON planned_expenses(user_id);
+CREATE INDEX idx_category_limits_user_id ON category_limits(user_id);
+CREATE INDEX idx_limit_exceeded_user_id ON limit_exceeded(user_id);
+CREATE INDEX idx_notifications_user_id ON notifications(user_id);
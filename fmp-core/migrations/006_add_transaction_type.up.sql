@@ -0,0 +1,4 @@
+-- Existing transactions were all recorded as spending
+ALTER TABLE transactions ADD COLUMN type VARCHAR(10) NOT NULL DEFAULT 'expense' CHECK (type IN ('income', 'expense'));
+
+CREATE INDEX idx_transactions_type ON transactions(type);
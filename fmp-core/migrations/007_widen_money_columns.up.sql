@@ -0,0 +1,9 @@
+-- DECIMAL(10,2) tops out below 100 million, which is too small for account
+-- balances and yearly totals
+ALTER TABLE transactions ALTER COLUMN amount TYPE DECIMAL(18,2);
+ALTER TABLE planned_expenses ALTER COLUMN amount TYPE DECIMAL(18,2);
+ALTER TABLE planned_incomes ALTER COLUMN amount TYPE DECIMAL(18,2);
+ALTER TABLE category_limits ALTER COLUMN limit_amount TYPE DECIMAL(18,2);
+ALTER TABLE limit_exceeded ALTER COLUMN limit_amount TYPE DECIMAL(18,2);
+ALTER TABLE limit_exceeded ALTER COLUMN actual_amount TYPE DECIMAL(18,2);
+ALTER TABLE accounts ALTER COLUMN opening_balance TYPE DECIMAL(18,2);
@@ -0,0 +1,51 @@
+-- Everything recorded so far is in rubles
+ALTER TABLE transactions ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'RUB';
+ALTER TABLE planned_expenses ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'RUB';
+ALTER TABLE planned_incomes ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'RUB';
+ALTER TABLE category_limits ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'RUB';
+
+-- One unit of base_currency costs rate units of quote_currency on date
+CREATE TABLE exchange_rates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    base_currency VARCHAR(3) NOT NULL,
+    quote_currency VARCHAR(3) NOT NULL,
+    rate NUMERIC NOT NULL CHECK (rate > 0),
+    date DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, base_currency, quote_currency, date),
+    CHECK (base_currency <> quote_currency)
+);
+
+CREATE TRIGGER update_exchange_rates_updated_at BEFORE UPDATE ON exchange_rates FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- convert_amount converts an amount at the latest rate known on the given
+-- date, falling back to the inverse pair, and fails when there is no rate
+CREATE OR REPLACE FUNCTION convert_amount(p_user_id UUID, p_amount NUMERIC, p_from VARCHAR, p_to VARCHAR, p_date DATE)
+RETURNS NUMERIC AS $$
+DECLARE
+    converted NUMERIC;
+BEGIN
+    IF p_amount IS NULL OR p_from = p_to THEN
+        RETURN p_amount;
+    END IF;
+
+    SELECT CASE WHEN base_currency = p_from THEN p_amount * rate ELSE p_amount / rate END INTO converted
+    FROM exchange_rates
+    WHERE user_id = p_user_id
+        AND date <= p_date
+        AND ((base_currency = p_from AND quote_currency = p_to) OR (base_currency = p_to AND quote_currency = p_from))
+    ORDER BY date DESC, base_currency = p_from DESC
+    LIMIT 1;
+
+    IF converted IS NULL THEN
+        RAISE EXCEPTION 'no exchange rate from % to % on %', p_from, p_to, p_date;
+    END IF;
+
+    RETURN converted;
+END;
+$$ language 'plpgsql' STABLE;
+
+-- Indexes for better performance
+CREATE INDEX idx_exchange_rates_lookup ON exchange_rates(user_id, base_currency, quote_currency, date);
@@ -0,0 +1,30 @@
+CREATE TABLE recurring_expenses (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    account_id UUID REFERENCES accounts(id) ON DELETE SET NULL,
+    amount DECIMAL(18,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
+    description TEXT,
+    frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('daily', 'weekly', 'monthly', 'yearly')),
+    repeat_interval INTEGER NOT NULL DEFAULT 1 CHECK (repeat_interval >= 1),
+    repeat_count INTEGER CHECK (repeat_count >= 1),
+    until DATE,
+    start_date DATE NOT NULL,
+    auto_post BOOLEAN NOT NULL DEFAULT FALSE,
+    generated_until DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (repeat_count IS NULL OR until IS NULL)
+);
+
+CREATE TRIGGER update_recurring_expenses_updated_at BEFORE UPDATE ON recurring_expenses FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Occurrences remember their rule, and the transaction once they are posted
+ALTER TABLE planned_expenses ADD COLUMN recurring_expense_id UUID REFERENCES recurring_expenses(id) ON DELETE SET NULL;
+ALTER TABLE planned_expenses ADD COLUMN transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL;
+
+-- Indexes for better performance
+CREATE INDEX idx_recurring_expenses_user_id ON recurring_expenses(user_id);
+CREATE UNIQUE INDEX idx_planned_expenses_occurrence ON planned_expenses(recurring_expense_id, planned_date);
+CREATE INDEX idx_planned_expenses_transaction_id ON planned_expenses(transaction_id);
@@ -0,0 +1,26 @@
+CREATE TABLE transaction_splits (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    amount DECIMAL(18,2) NOT NULL CHECK (amount > 0),
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TRIGGER update_transaction_splits_updated_at BEFORE UPDATE ON transaction_splits FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Transactions broken down by category: a split transaction contributes its
+-- split lines, any other transaction contributes itself
+CREATE VIEW transaction_lines AS
+SELECT t.id AS transaction_id, t.user_id, s.category_id, t.account_id, t.type, s.amount, t.currency, t.date
+FROM transactions t
+JOIN transaction_splits s ON s.transaction_id = t.id
+UNION ALL
+SELECT t.id AS transaction_id, t.user_id, t.category_id, t.account_id, t.type, t.amount, t.currency, t.date
+FROM transactions t
+WHERE NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id);
+
+-- Indexes for better performance
+CREATE INDEX idx_transaction_splits_transaction_id ON transaction_splits(transaction_id);
+CREATE INDEX idx_transaction_splits_category_id ON transaction_splits(category_id);
@@ -0,0 +1,40 @@
+CREATE TABLE transfers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    to_account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    amount DECIMAL(18,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    to_amount DECIMAL(18,2) NOT NULL CHECK (to_amount > 0),
+    to_currency VARCHAR(3) NOT NULL,
+    description TEXT,
+    date TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (from_account_id <> to_account_id)
+);
+
+CREATE TRIGGER update_transfers_updated_at BEFORE UPDATE ON transfers FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- A transfer is recorded as an expense on the source account and an income on
+-- the destination account. Neither leg has a category.
+ALTER TABLE transactions ADD COLUMN transfer_id UUID REFERENCES transfers(id) ON DELETE CASCADE;
+ALTER TABLE transactions ALTER COLUMN category_id DROP NOT NULL;
+ALTER TABLE transactions ADD CONSTRAINT transactions_category_or_transfer CHECK (category_id IS NOT NULL OR transfer_id IS NOT NULL);
+
+-- Transfers are not spending, keep them out of the analytics
+CREATE OR REPLACE VIEW transaction_lines AS
+SELECT t.id AS transaction_id, t.user_id, s.category_id, t.account_id, t.type, s.amount, t.currency, t.date
+FROM transactions t
+JOIN transaction_splits s ON s.transaction_id = t.id
+WHERE t.transfer_id IS NULL
+UNION ALL
+SELECT t.id AS transaction_id, t.user_id, t.category_id, t.account_id, t.type, t.amount, t.currency, t.date
+FROM transactions t
+WHERE t.transfer_id IS NULL
+  AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id);
+
+-- Indexes for better performance
+CREATE INDEX idx_transfers_user_id ON transfers(user_id);
+CREATE INDEX idx_transfers_date ON transfers(date);
+CREATE INDEX idx_transactions_transfer_id ON transactions(transfer_id);
@@ -0,0 +1,20 @@
+CREATE TABLE attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    transaction_id UUID REFERENCES transactions(id) ON DELETE CASCADE,
+    planned_expense_id UUID REFERENCES planned_expenses(id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    -- Every attachment belongs to exactly one transaction or planned expense
+    CHECK ((transaction_id IS NULL) <> (planned_expense_id IS NULL))
+);
+
+CREATE TRIGGER update_attachments_updated_at BEFORE UPDATE ON attachments FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Indexes for better performance
+CREATE INDEX idx_attachments_transaction_id ON attachments(transaction_id);
+CREATE INDEX idx_attachments_planned_expense_id ON attachments(planned_expense_id);
@@ -0,0 +1,51 @@
+-- With rollover, what is left of a month's limit (or overspent) carries into
+-- the limit of the same category for the next month
+ALTER TABLE category_limits ADD COLUMN rollover BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Amount carried into a limit from the previous month, in the limit currency.
+-- The previous month's limit must have rollover, its effective limit includes
+-- what it carried itself, and its spending includes subcategories.
+CREATE OR REPLACE FUNCTION limit_carry_over(p_limit_id UUID)
+RETURNS NUMERIC AS $$
+DECLARE
+    cur category_limits%ROWTYPE;
+    prev category_limits%ROWTYPE;
+    prev_start DATE;
+    prev_spent NUMERIC;
+BEGIN
+    SELECT * INTO cur FROM category_limits WHERE id = p_limit_id;
+    IF NOT FOUND THEN
+        RETURN NULL;
+    END IF;
+
+    prev_start := make_date(cur.year, cur.month, 1) - INTERVAL '1 month';
+    SELECT * INTO prev FROM category_limits
+    WHERE category_id = cur.category_id
+      AND month = EXTRACT(MONTH FROM prev_start)
+      AND year = EXTRACT(YEAR FROM prev_start);
+    IF NOT FOUND OR NOT prev.rollover THEN
+        RETURN 0;
+    END IF;
+
+    WITH RECURSIVE subtree AS (
+        SELECT prev.category_id AS id
+        UNION
+        SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+    )
+    SELECT COALESCE(SUM(convert_amount(prev.user_id, t.amount, t.currency, prev.currency, t.date::date)), 0)
+    INTO prev_spent
+    FROM transaction_lines t
+    JOIN subtree s ON s.id = t.category_id
+    WHERE t.type = 'expense'
+      AND t.date >= prev_start
+      AND t.date < make_date(cur.year, cur.month, 1);
+
+    RETURN convert_amount(
+        cur.user_id,
+        prev.limit_amount + limit_carry_over(prev.id) - prev_spent,
+        prev.currency,
+        cur.currency,
+        (make_date(cur.year, cur.month, 1) - INTERVAL '1 day')::date
+    );
+END;
+$$ language 'plpgsql' STABLE;
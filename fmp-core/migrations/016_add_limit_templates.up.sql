@@ -0,0 +1,27 @@
+-- Default limit of a category, used when generating the limits of a month
+CREATE TABLE limit_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    limit_amount DECIMAL(18,2) NOT NULL,
+    currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
+    rollover BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(category_id)
+);
+
+CREATE TRIGGER update_limit_templates_updated_at BEFORE UPDATE ON limit_templates FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Months whose limits have been generated, so the scheduled job does not
+-- bring back limits the user deleted afterwards
+CREATE TABLE limit_generations (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month INTEGER NOT NULL,
+    year INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, month, year)
+);
+
+-- Indexes for better performance
+CREATE INDEX idx_limit_templates_user_id ON limit_templates(user_id);
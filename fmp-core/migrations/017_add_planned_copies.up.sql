@@ -0,0 +1,7 @@
+-- Copies remember their source, so copying a month again does not duplicate them
+ALTER TABLE planned_incomes ADD COLUMN copied_from_id UUID REFERENCES planned_incomes(id) ON DELETE SET NULL;
+ALTER TABLE planned_expenses ADD COLUMN copied_from_id UUID REFERENCES planned_expenses(id) ON DELETE SET NULL;
+
+-- Indexes for better performance
+CREATE UNIQUE INDEX idx_planned_incomes_copy ON planned_incomes(copied_from_id, month, year);
+CREATE UNIQUE INDEX idx_planned_expenses_copy ON planned_expenses(copied_from_id, planned_date);
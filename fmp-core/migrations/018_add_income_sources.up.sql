@@ -0,0 +1,12 @@
+-- A month can have several planned income sources, e.g. salary and rent,
+-- each optionally linked to the category its income is recorded in
+ALTER TABLE planned_incomes DROP CONSTRAINT planned_incomes_user_id_month_year_key;
+ALTER TABLE planned_incomes ADD COLUMN name VARCHAR(255) NOT NULL DEFAULT 'Income';
+ALTER TABLE planned_incomes ADD COLUMN category_id UUID REFERENCES categories(id) ON DELETE SET NULL;
+
+-- Existing single rows become a source named after their description
+UPDATE planned_incomes SET name = LEFT(description, 255) WHERE COALESCE(description, '') <> '';
+
+-- Indexes for better performance
+CREATE INDEX idx_planned_incomes_user_month_year ON planned_incomes(user_id, year, month);
+CREATE INDEX idx_planned_incomes_category_id ON planned_incomes(category_id);
@@ -0,0 +1,9 @@
+-- Completing a planned expense either creates its transaction or links an
+-- existing one, only created transactions are removed when it is reopened
+ALTER TABLE planned_expenses ADD COLUMN transaction_created BOOLEAN NOT NULL DEFAULT false;
+
+-- Until now transactions were only linked by posting recurring expenses
+UPDATE planned_expenses SET transaction_created = true WHERE transaction_id IS NOT NULL;
+
+-- A transaction settles at most one planned expense
+CREATE UNIQUE INDEX idx_planned_expenses_transaction_unique ON planned_expenses(transaction_id) WHERE transaction_id IS NOT NULL;
@@ -0,0 +1,36 @@
+-- Savings goals, saved up by contributions and by the transactions of a
+-- linked account or category
+CREATE TABLE goals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    target_amount DECIMAL(18,2) NOT NULL CHECK (target_amount > 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
+    start_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    deadline DATE,
+    account_id UUID REFERENCES accounts(id) ON DELETE SET NULL,
+    category_id UUID REFERENCES categories(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (account_id IS NULL OR category_id IS NULL)
+);
+
+-- Money put into a goal by hand, negative amounts take it back out
+CREATE TABLE goal_contributions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    goal_id UUID NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+    amount DECIMAL(18,2) NOT NULL CHECK (amount <> 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
+    date DATE NOT NULL DEFAULT CURRENT_DATE,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TRIGGER update_goals_updated_at BEFORE UPDATE ON goals FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_goal_contributions_updated_at BEFORE UPDATE ON goal_contributions FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Indexes for better performance
+CREATE INDEX idx_goals_user_id ON goals(user_id);
+CREATE INDEX idx_goal_contributions_goal_id ON goal_contributions(goal_id, date);
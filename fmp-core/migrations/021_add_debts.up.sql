@@ -0,0 +1,57 @@
+-- People and institutions money is lent to or borrowed from
+CREATE TABLE counterparties (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(user_id, name)
+);
+
+-- Loans given (lent) and taken (borrowed). Interest is a yearly percentage,
+-- loans with a schedule are paid off monthly over term_months.
+CREATE TABLE debts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    counterparty_id UUID NOT NULL REFERENCES counterparties(id),
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('lent', 'borrowed')),
+    name VARCHAR(255) NOT NULL,
+    principal DECIMAL(18,2) NOT NULL CHECK (principal > 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
+    interest_rate DECIMAL(7,4) NOT NULL DEFAULT 0 CHECK (interest_rate >= 0),
+    schedule VARCHAR(20) NOT NULL DEFAULT 'none' CHECK (schedule IN ('none', 'annuity', 'differentiated')),
+    term_months INTEGER CHECK (term_months > 0),
+    start_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    account_id UUID REFERENCES accounts(id) ON DELETE SET NULL,
+    category_id UUID REFERENCES categories(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (schedule = 'none' OR term_months IS NOT NULL)
+);
+
+-- Payments split into the principal and interest they pay off, optionally
+-- recorded as a transaction
+CREATE TABLE debt_payments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    debt_id UUID NOT NULL REFERENCES debts(id) ON DELETE CASCADE,
+    amount DECIMAL(18,2) NOT NULL CHECK (amount > 0),
+    principal_amount DECIMAL(18,2) NOT NULL,
+    interest_amount DECIMAL(18,2) NOT NULL DEFAULT 0,
+    date DATE NOT NULL DEFAULT CURRENT_DATE,
+    note TEXT NOT NULL DEFAULT '',
+    transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (principal_amount + interest_amount = amount)
+);
+
+CREATE TRIGGER update_counterparties_updated_at BEFORE UPDATE ON counterparties FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_debts_updated_at BEFORE UPDATE ON debts FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_debt_payments_updated_at BEFORE UPDATE ON debt_payments FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Indexes for better performance
+CREATE INDEX idx_debts_user_id ON debts(user_id);
+CREATE INDEX idx_debts_counterparty_id ON debts(counterparty_id);
+CREATE INDEX idx_debt_payments_debt_id ON debt_payments(debt_id, date);
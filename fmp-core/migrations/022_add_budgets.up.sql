@@ -0,0 +1,52 @@
+-- Budgets own the data: the user_id column of the data tables holds the budget
+-- a row belongs to. A personal budget shares its id with its user, a shared
+-- budget has a users row of its own without a Telegram account.
+--
+-- So user_id on categories, transactions, limits and the other data tables
+-- means "budget", and budgets.id always has a users row to point at. A users
+-- row with a NULL telegram_id is a shared budget and never a person: it has
+-- no session, is never a member of a budget and is deleted with its budget.
+-- Only budget_members.user_id, budget_invites.created_by and accepted_by,
+-- and users.telegram_id refer to people.
+CREATE TABLE budgets (
+    id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    is_personal BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Owners manage the budget and its members, editors change its data and
+-- viewers only read it
+CREATE TABLE budget_members (
+    budget_id UUID NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(10) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (budget_id, user_id)
+);
+
+-- Single-use invites, accepted through the bot's /start deep link
+CREATE TABLE budget_invites (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    budget_id UUID NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(10) NOT NULL CHECK (role IN ('editor', 'viewer')),
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TRIGGER update_budgets_updated_at BEFORE UPDATE ON budgets FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_budget_members_updated_at BEFORE UPDATE ON budget_members FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Every user so far keeps their data in a personal budget
+INSERT INTO budgets (id, name, is_personal) SELECT id, 'Personal', true FROM users;
+INSERT INTO budget_members (budget_id, user_id, role) SELECT id, id, 'owner' FROM users;
+
+-- Indexes for better performance
+CREATE INDEX idx_budget_members_user_id ON budget_members(user_id);
+CREATE INDEX idx_budget_invites_budget_id ON budget_invites(budget_id);
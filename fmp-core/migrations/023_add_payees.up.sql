@@ -0,0 +1,34 @@
+-- Payee names and aliases match descriptions case-insensitively, with ё
+-- spelled as е and runs of spaces collapsed
+CREATE OR REPLACE FUNCTION normalize_payee_text(value TEXT)
+RETURNS TEXT AS $$
+    SELECT regexp_replace(lower(translate(btrim(value), 'Ёё', 'Ее')), '\s+', ' ', 'g')
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Who a transaction was paid to or received from
+CREATE TABLE payees (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+CREATE TRIGGER update_payees_updated_at BEFORE UPDATE ON payees FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Other spellings of a payee, each one names a single payee of the budget
+CREATE TABLE payee_aliases (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    payee_id UUID NOT NULL REFERENCES payees(id) ON DELETE CASCADE,
+    alias VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE transactions ADD COLUMN payee_id UUID REFERENCES payees(id) ON DELETE SET NULL;
+
+-- Indexes for better performance
+CREATE UNIQUE INDEX idx_payee_aliases_user_alias ON payee_aliases(user_id, normalize_payee_text(alias));
+CREATE INDEX idx_payee_aliases_payee_id ON payee_aliases(payee_id);
+CREATE INDEX idx_transactions_payee_id ON transactions(payee_id);
@@ -0,0 +1,40 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Text is searched with both the Russian and the English configuration, so
+-- either language is stemmed
+CREATE OR REPLACE FUNCTION search_vector(document TEXT)
+RETURNS tsvector AS $$
+    SELECT to_tsvector('russian', COALESCE(document, '')) || to_tsvector('english', COALESCE(document, ''))
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION search_query(q TEXT)
+RETURNS tsquery AS $$
+    SELECT websearch_to_tsquery('russian', q) || websearch_to_tsquery('english', q)
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Documents match on full-text search, or on trigram word similarity so that
+-- typos still find something
+CREATE OR REPLACE FUNCTION search_matches(document TEXT, q TEXT)
+RETURNS BOOLEAN AS $$
+    SELECT search_vector(document) @@ search_query(q) OR q <% COALESCE(document, '')
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION search_rank(document TEXT, q TEXT)
+RETURNS REAL AS $$
+    SELECT ts_rank_cd(search_vector(document), search_query(q)) + word_similarity(q, COALESCE(document, ''))
+$$ LANGUAGE sql STABLE;
+
+-- Searchable text of transactions and planned expenses: the description,
+-- the category names, the payee and the notes of split lines
+CREATE VIEW search_documents AS
+SELECT 'transaction'::TEXT AS kind, t.id, t.user_id, t.date, t.amount, t.currency, COALESCE(t.description, '') AS description,
+    concat_ws(' ', t.description, c.name, p.name,
+        (SELECT string_agg(concat_ws(' ', sc.name, s.note), ' ') FROM transaction_splits s JOIN categories sc ON sc.id = s.category_id WHERE s.transaction_id = t.id)) AS document
+FROM transactions t
+LEFT JOIN categories c ON c.id = t.category_id
+LEFT JOIN payees p ON p.id = t.payee_id
+UNION ALL
+SELECT 'planned_expense'::TEXT AS kind, e.id, e.user_id, e.planned_date AS date, e.amount, e.currency, COALESCE(e.description, '') AS description,
+    concat_ws(' ', e.description, c.name) AS document
+FROM planned_expenses e
+LEFT JOIN categories c ON c.id = e.category_id;
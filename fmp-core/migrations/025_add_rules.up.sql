@@ -0,0 +1,25 @@
+-- Rules fill in the category and tags of new transactions. Rules run by
+-- ascending priority and the first one whose conditions all hold applies.
+CREATE TABLE rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    description_contains VARCHAR(255) NOT NULL DEFAULT '',
+    description_regex VARCHAR(255) NOT NULL DEFAULT '',
+    min_amount DECIMAL(18,2),
+    max_amount DECIMAL(18,2),
+    payee_id UUID REFERENCES payees(id) ON DELETE CASCADE,
+    account_id UUID REFERENCES accounts(id) ON DELETE CASCADE,
+    category_id UUID REFERENCES categories(id) ON DELETE CASCADE,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (category_id IS NOT NULL OR cardinality(tags) > 0)
+);
+
+CREATE TRIGGER update_rules_updated_at BEFORE UPDATE ON rules FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Indexes for better performance
+CREATE INDEX idx_rules_user_id_priority ON rules(user_id, priority);
@@ -0,0 +1,128 @@
+-- Deleting moves rows to the trash: they get deleted_at instead of being
+-- removed, can be restored from there and are purged for good after the
+-- retention period
+ALTER TABLE categories ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE accounts ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE transactions ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE transfers ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE planned_expenses ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE recurring_expenses ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE planned_incomes ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE category_limits ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE goals ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE goal_contributions ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE debts ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE debt_payments ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- What was deleted, one entry per delete. Rows deleted along with the item,
+-- like the transactions of a category, share its deleted_at and are restored
+-- or purged with it.
+CREATE TABLE trash (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    item_id UUID NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (kind, item_id)
+);
+
+CREATE INDEX idx_trash_user_id ON trash(user_id);
+CREATE INDEX idx_trash_deleted_at ON trash(deleted_at);
+
+-- The purge job only looks at deleted rows
+CREATE INDEX idx_categories_deleted_at ON categories(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_accounts_deleted_at ON accounts(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_transactions_deleted_at ON transactions(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_transfers_deleted_at ON transfers(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_planned_expenses_deleted_at ON planned_expenses(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_recurring_expenses_deleted_at ON recurring_expenses(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_planned_incomes_deleted_at ON planned_incomes(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_category_limits_deleted_at ON category_limits(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_goals_deleted_at ON goals(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_goal_contributions_deleted_at ON goal_contributions(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_debts_deleted_at ON debts(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_debt_payments_deleted_at ON debt_payments(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Rows in the trash do not keep new ones from being created. Deleted
+-- occurrences of recurring expenses still count, so they are not generated again.
+ALTER TABLE category_limits DROP CONSTRAINT category_limits_category_id_month_year_key;
+CREATE UNIQUE INDEX idx_category_limits_category_month ON category_limits(category_id, month, year) WHERE deleted_at IS NULL;
+
+DROP INDEX idx_planned_incomes_copy;
+CREATE UNIQUE INDEX idx_planned_incomes_copy ON planned_incomes(copied_from_id, month, year) WHERE deleted_at IS NULL;
+
+DROP INDEX idx_planned_expenses_copy;
+CREATE UNIQUE INDEX idx_planned_expenses_copy ON planned_expenses(copied_from_id, planned_date) WHERE deleted_at IS NULL;
+
+-- Analytics leave out deleted transactions
+CREATE OR REPLACE VIEW transaction_lines AS
+SELECT t.id AS transaction_id, t.user_id, s.category_id, t.account_id, t.type, s.amount, t.currency, t.date
+FROM transactions t
+JOIN transaction_splits s ON s.transaction_id = t.id
+WHERE t.transfer_id IS NULL AND t.deleted_at IS NULL
+UNION ALL
+SELECT t.id AS transaction_id, t.user_id, t.category_id, t.account_id, t.type, t.amount, t.currency, t.date
+FROM transactions t
+WHERE t.transfer_id IS NULL AND t.deleted_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id);
+
+CREATE OR REPLACE VIEW search_documents AS
+SELECT 'transaction'::TEXT AS kind, t.id, t.user_id, t.date, t.amount, t.currency, COALESCE(t.description, '') AS description,
+    concat_ws(' ', t.description, c.name, p.name,
+        (SELECT string_agg(concat_ws(' ', sc.name, s.note), ' ') FROM transaction_splits s JOIN categories sc ON sc.id = s.category_id WHERE s.transaction_id = t.id)) AS document
+FROM transactions t
+LEFT JOIN categories c ON c.id = t.category_id
+LEFT JOIN payees p ON p.id = t.payee_id
+WHERE t.deleted_at IS NULL
+UNION ALL
+SELECT 'planned_expense'::TEXT AS kind, e.id, e.user_id, e.planned_date AS date, e.amount, e.currency, COALESCE(e.description, '') AS description,
+    concat_ws(' ', e.description, c.name) AS document
+FROM planned_expenses e
+LEFT JOIN categories c ON c.id = e.category_id
+WHERE e.deleted_at IS NULL;
+
+-- A deleted limit carries nothing over
+CREATE OR REPLACE FUNCTION limit_carry_over(p_limit_id UUID)
+RETURNS NUMERIC AS $$
+DECLARE
+    cur category_limits%ROWTYPE;
+    prev category_limits%ROWTYPE;
+    prev_start DATE;
+    prev_spent NUMERIC;
+BEGIN
+    SELECT * INTO cur FROM category_limits WHERE id = p_limit_id;
+    IF NOT FOUND THEN
+        RETURN NULL;
+    END IF;
+
+    prev_start := make_date(cur.year, cur.month, 1) - INTERVAL '1 month';
+    SELECT * INTO prev FROM category_limits
+    WHERE category_id = cur.category_id
+      AND month = EXTRACT(MONTH FROM prev_start)
+      AND year = EXTRACT(YEAR FROM prev_start)
+      AND deleted_at IS NULL;
+    IF NOT FOUND OR NOT prev.rollover THEN
+        RETURN 0;
+    END IF;
+
+    WITH RECURSIVE subtree AS (
+        SELECT prev.category_id AS id
+        UNION
+        SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+    )
+    SELECT COALESCE(SUM(convert_amount(prev.user_id, t.amount, t.currency, prev.currency, t.date::date)), 0)
+    INTO prev_spent
+    FROM transaction_lines t
+    JOIN subtree s ON s.id = t.category_id
+    WHERE t.type = 'expense'
+      AND t.date >= prev_start
+      AND t.date < make_date(cur.year, cur.month, 1);
+
+    RETURN convert_amount(
+        cur.user_id,
+        prev.limit_amount + limit_carry_over(prev.id) - prev_spent,
+        prev.currency,
+        cur.currency,
+        (make_date(cur.year, cur.month, 1) - INTERVAL '1 day')::date
+    );
+END;
+$$ language 'plpgsql' STABLE;
@@ -0,0 +1,23 @@
+-- A category in use is not deleted: what refers to it is moved to another
+-- category first, or purged from the trash before it. Deleting a category no
+-- longer takes its transactions, plans, limits and rules with it.
+ALTER TABLE transactions DROP CONSTRAINT transactions_category_id_fkey;
+ALTER TABLE transactions ADD CONSTRAINT transactions_category_id_fkey FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT;
+
+ALTER TABLE transaction_splits DROP CONSTRAINT transaction_splits_category_id_fkey;
+ALTER TABLE transaction_splits ADD CONSTRAINT transaction_splits_category_id_fkey FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT;
+
+ALTER TABLE planned_expenses DROP CONSTRAINT planned_expenses_category_id_fkey;
+ALTER TABLE planned_expenses ADD CONSTRAINT planned_expenses_category_id_fkey FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT;
+
+ALTER TABLE recurring_expenses DROP CONSTRAINT recurring_expenses_category_id_fkey;
+ALTER TABLE recurring_expenses ADD CONSTRAINT recurring_expenses_category_id_fkey FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT;
+
+ALTER TABLE category_limits DROP CONSTRAINT category_limits_category_id_fkey;
+ALTER TABLE category_limits ADD CONSTRAINT category_limits_category_id_fkey FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT;
+
+ALTER TABLE limit_templates DROP CONSTRAINT limit_templates_category_id_fkey;
+ALTER TABLE limit_templates ADD CONSTRAINT limit_templates_category_id_fkey FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT;
+
+ALTER TABLE rules DROP CONSTRAINT rules_category_id_fkey;
+ALTER TABLE rules ADD CONSTRAINT rules_category_id_fkey FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT;
@@ -0,0 +1,37 @@
+-- Payees, rules, tags and attachments go to the trash like the rest. Limit
+-- templates, exchange rates and counterparties are still deleted right away:
+-- templates only shape limits generated later, rates can be entered again
+-- and a counterparty can only be deleted once no debt names it.
+ALTER TABLE payees ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE rules ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE tags ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE attachments ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_payees_deleted_at ON payees(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_rules_deleted_at ON rules(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_tags_deleted_at ON tags(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_attachments_deleted_at ON attachments(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Names in the trash can be used again. Aliases of a payee in the trash stay
+-- taken, so restoring it can not make two payees match the same description.
+ALTER TABLE payees DROP CONSTRAINT payees_user_id_name_key;
+CREATE UNIQUE INDEX idx_payees_user_name ON payees(user_id, name) WHERE deleted_at IS NULL;
+
+ALTER TABLE tags DROP CONSTRAINT tags_user_id_name_key;
+CREATE UNIQUE INDEX idx_tags_user_name ON tags(user_id, name) WHERE deleted_at IS NULL;
+
+-- Search leaves out payees in the trash
+CREATE OR REPLACE VIEW search_documents AS
+SELECT 'transaction'::TEXT AS kind, t.id, t.user_id, t.date, t.amount, t.currency, COALESCE(t.description, '') AS description,
+    concat_ws(' ', t.description, c.name, p.name,
+        (SELECT string_agg(concat_ws(' ', sc.name, s.note), ' ') FROM transaction_splits s JOIN categories sc ON sc.id = s.category_id WHERE s.transaction_id = t.id)) AS document
+FROM transactions t
+LEFT JOIN categories c ON c.id = t.category_id
+LEFT JOIN payees p ON p.id = t.payee_id AND p.deleted_at IS NULL
+WHERE t.deleted_at IS NULL
+UNION ALL
+SELECT 'planned_expense'::TEXT AS kind, e.id, e.user_id, e.planned_date AS date, e.amount, e.currency, COALESCE(e.description, '') AS description,
+    concat_ws(' ', e.description, c.name) AS document
+FROM planned_expenses e
+LEFT JOIN categories c ON c.id = e.category_id
+WHERE e.deleted_at IS NULL;
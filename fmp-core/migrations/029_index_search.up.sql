@@ -0,0 +1,55 @@
+-- Search goes through indexes: searchable rows keep their tsvector in a
+-- stored column, and descriptions, names and notes have trigram indexes
+ALTER TABLE transactions ADD COLUMN search_tsv tsvector GENERATED ALWAYS AS (search_vector(description)) STORED;
+ALTER TABLE planned_expenses ADD COLUMN search_tsv tsvector GENERATED ALWAYS AS (search_vector(description)) STORED;
+ALTER TABLE transaction_splits ADD COLUMN search_tsv tsvector GENERATED ALWAYS AS (search_vector(note)) STORED;
+ALTER TABLE categories ADD COLUMN search_tsv tsvector GENERATED ALWAYS AS (search_vector(name)) STORED;
+ALTER TABLE payees ADD COLUMN search_tsv tsvector GENERATED ALWAYS AS (search_vector(name)) STORED;
+
+CREATE INDEX idx_transactions_search_tsv ON transactions USING GIN (search_tsv);
+CREATE INDEX idx_planned_expenses_search_tsv ON planned_expenses USING GIN (search_tsv);
+CREATE INDEX idx_transaction_splits_search_tsv ON transaction_splits USING GIN (search_tsv);
+CREATE INDEX idx_categories_search_tsv ON categories USING GIN (search_tsv);
+CREATE INDEX idx_payees_search_tsv ON payees USING GIN (search_tsv);
+
+CREATE INDEX idx_transactions_description_trgm ON transactions USING GIN (description gin_trgm_ops);
+CREATE INDEX idx_planned_expenses_description_trgm ON planned_expenses USING GIN (description gin_trgm_ops);
+CREATE INDEX idx_transaction_splits_note_trgm ON transaction_splits USING GIN (note gin_trgm_ops);
+CREATE INDEX idx_categories_name_trgm ON categories USING GIN (name gin_trgm_ops);
+CREATE INDEX idx_payees_name_trgm ON payees USING GIN (name gin_trgm_ops);
+
+-- Rows of the user matching the query on their own description, or on the
+-- name of their category or payee, or on a split line. Each branch can use
+-- the indexes above, the documents are only ranked and highlighted for the
+-- rows found here.
+CREATE OR REPLACE FUNCTION search_ids(p_user_id UUID, q TEXT)
+RETURNS TABLE (kind TEXT, id UUID) AS $$
+    WITH matched_categories AS (
+        SELECT c.id FROM categories c
+        WHERE c.user_id = p_user_id AND (c.search_tsv @@ search_query(q) OR q <% c.name)
+    ), matched_payees AS (
+        SELECT p.id FROM payees p
+        WHERE p.user_id = p_user_id AND p.deleted_at IS NULL AND (p.search_tsv @@ search_query(q) OR q <% p.name)
+    )
+    SELECT 'transaction'::TEXT, t.id FROM transactions t
+    WHERE t.user_id = p_user_id AND t.deleted_at IS NULL AND (t.search_tsv @@ search_query(q) OR q <% t.description)
+    UNION
+    SELECT 'transaction'::TEXT, t.id FROM transactions t JOIN matched_categories c ON c.id = t.category_id
+    WHERE t.deleted_at IS NULL
+    UNION
+    SELECT 'transaction'::TEXT, t.id FROM transactions t JOIN matched_payees p ON p.id = t.payee_id
+    WHERE t.deleted_at IS NULL
+    UNION
+    SELECT 'transaction'::TEXT, t.id FROM transaction_splits s JOIN transactions t ON t.id = s.transaction_id
+    WHERE t.user_id = p_user_id AND t.deleted_at IS NULL
+      AND (s.search_tsv @@ search_query(q) OR q <% s.note OR s.category_id IN (SELECT id FROM matched_categories))
+    UNION
+    SELECT 'planned_expense'::TEXT, e.id FROM planned_expenses e
+    WHERE e.user_id = p_user_id AND e.deleted_at IS NULL AND (e.search_tsv @@ search_query(q) OR q <% e.description)
+    UNION
+    SELECT 'planned_expense'::TEXT, e.id FROM planned_expenses e JOIN matched_categories c ON c.id = e.category_id
+    WHERE e.deleted_at IS NULL
+$$ LANGUAGE sql STABLE;
+
+-- Replaced by search_ids
+DROP FUNCTION search_matches(TEXT, TEXT);
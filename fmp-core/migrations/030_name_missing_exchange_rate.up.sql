@@ -0,0 +1,27 @@
+-- A missing rate raises its own SQLSTATE with the pair in the detail, so the
+-- API can tell the user which rate to add
+CREATE OR REPLACE FUNCTION convert_amount(p_user_id UUID, p_amount NUMERIC, p_from VARCHAR, p_to VARCHAR, p_date DATE)
+RETURNS NUMERIC AS $$
+DECLARE
+    converted NUMERIC;
+BEGIN
+    IF p_amount IS NULL OR p_from = p_to THEN
+        RETURN p_amount;
+    END IF;
+
+    SELECT CASE WHEN base_currency = p_from THEN p_amount * rate ELSE p_amount / rate END INTO converted
+    FROM exchange_rates
+    WHERE user_id = p_user_id
+        AND date <= p_date
+        AND ((base_currency = p_from AND quote_currency = p_to) OR (base_currency = p_to AND quote_currency = p_from))
+    ORDER BY date DESC, base_currency = p_from DESC
+    LIMIT 1;
+
+    IF converted IS NULL THEN
+        RAISE EXCEPTION 'no exchange rate from % to % on %', p_from, p_to, p_date
+            USING ERRCODE = 'FX001', DETAIL = concat_ws(' ', p_from, p_to, p_date);
+    END IF;
+
+    RETURN converted;
+END;
+$$ language 'plpgsql' STABLE;
@@ -0,0 +1,69 @@
+-- Carry-over walks the chain of rollover months in one query instead of
+-- calling itself once per month. The chain stops at the first month without
+-- a rollover limit, and the spending of every month in it is summed in the
+-- same query, so a summary no longer repeats the walk month by month.
+CREATE OR REPLACE FUNCTION limit_carry_over(p_limit_id UUID)
+RETURNS NUMERIC AS $$
+DECLARE
+    cur category_limits%ROWTYPE;
+    link RECORD;
+    carried NUMERIC := 0;
+    carried_currency VARCHAR;
+BEGIN
+    SELECT * INTO cur FROM category_limits WHERE id = p_limit_id;
+    IF NOT FOUND THEN
+        RETURN NULL;
+    END IF;
+
+    -- The rollover months right before this one, oldest first. What a month
+    -- has left includes what it carried itself and is carried over in the
+    -- currency of the next month at the rate of its last day.
+    FOR link IN
+        WITH RECURSIVE chain AS (
+            SELECT l.limit_amount, l.currency, make_date(l.year, l.month, 1) AS start
+            FROM category_limits l
+            WHERE l.category_id = cur.category_id AND l.rollover AND l.deleted_at IS NULL
+              AND l.month = EXTRACT(MONTH FROM make_date(cur.year, cur.month, 1) - INTERVAL '1 month')
+              AND l.year = EXTRACT(YEAR FROM make_date(cur.year, cur.month, 1) - INTERVAL '1 month')
+            UNION ALL
+            SELECT l.limit_amount, l.currency, make_date(l.year, l.month, 1)
+            FROM chain ch
+            JOIN category_limits l ON l.category_id = cur.category_id AND l.rollover AND l.deleted_at IS NULL
+              AND l.month = EXTRACT(MONTH FROM ch.start - INTERVAL '1 month')
+              AND l.year = EXTRACT(YEAR FROM ch.start - INTERVAL '1 month')
+        ),
+        subtree AS (
+            SELECT cur.category_id AS id
+            UNION
+            SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+        )
+        SELECT ch.limit_amount, ch.currency, ch.start,
+            (SELECT COALESCE(SUM(convert_amount(cur.user_id, t.amount, t.currency, ch.currency, t.date::date)), 0)
+             FROM transaction_lines t
+             JOIN subtree s ON s.id = t.category_id
+             WHERE t.type = 'expense'
+               AND t.date >= ch.start
+               AND t.date < ch.start + INTERVAL '1 month') AS spent
+        FROM chain ch
+        ORDER BY ch.start
+    LOOP
+        IF carried_currency IS NOT NULL THEN
+            carried := convert_amount(cur.user_id, carried, carried_currency, link.currency, (link.start - INTERVAL '1 day')::date);
+        END IF;
+        carried := link.limit_amount + carried - link.spent;
+        carried_currency := link.currency;
+    END LOOP;
+
+    IF carried_currency IS NULL THEN
+        RETURN 0;
+    END IF;
+
+    RETURN convert_amount(
+        cur.user_id,
+        carried,
+        carried_currency,
+        cur.currency,
+        (make_date(cur.year, cur.month, 1) - INTERVAL '1 day')::date
+    );
+END;
+$$ language 'plpgsql' STABLE;
@@ -0,0 +1,30 @@
+-- A category in the trash no longer finds the transactions and planned
+-- expenses filed under it, like a payee in the trash
+CREATE OR REPLACE FUNCTION search_ids(p_user_id UUID, q TEXT)
+RETURNS TABLE (kind TEXT, id UUID) AS $$
+    WITH matched_categories AS (
+        SELECT c.id FROM categories c
+        WHERE c.user_id = p_user_id AND c.deleted_at IS NULL AND (c.search_tsv @@ search_query(q) OR q <% c.name)
+    ), matched_payees AS (
+        SELECT p.id FROM payees p
+        WHERE p.user_id = p_user_id AND p.deleted_at IS NULL AND (p.search_tsv @@ search_query(q) OR q <% p.name)
+    )
+    SELECT 'transaction'::TEXT, t.id FROM transactions t
+    WHERE t.user_id = p_user_id AND t.deleted_at IS NULL AND (t.search_tsv @@ search_query(q) OR q <% t.description)
+    UNION
+    SELECT 'transaction'::TEXT, t.id FROM transactions t JOIN matched_categories c ON c.id = t.category_id
+    WHERE t.deleted_at IS NULL
+    UNION
+    SELECT 'transaction'::TEXT, t.id FROM transactions t JOIN matched_payees p ON p.id = t.payee_id
+    WHERE t.deleted_at IS NULL
+    UNION
+    SELECT 'transaction'::TEXT, t.id FROM transaction_splits s JOIN transactions t ON t.id = s.transaction_id
+    WHERE t.user_id = p_user_id AND t.deleted_at IS NULL
+      AND (s.search_tsv @@ search_query(q) OR q <% s.note OR s.category_id IN (SELECT id FROM matched_categories))
+    UNION
+    SELECT 'planned_expense'::TEXT, e.id FROM planned_expenses e
+    WHERE e.user_id = p_user_id AND e.deleted_at IS NULL AND (e.search_tsv @@ search_query(q) OR q <% e.description)
+    UNION
+    SELECT 'planned_expense'::TEXT, e.id FROM planned_expenses e JOIN matched_categories c ON c.id = e.category_id
+    WHERE e.deleted_at IS NULL
+$$ LANGUAGE sql STABLE;
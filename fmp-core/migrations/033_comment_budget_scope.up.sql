@@ -0,0 +1,5 @@
+-- Spell out in the schema what user_id means since budgets, see 022
+COMMENT ON TABLE users IS 'People with a Telegram ID, and the owner rows of shared budgets with a NULL telegram_id';
+COMMENT ON COLUMN users.telegram_id IS 'NULL for the owner row of a shared budget, which is never a person';
+COMMENT ON TABLE budgets IS 'Owner of the data: the user_id column of the data tables holds budgets.id';
+COMMENT ON COLUMN budget_members.user_id IS 'The person, a users row with a telegram_id';
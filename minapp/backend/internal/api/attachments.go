@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"time"
+
+	"minapp-backend/internal/config"
+	"minapp-backend/internal/telegram"
+)
+
+// attachReceipt downloads a photo or document sent to the bot and attaches it
+// to the user's most recently recorded transaction, replying in the chat
+func attachReceipt(bot *telegram.Bot, cfg *config.Config, chatID, telegramUserID int64, fileID, fileName string) error {
+	transaction, err := latestTransaction(cfg, telegramUserID)
+	if err != nil {
+		bot.SendMessage(chatID, "❌ Не удалось найти операцию для чека. Попробуйте позже.")
+		return err
+	}
+	if transaction == nil {
+		return bot.SendMessage(chatID, "🤔 Сначала добавьте операцию, а потом пришлите чек к ней.")
+	}
+
+	data, filePath, err := bot.DownloadFile(fileID)
+	if err != nil {
+		bot.SendMessage(chatID, "❌ Не удалось получить файл от Telegram. Попробуйте ещё раз.")
+		return err
+	}
+	if fileName == "" {
+		fileName = path.Base(filePath)
+	}
+
+	url := fmt.Sprintf("%s/api/v1/transactions/%s/attachments", cfg.FMPCoreAPIURL, transaction.ID)
+	if _, err := uploadFileToAPI(url, fileName, data, personalCaller(telegramUserID)); err != nil {
+		bot.SendMessage(chatID, "❌ Не удалось прикрепить файл. Поддерживаются фото и PDF.")
+		return err
+	}
+
+	message := fmt.Sprintf("📎 Чек прикреплён к операции от %s", transaction.Date.Format("02.01.2006"))
+	if transaction.Description != "" {
+		message = fmt.Sprintf("📎 Чек прикреплён к операции «%s» от %s", transaction.Description, transaction.Date.Format("02.01.2006"))
+	}
+	return bot.SendMessage(chatID, message)
+}
+
+type recentTransaction struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	Date        time.Time `json:"date"`
+}
+
+// latestTransaction returns the transaction recorded last, leaving out
+// transfers between accounts, or nil when the user has none
+func latestTransaction(cfg *config.Config, telegramUserID int64) (*recentTransaction, error) {
+	url := cfg.FMPCoreAPIURL + "/api/v1/transactions?exclude_transfers=true&sort=created_at&limit=1"
+	result, err := makeAPIRequest(url, "GET", nil, personalCaller(telegramUserID))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var transactions []recentTransaction
+	if err := json.Unmarshal(data, &transactions); err != nil {
+		return nil, err
+	}
+
+	if len(transactions) == 0 {
+		return nil, nil
+	}
+	return &transactions[0], nil
+}
+
+// uploadFileToAPI posts a file to fmp-core as the "file" field of a
+// multipart form, authenticated like makeAPIRequest
+func uploadFileToAPI(url, fileName string, data []byte, caller apiCaller) (interface{}, error) {
+	client := &http.Client{Timeout: 60 * time.Second}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	if err := authorizeAPIRequest(req, caller); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result interface{}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net/http"
+
+	"minapp-backend/internal/config"
+	"minapp-backend/internal/telegram"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inviteStartPrefix starts the /start parameter of budget invite links
+const inviteStartPrefix = "join_"
+
+type BudgetRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+type BudgetInviteRequest struct {
+	Role    string `json:"role,omitempty" binding:"omitempty,oneof=editor viewer"`
+	TTLDays int    `json:"ttl_days,omitempty" binding:"omitempty,min=1,max=30"`
+}
+
+// Budgets handlers
+func getBudgets(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budgets, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/budgets", "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, budgets)
+	}
+}
+
+func createBudget(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req BudgetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		budget, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/budgets", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, budget)
+	}
+}
+
+func getBudgetMembers(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		members, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/budgets/"+id+"/members", "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, members)
+	}
+}
+
+func removeBudgetMember(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		userID := c.Param("userId")
+		if _, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/budgets/"+id+"/members/"+userID, "DELETE", nil, currentCaller(c)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// createBudgetInvite creates an invite and adds the t.me link that opens the
+// bot with the invite's /start parameter
+func createBudgetInvite(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		var req BudgetInviteRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		invite, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/budgets/"+id+"/invites", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if fields, ok := invite.(map[string]interface{}); ok && cfg.TelegramBotUsername != "" {
+			if start, ok := fields["start_parameter"].(string); ok {
+				fields["link"] = "https://t.me/" + cfg.TelegramBotUsername + "?start=" + start
+			}
+		}
+		c.JSON(http.StatusCreated, invite)
+	}
+}
+
+// acceptBudgetInvite joins the budget of the token sent as /start join_<token>
+// through an invite link
+func acceptBudgetInvite(bot *telegram.Bot, cfg *config.Config, chatID, telegramUserID int64, token string) error {
+	req := map[string]string{"token": token}
+	budget, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/budget-invites/accept", "POST", req, personalCaller(telegramUserID))
+	if err != nil {
+		bot.SendMessage(chatID, "❌ Приглашение недействительно или уже использовано.")
+		return err
+	}
+
+	message := "🤝 Вы присоединились к общему бюджету!\n\nВыберите его в мини-приложении, чтобы вести учёт вместе."
+	if fields, ok := budget.(map[string]interface{}); ok {
+		if name, ok := fields["name"].(string); ok {
+			message = "🤝 Вы присоединились к бюджету «" + name + "»!\n\nВыберите его в мини-приложении, чтобы вести учёт вместе."
+		}
+	}
+	return bot.SendMessage(chatID, message)
+}
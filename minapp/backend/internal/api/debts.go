@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"minapp-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CounterpartyRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+	Note string `json:"note,omitempty"`
+}
+
+type DebtRequest struct {
+	CounterpartyID uuid.UUID   `json:"counterparty_id" binding:"required"`
+	Direction      string      `json:"direction" binding:"required,oneof=lent borrowed"`
+	Name           string      `json:"name" binding:"required,max=255"`
+	Principal      json.Number `json:"principal" binding:"required"`
+	Currency       string      `json:"currency,omitempty" binding:"omitempty,iso4217"`
+	InterestRate   float64     `json:"interest_rate,omitempty" binding:"gte=0"`
+	Schedule       string      `json:"schedule,omitempty" binding:"omitempty,oneof=none annuity differentiated"`
+	TermMonths     *int        `json:"term_months,omitempty"`
+	StartDate      *time.Time  `json:"start_date,omitempty"`
+	AccountID      *uuid.UUID  `json:"account_id,omitempty"`
+	CategoryID     *uuid.UUID  `json:"category_id,omitempty"`
+}
+
+type DebtPaymentRequest struct {
+	Amount            json.Number `json:"amount" binding:"required"`
+	InterestAmount    json.Number `json:"interest_amount,omitempty"`
+	Date              *time.Time  `json:"date,omitempty"`
+	Note              string      `json:"note,omitempty"`
+	CreateTransaction bool        `json:"create_transaction"`
+	AccountID         *uuid.UUID  `json:"account_id,omitempty"`
+	CategoryID        *uuid.UUID  `json:"category_id,omitempty"`
+}
+
+// Debts handlers
+func getCounterparties(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		counterparties, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/counterparties", "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, counterparties)
+	}
+}
+
+func createCounterparty(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req CounterpartyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		counterparty, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/counterparties", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, counterparty)
+	}
+}
+
+func getDebts(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		url := cfg.FMPCoreAPIURL + "/api/v1/debts"
+		if c.Request.URL.RawQuery != "" {
+			url += "?" + c.Request.URL.RawQuery
+		}
+
+		debts, err := makeAPIRequest(url, "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, debts)
+	}
+}
+
+func createDebt(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req DebtRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		debt, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/debts", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, debt)
+	}
+}
+
+func getDebtBalances(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		url := cfg.FMPCoreAPIURL + "/api/v1/debts/balances"
+		if c.Request.URL.RawQuery != "" {
+			url += "?" + c.Request.URL.RawQuery
+		}
+
+		balances, err := makeAPIRequest(url, "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, balances)
+	}
+}
+
+func createDebtPayment(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		var req DebtPaymentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		payment, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/debts/"+id+"/payments", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, payment)
+	}
+}
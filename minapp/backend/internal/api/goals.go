@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"minapp-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type GoalRequest struct {
+	Name         string      `json:"name" binding:"required,max=255"`
+	TargetAmount json.Number `json:"target_amount" binding:"required"`
+	Currency     string      `json:"currency,omitempty" binding:"omitempty,iso4217"`
+	StartDate    *time.Time  `json:"start_date,omitempty"`
+	Deadline     *time.Time  `json:"deadline,omitempty"`
+	AccountID    *uuid.UUID  `json:"account_id,omitempty" binding:"excluded_with=CategoryID"`
+	CategoryID   *uuid.UUID  `json:"category_id,omitempty"`
+}
+
+type GoalContributionRequest struct {
+	Amount   json.Number `json:"amount" binding:"required"`
+	Currency string      `json:"currency,omitempty" binding:"omitempty,iso4217"`
+	Date     *time.Time  `json:"date,omitempty"`
+	Note     string      `json:"note,omitempty"`
+}
+
+// Goals handlers
+func getGoals(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		goals, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/goals", "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, goals)
+	}
+}
+
+func createGoal(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req GoalRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		goal, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/goals", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, goal)
+	}
+}
+
+func getGoalsProgress(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		url := cfg.FMPCoreAPIURL + "/api/v1/goals/progress"
+		if c.Request.URL.RawQuery != "" {
+			url += "?" + c.Request.URL.RawQuery
+		}
+
+		progress, err := makeAPIRequest(url, "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, progress)
+	}
+}
+
+func createGoalContribution(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		var req GoalContributionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		contribution, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/goals/"+id+"/contributions", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, contribution)
+	}
+}
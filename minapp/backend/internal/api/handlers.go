@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"minapp-backend/internal/auth"
 	"minapp-backend/internal/config"
+	"minapp-backend/internal/notifications"
 	"minapp-backend/internal/telegram"
 
 	"github.com/gin-gonic/gin"
@@ -17,25 +21,78 @@ import (
 )
 
 type TransactionRequest struct {
-	CategoryID  uuid.UUID `json:"category_id" binding:"required"`
-	Amount      float64   `json:"amount" binding:"required"`
-	Description string    `json:"description"`
-	Date        time.Time `json:"date"`
+	// CategoryID may be left out for the rules of fmp-core to fill in
+	CategoryID  *uuid.UUID                `json:"category_id,omitempty"`
+	AccountID   *uuid.UUID                `json:"account_id,omitempty"`
+	Type        string                    `json:"type,omitempty" binding:"omitempty,oneof=income expense"`
+	Amount      json.Number               `json:"amount" binding:"required"`
+	Currency    string                    `json:"currency,omitempty" binding:"omitempty,iso4217"`
+	Description string                    `json:"description"`
+	PayeeID     *uuid.UUID                `json:"payee_id,omitempty"`
+	Date        time.Time                 `json:"date"`
+	Tags        []string                  `json:"tags,omitempty"`
+	Splits      []TransactionSplitRequest `json:"splits,omitempty" binding:"omitempty,dive"`
+}
+
+type TransactionSplitRequest struct {
+	CategoryID uuid.UUID   `json:"category_id" binding:"required"`
+	Amount     json.Number `json:"amount" binding:"required"`
+	Note       string      `json:"note,omitempty"`
 }
 
 type CategoryRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty"`
+	Name        string     `json:"name" binding:"required"`
+	Description string     `json:"description"`
 }
 
 type CategoryLimitRequest struct {
-	CategoryID uuid.UUID `json:"category_id" binding:"required"`
-	Limit      float64   `json:"limit" binding:"required"`
-	Month      int       `json:"month" binding:"required"`
-	Year       int       `json:"year" binding:"required"`
+	CategoryID uuid.UUID   `json:"category_id" binding:"required"`
+	Limit      json.Number `json:"limit" binding:"required"`
+	Currency   string      `json:"currency,omitempty" binding:"omitempty,iso4217"`
+	Rollover   bool        `json:"rollover"`
+	Month      int         `json:"month" binding:"required"`
+	Year       int         `json:"year" binding:"required"`
+}
+
+type ExchangeRateRequest struct {
+	BaseCurrency  string      `json:"base_currency" binding:"required,iso4217"`
+	QuoteCurrency string      `json:"quote_currency" binding:"required,iso4217"`
+	Rate          json.Number `json:"rate" binding:"required"`
+	Date          time.Time   `json:"date" binding:"required"`
+}
+
+type TransferRequest struct {
+	FromAccountID uuid.UUID   `json:"from_account_id" binding:"required"`
+	ToAccountID   uuid.UUID   `json:"to_account_id" binding:"required,nefield=FromAccountID"`
+	Amount        json.Number `json:"amount" binding:"required"`
+	ToAmount      json.Number `json:"to_amount,omitempty"`
+	Description   string      `json:"description"`
+	Date          time.Time   `json:"date"`
+}
+
+// RecurringExpenseRequest takes the recurrence either as separate fields or as an RRULE
+type RecurringExpenseRequest struct {
+	CategoryID  uuid.UUID   `json:"category_id" binding:"required"`
+	AccountID   *uuid.UUID  `json:"account_id,omitempty"`
+	Amount      json.Number `json:"amount" binding:"required"`
+	Currency    string      `json:"currency,omitempty" binding:"omitempty,iso4217"`
+	Description string      `json:"description"`
+	Frequency   string      `json:"frequency,omitempty" binding:"omitempty,oneof=daily weekly monthly yearly"`
+	Interval    int         `json:"interval,omitempty"`
+	Count       *int        `json:"count,omitempty"`
+	Until       *time.Time  `json:"until,omitempty"`
+	RRule       string      `json:"rrule,omitempty"`
+	StartDate   time.Time   `json:"start_date" binding:"required"`
+	AutoPost    bool        `json:"auto_post"`
 }
 
+// sessions signs the tokens that carry the user identity to fmp-core
+var sessions *auth.SessionManager
+
 func SetupRoutes(router *gin.Engine, bot *telegram.Bot, cfg *config.Config) {
+	sessions = auth.NewSessionManager(cfg.JWTSecret, cfg.SessionTTL)
+
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
@@ -48,7 +105,7 @@ func SetupRoutes(router *gin.Engine, bot *telegram.Bot, cfg *config.Config) {
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", cfg.FrontendURL)
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, "+initDataHeader+", "+budgetHeader)
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -59,29 +116,90 @@ func SetupRoutes(router *gin.Engine, bot *telegram.Bot, cfg *config.Config) {
 	})
 
 	api := router.Group("/api")
+	{
+		// Bot and scheduler routes, identified by the Telegram chat
+		api.POST("/webhook", requireWebhookSecret(cfg.TelegramWebhookSecret), handleWebhook(bot, cfg))
+		api.POST("/notifications/daily-reminder", requireInternalSecret(cfg.InternalAPISecret), sendDailyReminder(bot, cfg))
+
+		// Exchanges verified Mini App init data for a session token
+		api.POST("/auth/session", createSession(bot, cfg))
+	}
+
+	webApp := api.Group("", requireSession(sessions))
 	{
 		// Telegram Mini App routes
-		api.POST("/webhook", handleWebhook(bot, cfg))
-		api.GET("/categories", getCategories(cfg))
-		api.POST("/categories", createCategory(cfg))
-		api.GET("/transactions", getTransactions(cfg))
-		api.POST("/transactions", createTransaction(cfg))
-		api.GET("/category-limits", getCategoryLimits(cfg))
-		api.POST("/category-limits", createCategoryLimit(cfg))
-		api.GET("/monthly-summary", getMonthlySummary(cfg))
-		api.POST("/notifications/daily-reminder", sendDailyReminder(bot, cfg))
+		webApp.GET("/categories", getCategories(cfg))
+		webApp.POST("/categories", createCategory(cfg))
+		webApp.GET("/accounts", getAccounts(cfg))
+		webApp.GET("/accounts/balances", getAccountBalances(cfg))
+		webApp.GET("/transactions", getTransactions(cfg))
+		webApp.POST("/transactions", createTransaction(cfg))
+		webApp.GET("/transfers", getTransfers(cfg))
+		webApp.POST("/transfers", createTransfer(cfg))
+		webApp.GET("/category-limits", getCategoryLimits(cfg))
+		webApp.POST("/category-limits", createCategoryLimit(cfg))
+		webApp.GET("/monthly-summary", getMonthlySummary(cfg))
+		webApp.GET("/exchange-rates", getExchangeRates(cfg))
+		webApp.POST("/exchange-rates", createExchangeRate(cfg))
 
 		// Planned Expenses
-		api.GET("/planned-expenses", getPlannedExpenses(cfg))
-		api.POST("/planned-expenses", createPlannedExpense(cfg))
-		api.PUT("/planned-expenses/:id", updatePlannedExpense(cfg))
-		api.DELETE("/planned-expenses/:id", deletePlannedExpense(cfg))
+		webApp.GET("/planned-expenses", getPlannedExpenses(cfg))
+		webApp.POST("/planned-expenses", createPlannedExpense(cfg))
+		webApp.PUT("/planned-expenses/:id", updatePlannedExpense(cfg))
+		webApp.DELETE("/planned-expenses/:id", deletePlannedExpense(cfg))
+		webApp.POST("/planned-expenses/:id/complete", completePlannedExpense(cfg))
+		webApp.POST("/planned-expenses/:id/uncomplete", uncompletePlannedExpense(cfg))
+
+		// Recurring Expenses
+		webApp.GET("/recurring-expenses", getRecurringExpenses(cfg))
+		webApp.POST("/recurring-expenses", createRecurringExpense(cfg))
 
 		// Planned Income
-		api.GET("/planned-income", getPlannedIncome(cfg))
-		api.POST("/planned-income", createPlannedIncome(cfg))
-		api.PUT("/planned-income/:id", updatePlannedIncome(cfg))
-		api.DELETE("/planned-income/:id", deletePlannedIncome(cfg))
+		webApp.GET("/planned-income", getPlannedIncome(cfg))
+		webApp.POST("/planned-income", createPlannedIncome(cfg))
+		webApp.POST("/planned-income/copy", copyPlannedIncome(bot, cfg))
+		webApp.PUT("/planned-income/:id", updatePlannedIncome(cfg))
+		webApp.DELETE("/planned-income/:id", deletePlannedIncome(cfg))
+
+		// Goals
+		webApp.GET("/goals", getGoals(cfg))
+		webApp.POST("/goals", createGoal(cfg))
+		webApp.GET("/goals/progress", getGoalsProgress(cfg))
+		webApp.POST("/goals/:id/contributions", createGoalContribution(cfg))
+
+		// Debts
+		webApp.GET("/counterparties", getCounterparties(cfg))
+		webApp.POST("/counterparties", createCounterparty(cfg))
+		webApp.GET("/debts", getDebts(cfg))
+		webApp.POST("/debts", createDebt(cfg))
+		webApp.GET("/debts/balances", getDebtBalances(cfg))
+		webApp.POST("/debts/:id/payments", createDebtPayment(cfg))
+
+		// Rules
+		webApp.GET("/rules", getRules(cfg))
+		webApp.POST("/rules", createRule(cfg))
+		webApp.POST("/rules/dry-run", runRules(cfg, "dry-run"))
+		webApp.POST("/rules/apply", runRules(cfg, "apply"))
+
+		// Search
+		webApp.GET("/search", search(cfg))
+
+		// Trash
+		webApp.GET("/trash", getTrash(cfg))
+		webApp.POST("/trash/:kind/:id/restore", restoreTrashItem(cfg))
+		webApp.DELETE("/trash/:kind/:id", purgeTrashItem(cfg))
+
+		// Payees
+		webApp.GET("/payees", getPayees(cfg))
+		webApp.POST("/payees", createPayee(cfg))
+		webApp.GET("/analytics/top-payees", getTopPayees(cfg))
+
+		// Budgets
+		webApp.GET("/budgets", getBudgets(cfg))
+		webApp.POST("/budgets", createBudget(cfg))
+		webApp.GET("/budgets/:id/members", getBudgetMembers(cfg))
+		webApp.DELETE("/budgets/:id/members/:userId", removeBudgetMember(cfg))
+		webApp.POST("/budgets/:id/invites", createBudgetInvite(cfg))
 	}
 }
 
@@ -92,7 +210,12 @@ func handleWebhook(bot *telegram.Bot, cfg *config.Config) gin.HandlerFunc {
 				Chat struct {
 					ID int64 `json:"id"`
 				} `json:"chat"`
-				Text string `json:"text"`
+				From struct {
+					ID int64 `json:"id"`
+				} `json:"from"`
+				Text     string               `json:"text"`
+				Photo    []telegram.PhotoSize `json:"photo"`
+				Document *telegram.Document   `json:"document"`
 			} `json:"message"`
 		}
 
@@ -101,6 +224,34 @@ func handleWebhook(bot *telegram.Bot, cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		// Photos and documents are receipts for the last transaction, the
+		// largest size of a photo comes last
+		if photos := update.Message.Photo; len(photos) > 0 || update.Message.Document != nil {
+			fileID, fileName := "", ""
+			if len(photos) > 0 {
+				fileID = photos[len(photos)-1].FileID
+			} else {
+				fileID, fileName = update.Message.Document.FileID, update.Message.Document.FileName
+			}
+
+			if err := attachReceipt(bot, cfg, update.Message.Chat.ID, update.Message.From.ID, fileID, fileName); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			return
+		}
+
+		// Invite links open the bot with /start join_<token>
+		if token, ok := strings.CutPrefix(update.Message.Text, "/start "+inviteStartPrefix); ok {
+			if err := acceptBudgetInvite(bot, cfg, update.Message.Chat.ID, update.Message.From.ID, token); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			return
+		}
+
 		// Handle different commands
 		switch update.Message.Text {
 		case "/start":
@@ -118,7 +269,11 @@ func handleWebhook(bot *telegram.Bot, cfg *config.Config) gin.HandlerFunc {
 		case "/stats":
 			// Get monthly summary for current month
 			now := time.Now()
-			summary, err := getMonthlySummaryFromAPI(cfg, int(now.Month()), now.Year())
+			summary, err := getMonthlySummaryFromAPI(cfg, personalCaller(update.Message.From.ID), int(now.Month()), now.Year(), "")
+			var message string
+			if err == nil {
+				message, err = formatMonthlySummary(summary)
+			}
 			if err != nil {
 				message := "❌ Не удалось получить статистику. Попробуйте позже."
 				bot.SendMessage(update.Message.Chat.ID, message)
@@ -126,7 +281,6 @@ func handleWebhook(bot *telegram.Bot, cfg *config.Config) gin.HandlerFunc {
 				return
 			}
 
-			message := formatMonthlySummary(summary)
 			if err := bot.SendMessage(update.Message.Chat.ID, message); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
@@ -140,9 +294,27 @@ func handleWebhook(bot *telegram.Bot, cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
+func createSession(bot *telegram.Bot, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		webAppData, err := bot.ValidateWebAppData(c.GetHeader(initDataHeader), cfg.InitDataMaxAge)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		session, err := sessions.Issue(webAppData.User.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, session)
+	}
+}
+
 func getCategories(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		categories, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/categories", "GET", nil)
+		// The Mini App works with a flat list, subcategories carry parent_id
+		categories, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/categories?flat=true", "GET", nil, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -159,7 +331,7 @@ func createCategory(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		category, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/categories", "POST", req)
+		category, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/categories", "POST", req, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -168,30 +340,76 @@ func createCategory(cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
-func getTransactions(cfg *config.Config) gin.HandlerFunc {
+func getAccounts(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		url := cfg.FMPCoreAPIURL + "/api/v1/transactions"
+		accounts, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/accounts", "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, accounts)
+	}
+}
 
-		// Add query parameters
-		if categoryID := c.Query("category_id"); categoryID != "" {
-			url += "?category_id=" + categoryID
+func getAccountBalances(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		url := cfg.FMPCoreAPIURL + "/api/v1/accounts/balances"
+		if asOf := c.Query("as_of"); asOf != "" {
+			url += "?as_of=" + asOf
 		}
-		if startDate := c.Query("start_date"); startDate != "" {
-			if categoryID := c.Query("category_id"); categoryID != "" {
-				url += "&start_date=" + startDate
-			} else {
-				url += "?start_date=" + startDate
-			}
+
+		balances, err := makeAPIRequest(url, "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
-		if endDate := c.Query("end_date"); endDate != "" {
-			separator := "?"
-			if categoryID := c.Query("category_id"); categoryID != "" || c.Query("start_date") != "" {
-				separator = "&"
-			}
-			url += separator + "end_date=" + endDate
+		c.JSON(http.StatusOK, balances)
+	}
+}
+
+func getExchangeRates(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		url := cfg.FMPCoreAPIURL + "/api/v1/exchange-rates"
+		if c.Request.URL.RawQuery != "" {
+			url += "?" + c.Request.URL.RawQuery
+		}
+
+		rates, err := makeAPIRequest(url, "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
+		c.JSON(http.StatusOK, rates)
+	}
+}
 
-		transactions, err := makeAPIRequest(url, "GET", nil)
+func createExchangeRate(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ExchangeRateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		rate, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/exchange-rates", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, rate)
+	}
+}
+
+func getTransactions(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		url := cfg.FMPCoreAPIURL + "/api/v1/transactions"
+
+		// The filters are passed on as is, fmp-core validates them
+		if c.Request.URL.RawQuery != "" {
+			url += "?" + c.Request.URL.RawQuery
+		}
+
+		transactions, err := makeAPIRequest(url, "GET", nil, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -213,7 +431,7 @@ func createTransaction(cfg *config.Config) gin.HandlerFunc {
 			req.Date = time.Now()
 		}
 
-		transaction, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/transactions", "POST", req)
+		transaction, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/transactions", "POST", req, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -222,6 +440,43 @@ func createTransaction(cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
+func getTransfers(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		url := cfg.FMPCoreAPIURL + "/api/v1/transfers"
+		if c.Request.URL.RawQuery != "" {
+			url += "?" + c.Request.URL.RawQuery
+		}
+
+		transfers, err := makeAPIRequest(url, "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, transfers)
+	}
+}
+
+func createTransfer(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req TransferRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Date.IsZero() {
+			req.Date = time.Now()
+		}
+
+		transfer, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/transfers", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, transfer)
+	}
+}
+
 func getCategoryLimits(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		url := cfg.FMPCoreAPIURL + "/api/v1/category-limits"
@@ -245,7 +500,7 @@ func getCategoryLimits(cfg *config.Config) gin.HandlerFunc {
 			url += separator + "year=" + year
 		}
 
-		limits, err := makeAPIRequest(url, "GET", nil)
+		limits, err := makeAPIRequest(url, "GET", nil, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -262,7 +517,7 @@ func createCategoryLimit(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		limit, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/category-limits", "POST", req)
+		limit, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/category-limits", "POST", req, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -285,7 +540,7 @@ func getMonthlySummary(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		summary, err := getMonthlySummaryFromAPI(cfg, month, year)
+		summary, err := getMonthlySummaryFromAPI(cfg, currentCaller(c), month, year, c.Query("currency"))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -309,7 +564,8 @@ func sendDailyReminder(bot *telegram.Bot, cfg *config.Config) gin.HandlerFunc {
 		today := time.Now().Format("2006-01-02")
 		url := cfg.FMPCoreAPIURL + "/api/v1/transactions?start_date=" + today + "&end_date=" + today
 
-		transactions, err := makeAPIRequest(url, "GET", nil)
+		// In a private chat the chat ID is the user's Telegram ID
+		transactions, err := makeAPIRequest(url, "GET", nil, personalCaller(req.ChatID))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -356,7 +612,8 @@ func sendDailyReminder(bot *telegram.Bot, cfg *config.Config) gin.HandlerFunc {
 }
 
 // Helper functions
-func makeAPIRequest(url, method string, body interface{}) (interface{}, error) {
+// makeAPIRequest calls fmp-core on behalf of the given Telegram user
+func makeAPIRequest(url, method string, body interface{}, caller apiCaller) (interface{}, error) {
 	client := &http.Client{Timeout: 30 * time.Second}
 
 	var req *http.Request
@@ -378,6 +635,9 @@ func makeAPIRequest(url, method string, body interface{}) (interface{}, error) {
 			return nil, err
 		}
 	}
+	if err := authorizeAPIRequest(req, caller); err != nil {
+		return nil, err
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -402,21 +662,83 @@ func makeAPIRequest(url, method string, body interface{}) (interface{}, error) {
 	return result, nil
 }
 
-func getMonthlySummaryFromAPI(cfg *config.Config, month, year int) (interface{}, error) {
+// authorizeAPIRequest signs the request to fmp-core as the caller
+func authorizeAPIRequest(req *http.Request, caller apiCaller) error {
+	session, err := sessions.Issue(caller.TelegramUserID)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+	if caller.BudgetID != "" {
+		req.Header.Set(budgetHeader, caller.BudgetID)
+	}
+	return nil
+}
+
+// getMonthlySummaryFromAPI asks for the summary in the given reporting
+// currency, fmp-core picks its default when it is empty
+func getMonthlySummaryFromAPI(cfg *config.Config, caller apiCaller, month, year int, currency string) (interface{}, error) {
 	url := cfg.FMPCoreAPIURL + "/api/v1/analytics/monthly-summary?month=" + strconv.Itoa(month) + "&year=" + strconv.Itoa(year)
-	return makeAPIRequest(url, "GET", nil)
+	if currency != "" {
+		url += "&currency=" + currency
+	}
+	return makeAPIRequest(url, "GET", nil, caller)
 }
 
-func formatMonthlySummary(summary interface{}) string {
-	// This is a simplified implementation
-	// In a real application, you would format the actual summary data
-	return "📊 Статистика за текущий месяц:\n\n" +
-		"• Общие расходы: 0 ₽\n" +
-		"• Категории:\n" +
-		"  - Еда: 0 ₽\n" +
-		"  - Транспорт: 0 ₽\n" +
-		"  - Развлечения: 0 ₽\n\n" +
-		"Используйте мини-приложение для детальной аналитики! 📱"
+// formatMonthlySummary writes the /stats message from the summary fmp-core
+// returned, amounts in its reporting currency. Top-level categories include
+// their subcategories, so only they are listed.
+func formatMonthlySummary(summary interface{}) (string, error) {
+	var parsed struct {
+		Currency   string `json:"currency"`
+		Categories []struct {
+			ParentID     *string      `json:"parent_id"`
+			CategoryName string       `json:"category_name"`
+			Amount       json.Number  `json:"amount"`
+			Limit        *json.Number `json:"limit"`
+		} `json:"categories"`
+		Expenses json.Number `json:"expenses"`
+		Income   json.Number `json:"income"`
+		Net      json.Number `json:"net"`
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return "", err
+	}
+
+	symbol := notifications.CurrencySymbol(parsed.Currency)
+	var b strings.Builder
+	b.WriteString("📊 Статистика за текущий месяц:\n\n")
+	fmt.Fprintf(&b, "• Расходы: %s %s\n", parsed.Expenses, symbol)
+	fmt.Fprintf(&b, "• Доходы: %s %s\n", parsed.Income, symbol)
+	fmt.Fprintf(&b, "• Итого: %s %s\n", parsed.Net, symbol)
+
+	b.WriteString("• Категории:\n")
+	listed := 0
+	for _, category := range parsed.Categories {
+		if category.ParentID != nil {
+			continue
+		}
+		amount, err := category.Amount.Float64()
+		if err != nil || amount == 0 {
+			continue
+		}
+		if category.Limit != nil {
+			fmt.Fprintf(&b, "  - %s: %s из %s %s\n", category.CategoryName, category.Amount, *category.Limit, symbol)
+		} else {
+			fmt.Fprintf(&b, "  - %s: %s %s\n", category.CategoryName, category.Amount, symbol)
+		}
+		listed++
+	}
+	if listed == 0 {
+		b.WriteString("  - расходов пока нет\n")
+	}
+
+	b.WriteString("\nИспользуйте мини-приложение для детальной аналитики! 📱")
+	return b.String(), nil
 }
 
 // Planned Expenses handlers
@@ -424,33 +746,12 @@ func getPlannedExpenses(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		url := cfg.FMPCoreAPIURL + "/api/v1/planned-expenses"
 
-		// Add query parameters
-		if categoryID := c.Query("category_id"); categoryID != "" {
-			url += "?category_id=" + categoryID
-		}
-		if startDate := c.Query("start_date"); startDate != "" {
-			separator := "?"
-			if categoryID := c.Query("category_id"); categoryID != "" {
-				separator = "&"
-			}
-			url += separator + "start_date=" + startDate
-		}
-		if endDate := c.Query("end_date"); endDate != "" {
-			separator := "?"
-			if categoryID := c.Query("category_id"); categoryID != "" || c.Query("start_date") != "" {
-				separator = "&"
-			}
-			url += separator + "end_date=" + endDate
-		}
-		if isCompleted := c.Query("is_completed"); isCompleted != "" {
-			separator := "?"
-			if categoryID := c.Query("category_id"); categoryID != "" || c.Query("start_date") != "" || c.Query("end_date") != "" {
-				separator = "&"
-			}
-			url += separator + "is_completed=" + isCompleted
+		// The filters are passed on as is, fmp-core validates them
+		if c.Request.URL.RawQuery != "" {
+			url += "?" + c.Request.URL.RawQuery
 		}
 
-		expenses, err := makeAPIRequest(url, "GET", nil)
+		expenses, err := makeAPIRequest(url, "GET", nil, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -462,17 +763,18 @@ func getPlannedExpenses(cfg *config.Config) gin.HandlerFunc {
 func createPlannedExpense(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
-			CategoryID  uuid.UUID `json:"category_id" binding:"required"`
-			Amount      float64   `json:"amount" binding:"required"`
-			Description string    `json:"description"`
-			PlannedDate time.Time `json:"planned_date" binding:"required"`
+			CategoryID  uuid.UUID   `json:"category_id" binding:"required"`
+			Amount      json.Number `json:"amount" binding:"required"`
+			Currency    string      `json:"currency,omitempty" binding:"omitempty,iso4217"`
+			Description string      `json:"description"`
+			PlannedDate time.Time   `json:"planned_date" binding:"required"`
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		expense, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-expenses", "POST", req)
+		expense, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-expenses", "POST", req, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -485,17 +787,18 @@ func updatePlannedExpense(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 		var req struct {
-			CategoryID  uuid.UUID `json:"category_id" binding:"required"`
-			Amount      float64   `json:"amount" binding:"required"`
-			Description string    `json:"description"`
-			PlannedDate time.Time `json:"planned_date" binding:"required"`
+			CategoryID  uuid.UUID   `json:"category_id" binding:"required"`
+			Amount      json.Number `json:"amount" binding:"required"`
+			Currency    string      `json:"currency,omitempty" binding:"omitempty,iso4217"`
+			Description string      `json:"description"`
+			PlannedDate time.Time   `json:"planned_date" binding:"required"`
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		expense, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-expenses/"+id, "PUT", req)
+		expense, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-expenses/"+id, "PUT", req, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -508,7 +811,7 @@ func deletePlannedExpense(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 
-		_, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-expenses/"+id, "DELETE", nil)
+		_, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-expenses/"+id, "DELETE", nil, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -517,6 +820,84 @@ func deletePlannedExpense(cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
+func completePlannedExpense(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		var req struct {
+			TransactionID *uuid.UUID  `json:"transaction_id,omitempty"`
+			AccountID     *uuid.UUID  `json:"account_id,omitempty"`
+			Amount        json.Number `json:"amount,omitempty"`
+			Date          *time.Time  `json:"date,omitempty"`
+		}
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		expense, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-expenses/"+id+"/complete", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, expense)
+	}
+}
+
+func uncompletePlannedExpense(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+
+		expense, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-expenses/"+id+"/uncomplete", "POST", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, expense)
+	}
+}
+
+// Recurring Expenses handlers
+func search(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		results, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/search?"+c.Request.URL.RawQuery, "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, results)
+	}
+}
+
+func getRecurringExpenses(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expenses, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/recurring-expenses", "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, expenses)
+	}
+}
+
+func createRecurringExpense(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RecurringExpenseRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		expense, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/recurring-expenses", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, expense)
+	}
+}
+
 // Planned Income handlers
 func getPlannedIncome(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -534,7 +915,7 @@ func getPlannedIncome(cfg *config.Config) gin.HandlerFunc {
 			url += separator + "year=" + year
 		}
 
-		incomes, err := makeAPIRequest(url, "GET", nil)
+		incomes, err := makeAPIRequest(url, "GET", nil, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -546,17 +927,20 @@ func getPlannedIncome(cfg *config.Config) gin.HandlerFunc {
 func createPlannedIncome(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
-			Amount      float64 `json:"amount" binding:"required"`
-			Description string  `json:"description"`
-			Month       int     `json:"month" binding:"required"`
-			Year        int     `json:"year" binding:"required"`
+			Name        string      `json:"name,omitempty" binding:"max=255"`
+			CategoryID  *uuid.UUID  `json:"category_id,omitempty"`
+			Amount      json.Number `json:"amount" binding:"required"`
+			Currency    string      `json:"currency,omitempty" binding:"omitempty,iso4217"`
+			Description string      `json:"description"`
+			Month       int         `json:"month" binding:"required"`
+			Year        int         `json:"year" binding:"required"`
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		income, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-income", "POST", req)
+		income, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-income", "POST", req, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -565,21 +949,71 @@ func createPlannedIncome(cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
+// copyPlannedIncome copies planned income into another month and tells the
+// user in the bot chat how much was copied, once per currency
+func copyPlannedIncome(bot *telegram.Bot, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			FromMonth       int  `json:"from_month,omitempty" binding:"omitempty,min=1,max=12"`
+			FromYear        int  `json:"from_year,omitempty"`
+			ToMonth         int  `json:"to_month" binding:"required,min=1,max=12"`
+			ToYear          int  `json:"to_year" binding:"required"`
+			IncludeExpenses bool `json:"include_expenses"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		caller := currentCaller(c)
+		result, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-income/copy", "POST", req, caller)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var copied struct {
+			ToMonth      int                    `json:"to_month"`
+			ToYear       int                    `json:"to_year"`
+			IncomeTotals map[string]json.Number `json:"income_totals"`
+		}
+		if data, err := json.Marshal(result); err == nil && json.Unmarshal(data, &copied) == nil {
+			// In a private chat with the bot the chat ID is the user ID
+			notifier := notifications.NewNotificationService(bot, cfg)
+			for currency, total := range copied.IncomeTotals {
+				amount, err := total.Float64()
+				if err != nil {
+					log.Printf("Invalid copied income total %q: %v", total, err)
+					continue
+				}
+				if err := notifier.SendIncomeCopyNotification(caller.TelegramUserID, copied.ToMonth, copied.ToYear, amount, currency); err != nil {
+					log.Printf("Failed to send income copy notification: %v", err)
+				}
+			}
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
 func updatePlannedIncome(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 		var req struct {
-			Amount      float64 `json:"amount" binding:"required"`
-			Description string  `json:"description"`
-			Month       int     `json:"month" binding:"required"`
-			Year        int     `json:"year" binding:"required"`
+			Name        string      `json:"name,omitempty" binding:"max=255"`
+			CategoryID  *uuid.UUID  `json:"category_id,omitempty"`
+			Amount      json.Number `json:"amount" binding:"required"`
+			Currency    string      `json:"currency,omitempty" binding:"omitempty,iso4217"`
+			Description string      `json:"description"`
+			Month       int         `json:"month" binding:"required"`
+			Year        int         `json:"year" binding:"required"`
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		income, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-income/"+id, "PUT", req)
+		income, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-income/"+id, "PUT", req, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -592,7 +1026,7 @@ func deletePlannedIncome(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id := c.Param("id")
 
-		_, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-income/"+id, "DELETE", nil)
+		_, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/planned-income/"+id, "DELETE", nil, currentCaller(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFormatMonthlySummary(t *testing.T) {
+	var summary interface{}
+	err := json.Unmarshal([]byte(`{
+		"month": 3, "year": 2026, "currency": "USD",
+		"categories": [
+			{"category_id": "1", "category_name": "Food", "amount": "120.50", "limit": "200.00"},
+			{"category_id": "2", "parent_id": "1", "category_name": "Cafes", "amount": "20.50"},
+			{"category_id": "3", "category_name": "Rent", "amount": "900.00"},
+			{"category_id": "4", "category_name": "Travel", "amount": "0.00"}
+		],
+		"total": "1020.50", "income": "3000.00", "expenses": "1020.50", "net": "1979.50"
+	}`), &summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := formatMonthlySummary(summary)
+	if err != nil {
+		t.Fatalf("formatMonthlySummary() error = %v", err)
+	}
+	for _, want := range []string{"Расходы: 1020.50 $", "Доходы: 3000.00 $", "Итого: 1979.50 $", "Food: 120.50 из 200.00 $", "Rent: 900.00 $"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatMonthlySummary() = %q, want it to contain %q", got, want)
+		}
+	}
+	for _, unwanted := range []string{"Cafes", "Travel", "₽"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("formatMonthlySummary() = %q, want no %q", got, unwanted)
+		}
+	}
+}
+
+func TestFormatMonthlySummaryWithoutSpending(t *testing.T) {
+	summary := map[string]interface{}{"currency": "RUB", "categories": []interface{}{}, "expenses": "0.00", "income": "0.00", "net": "0.00"}
+
+	got, err := formatMonthlySummary(summary)
+	if err != nil {
+		t.Fatalf("formatMonthlySummary() error = %v", err)
+	}
+	if !strings.Contains(got, "Расходы: 0.00 ₽") || !strings.Contains(got, "расходов пока нет") {
+		t.Errorf("formatMonthlySummary() = %q", got)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"minapp-backend/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// initDataHeader carries Telegram.WebApp.initData from the Mini App
+	initDataHeader = "X-Telegram-Init-Data"
+	// webhookSecretHeader carries the secret_token given to setWebhook
+	webhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+	// internalSecretHeader carries the shared secret of calls from inside the
+	// deployment, such as the reminder job
+	internalSecretHeader = "X-Internal-Secret"
+	// budgetHeader selects the budget the Mini App works with, it is passed
+	// on to fmp-core as is
+	budgetHeader      = "X-Budget-ID"
+	telegramUserIDKey = "telegramUserID"
+	budgetIDKey       = "budgetID"
+)
+
+// requireSession identifies the Mini App user from the bearer session token
+func requireSession(sessions *auth.SessionManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing session token"})
+			return
+		}
+
+		telegramUserID, err := sessions.Parse(token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(telegramUserIDKey, telegramUserID)
+		c.Set(budgetIDKey, c.GetHeader(budgetHeader))
+		c.Next()
+	}
+}
+
+// requireWebhookSecret makes sure webhook updates come from Telegram. The
+// secret is required at startup, an empty one lets nothing through.
+func requireWebhookSecret(secret string) gin.HandlerFunc {
+	return requireSecret(webhookSecretHeader, secret, "Invalid webhook secret")
+}
+
+// requireInternalSecret makes sure the call comes from inside the deployment.
+// The routes are closed when no secret is configured.
+func requireInternalSecret(secret string) gin.HandlerFunc {
+	return requireSecret(internalSecretHeader, secret, "Invalid internal secret")
+}
+
+func requireSecret(header, secret, message string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if secret == "" || subtle.ConstantTimeCompare([]byte(c.GetHeader(header)), []byte(secret)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+			return
+		}
+		c.Next()
+	}
+}
+
+func currentTelegramUserID(c *gin.Context) int64 {
+	return c.GetInt64(telegramUserIDKey)
+}
+
+// apiCaller is who fmp-core is called for: the Telegram user and the budget
+// they work with, fmp-core uses the personal budget when BudgetID is empty
+type apiCaller struct {
+	TelegramUserID int64
+	BudgetID       string
+}
+
+// personalCaller calls fmp-core for the personal budget of the user, as the
+// bot and the scheduler do
+func personalCaller(telegramUserID int64) apiCaller {
+	return apiCaller{TelegramUserID: telegramUserID}
+}
+
+// currentCaller calls fmp-core for the Mini App user and the budget selected
+// in the Mini App
+func currentCaller(c *gin.Context) apiCaller {
+	return apiCaller{TelegramUserID: currentTelegramUserID(c), BudgetID: c.GetString(budgetIDKey)}
+}
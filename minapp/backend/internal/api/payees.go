@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+
+	"minapp-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayeeRequest struct {
+	Name    string   `json:"name" binding:"required,max=255"`
+	Aliases []string `json:"aliases,omitempty" binding:"omitempty,dive,required,max=255"`
+}
+
+// Payees handlers
+func getPayees(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payees, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/payees", "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, payees)
+	}
+}
+
+func createPayee(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req PayeeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		payee, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/payees", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, payee)
+	}
+}
+
+func getTopPayees(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		url := cfg.FMPCoreAPIURL + "/api/v1/analytics/top-payees"
+		if c.Request.URL.RawQuery != "" {
+			url += "?" + c.Request.URL.RawQuery
+		}
+
+		payees, err := makeAPIRequest(url, "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, payees)
+	}
+}
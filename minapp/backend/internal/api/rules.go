@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"minapp-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RuleRequest struct {
+	Name                string       `json:"name" binding:"required,max=255"`
+	Priority            int          `json:"priority"`
+	IsActive            *bool        `json:"is_active,omitempty"`
+	DescriptionContains string       `json:"description_contains,omitempty" binding:"max=255"`
+	DescriptionRegex    string       `json:"description_regex,omitempty" binding:"max=255"`
+	MinAmount           *json.Number `json:"min_amount,omitempty"`
+	MaxAmount           *json.Number `json:"max_amount,omitempty"`
+	PayeeID             *uuid.UUID   `json:"payee_id,omitempty"`
+	AccountID           *uuid.UUID   `json:"account_id,omitempty"`
+	CategoryID          *uuid.UUID   `json:"category_id,omitempty" binding:"required_without=Tags"`
+	Tags                []string     `json:"tags,omitempty"`
+}
+
+type ApplyRulesRequest struct {
+	RuleIDs   []uuid.UUID `json:"rule_ids,omitempty"`
+	StartDate *time.Time  `json:"start_date,omitempty"`
+	EndDate   *time.Time  `json:"end_date,omitempty"`
+}
+
+// Rules handlers
+func getRules(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/rules", "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, rules)
+	}
+}
+
+func createRule(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		rule, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/rules", "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, rule)
+	}
+}
+
+// runRules runs the rules over past transactions, action is dry-run or apply
+func runRules(cfg *config.Config, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ApplyRulesRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		result, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/rules/"+action, "POST", req, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+
+	"minapp-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Trash handlers
+func getTrash(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		items, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/trash?"+c.Request.URL.RawQuery, "GET", nil, currentCaller(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, items)
+	}
+}
+
+func restoreTrashItem(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind := c.Param("kind")
+		id := c.Param("id")
+		if _, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/trash/"+kind+"/"+id+"/restore", "POST", nil, currentCaller(c)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func purgeTrashItem(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind := c.Param("kind")
+		id := c.Param("id")
+		if _, err := makeAPIRequest(cfg.FMPCoreAPIURL+"/api/v1/trash/"+kind+"/"+id, "DELETE", nil, currentCaller(c)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SessionManager issues and checks the short-lived tokens that identify a
+// Telegram user to the Mini App backend and to fmp-core. Both services must
+// share the same secret.
+type SessionManager struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+type Session struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func NewSessionManager(secret string, ttl time.Duration) *SessionManager {
+	return &SessionManager{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+// Issue signs a session token for the given Telegram user
+func (sm *SessionManager) Issue(telegramUserID int64) (*Session, error) {
+	now := time.Now()
+	expiresAt := now.Add(sm.ttl)
+
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.FormatInt(telegramUserID, 10),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(sm.secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign session token: %w", err)
+	}
+
+	return &Session{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// Parse verifies a session token and returns the Telegram user it was issued for
+func (sm *SessionManager) Parse(token string) (int64, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return sm.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return 0, fmt.Errorf("invalid session token: %w", err)
+	}
+
+	telegramUserID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid session token subject: %w", err)
+	}
+
+	return telegramUserID, nil
+}
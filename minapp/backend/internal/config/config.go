@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +13,18 @@ type Config struct {
 	TelegramBotToken string
 	FMPCoreAPIURL    string
 	FrontendURL      string
+	// TelegramWebhookSecret must match the secret_token given to setWebhook
+	TelegramWebhookSecret string
+	// InternalAPISecret guards the routes called from inside the deployment,
+	// they are closed when it is empty
+	InternalAPISecret string
+	// TelegramBotUsername builds t.me deep links, such as budget invites
+	TelegramBotUsername string
+	// InitDataMaxAge is how long Mini App init data is accepted after auth_date
+	InitDataMaxAge time.Duration
+	// JWTSecret signs session tokens, fmp-core must use the same value
+	JWTSecret  string
+	SessionTTL time.Duration
 }
 
 func Load() *Config {
@@ -19,11 +32,17 @@ func Load() *Config {
 	godotenv.Load()
 
 	return &Config{
-		Environment:      getEnv("ENVIRONMENT", "development"),
-		Port:             getEnv("PORT", "8080"),
-		TelegramBotToken: getEnv("TELEGRAM_BOT_TOKEN", ""),
-		FMPCoreAPIURL:    getEnv("FMP_CORE_API_URL", "http://localhost:8080/api/v1"),
-		FrontendURL:      getEnv("FRONTEND_URL", "http://localhost:3000"),
+		Environment:           getEnv("ENVIRONMENT", "development"),
+		Port:                  getEnv("PORT", "8080"),
+		TelegramBotToken:      getEnv("TELEGRAM_BOT_TOKEN", ""),
+		FMPCoreAPIURL:         getEnv("FMP_CORE_API_URL", "http://localhost:8080/api/v1"),
+		FrontendURL:           getEnv("FRONTEND_URL", "http://localhost:3000"),
+		TelegramWebhookSecret: getEnv("TELEGRAM_WEBHOOK_SECRET", ""),
+		TelegramBotUsername:   getEnv("TELEGRAM_BOT_USERNAME", ""),
+		InternalAPISecret:     getEnv("INTERNAL_API_SECRET", ""),
+		InitDataMaxAge:        getDurationEnv("TELEGRAM_INIT_DATA_MAX_AGE", 24*time.Hour),
+		JWTSecret:             getEnv("JWT_SECRET", ""),
+		SessionTTL:            getDurationEnv("JWT_EXPIRES_IN", time.Hour),
 	}
 }
 
@@ -33,3 +52,12 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
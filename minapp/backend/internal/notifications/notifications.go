@@ -7,19 +7,22 @@ import (
 	"net/http"
 	"time"
 
+	"minapp-backend/internal/auth"
 	"minapp-backend/internal/config"
 	"minapp-backend/internal/telegram"
 )
 
 type NotificationService struct {
-	bot *telegram.Bot
-	cfg *config.Config
+	bot      *telegram.Bot
+	cfg      *config.Config
+	sessions *auth.SessionManager
 }
 
 func NewNotificationService(bot *telegram.Bot, cfg *config.Config) *NotificationService {
 	return &NotificationService{
-		bot: bot,
-		cfg: cfg,
+		bot:      bot,
+		cfg:      cfg,
+		sessions: auth.NewSessionManager(cfg.JWTSecret, cfg.SessionTTL),
 	}
 }
 
@@ -30,8 +33,19 @@ func (ns *NotificationService) SendDailyReminder(chatID int64) error {
 	url := ns.cfg.FMPCoreAPIURL + "/api/v1/transactions?start_date=" + today + "&end_date=" + today
 
 	// Make API request to check transactions
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to check transactions: %w", err)
+	}
+	// In a private chat the chat ID is the user's Telegram ID
+	session, err := ns.sessions.Issue(chatID)
+	if err != nil {
+		return fmt.Errorf("failed to check transactions: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+
 	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Get(url)
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to check transactions: %w", err)
 	}
@@ -55,16 +69,17 @@ func (ns *NotificationService) SendDailyReminder(chatID int64) error {
 }
 
 // SendLimitWarning sends a warning when category limit is approaching
-func (ns *NotificationService) SendLimitWarning(chatID int64, categoryName string, currentAmount, limitAmount float64) error {
+func (ns *NotificationService) SendLimitWarning(chatID int64, categoryName string, currentAmount, limitAmount float64, currency string) error {
 	percentage := (currentAmount / limitAmount) * 100
+	symbol := CurrencySymbol(currency)
 
 	var message string
 	if percentage >= 100 {
-		message = fmt.Sprintf("🚨 Лимит превышен!\n\nКатегория: %s\nПотрачено: %.2f ₽\nЛимит: %.2f ₽\nПревышение: %.2f ₽",
-			categoryName, currentAmount, limitAmount, currentAmount-limitAmount)
+		message = fmt.Sprintf("🚨 Лимит превышен!\n\nКатегория: %s\nПотрачено: %.2f %s\nЛимит: %.2f %s\nПревышение: %.2f %s",
+			categoryName, currentAmount, symbol, limitAmount, symbol, currentAmount-limitAmount, symbol)
 	} else if percentage >= 80 {
-		message = fmt.Sprintf("⚠️ Приближается к лимиту!\n\nКатегория: %s\nПотрачено: %.2f ₽ (%.1f%%)\nЛимит: %.2f ₽\nОсталось: %.2f ₽",
-			categoryName, currentAmount, percentage, limitAmount, limitAmount-currentAmount)
+		message = fmt.Sprintf("⚠️ Приближается к лимиту!\n\nКатегория: %s\nПотрачено: %.2f %s (%.1f%%)\nЛимит: %.2f %s\nОсталось: %.2f %s",
+			categoryName, currentAmount, symbol, percentage, limitAmount, symbol, limitAmount-currentAmount, symbol)
 	} else {
 		return nil // No warning needed
 	}
@@ -78,16 +93,17 @@ func (ns *NotificationService) SendLimitWarning(chatID int64, categoryName strin
 }
 
 // SendPlannedExpenseReminder sends a reminder about upcoming planned expenses
-func (ns *NotificationService) SendPlannedExpenseReminder(chatID int64, expenseName string, plannedDate time.Time, amount float64) error {
+func (ns *NotificationService) SendPlannedExpenseReminder(chatID int64, expenseName string, plannedDate time.Time, amount float64, currency string) error {
 	daysUntil := int(time.Until(plannedDate).Hours() / 24)
+	symbol := CurrencySymbol(currency)
 
 	var message string
 	if daysUntil == 0 {
-		message = fmt.Sprintf("📅 Сегодня планируемый расход!\n\n%s\nСумма: %.2f ₽", expenseName, amount)
+		message = fmt.Sprintf("📅 Сегодня планируемый расход!\n\n%s\nСумма: %.2f %s", expenseName, amount, symbol)
 	} else if daysUntil == 1 {
-		message = fmt.Sprintf("📅 Завтра планируемый расход!\n\n%s\nСумма: %.2f ₽", expenseName, amount)
+		message = fmt.Sprintf("📅 Завтра планируемый расход!\n\n%s\nСумма: %.2f %s", expenseName, amount, symbol)
 	} else if daysUntil <= 3 {
-		message = fmt.Sprintf("📅 Через %d дня планируемый расход!\n\n%s\nСумма: %.2f ₽", daysUntil, expenseName, amount)
+		message = fmt.Sprintf("📅 Через %d дня планируемый расход!\n\n%s\nСумма: %.2f %s", daysUntil, expenseName, amount, symbol)
 	} else {
 		return nil // Too far in the future
 	}
@@ -101,14 +117,14 @@ func (ns *NotificationService) SendPlannedExpenseReminder(chatID int64, expenseN
 }
 
 // SendIncomeCopyNotification sends notification when income is copied from previous month
-func (ns *NotificationService) SendIncomeCopyNotification(chatID int64, month int, year int, amount float64) error {
+func (ns *NotificationService) SendIncomeCopyNotification(chatID int64, month int, year int, amount float64, currency string) error {
 	monthNames := []string{
 		"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
 		"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
 	}
 
-	message := fmt.Sprintf("📋 Доход скопирован!\n\nМесяц: %s %d\nСумма: %.2f ₽\n\nДоход был скопирован из предыдущего месяца. Проверьте и при необходимости отредактируйте.",
-		monthNames[month-1], year, amount)
+	message := fmt.Sprintf("📋 Доход скопирован!\n\nМесяц: %s %d\nСумма: %.2f %s\n\nДоход был скопирован из предыдущего месяца. Проверьте и при необходимости отредактируйте.",
+		monthNames[month-1], year, amount, CurrencySymbol(currency))
 
 	if err := ns.bot.SendMessage(chatID, message); err != nil {
 		return fmt.Errorf("failed to send income copy notification: %w", err)
@@ -118,6 +134,21 @@ func (ns *NotificationService) SendIncomeCopyNotification(chatID int64, month in
 	return nil
 }
 
+// CurrencySymbol returns the sign shown next to amounts, or the code itself
+// for currencies without a well-known sign
+func CurrencySymbol(currency string) string {
+	switch currency {
+	case "", "RUB":
+		return "₽"
+	case "USD":
+		return "$"
+	case "EUR":
+		return "€"
+	default:
+		return currency
+	}
+}
+
 func (ns *NotificationService) generateReminderMessage() string {
 	memes := []string{
 		"😴", "🤔", "💭", "📝", "💰", "⏰", "📱", "🎯",
@@ -2,10 +2,17 @@ package telegram
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -34,6 +41,22 @@ type SendMessageRequest struct {
 	ParseMode string `json:"parse_mode,omitempty"`
 }
 
+// PhotoSize is one of the sizes Telegram keeps of a photo sent to the bot
+type PhotoSize struct {
+	FileID   string `json:"file_id"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	FileSize int64  `json:"file_size,omitempty"`
+}
+
+// Document is a file sent to the bot as a document
+type Document struct {
+	FileID   string `json:"file_id"`
+	FileName string `json:"file_name,omitempty"`
+	MimeType string `json:"mime_type,omitempty"`
+	FileSize int64  `json:"file_size,omitempty"`
+}
+
 type SendMessageResponse struct {
 	OK     bool `json:"ok"`
 	Result struct {
@@ -82,13 +105,101 @@ func (b *Bot) SendMessage(chatID int64, text string) error {
 	return nil
 }
 
-func (b *Bot) ValidateWebAppData(data string) (*TelegramWebAppData, error) {
-	// In a real implementation, you would validate the hash here
-	// For now, we'll just parse the data
-	var webAppData TelegramWebAppData
-	if err := json.Unmarshal([]byte(data), &webAppData); err != nil {
+// DownloadFile fetches a file sent to the bot. Telegram serves files of up
+// to 20 MB to bots, the file path tells the original extension.
+// See https://core.telegram.org/bots/api#getfile
+func (b *Bot) DownloadFile(fileID string) ([]byte, string, error) {
+	resp, err := b.Client.Get(fmt.Sprintf("https://api.telegram.org/bot%s/getFile?file_id=%s", b.Token, url.QueryEscape(fileID)))
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	var file struct {
+		OK     bool `json:"ok"`
+		Result struct {
+			FilePath string `json:"file_path"`
+		} `json:"result"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return nil, "", err
+	}
+	if !file.OK || file.Result.FilePath == "" {
+		return nil, "", fmt.Errorf("telegram API error: %s", file.Description)
+	}
+
+	contents, err := b.Client.Get(fmt.Sprintf("https://api.telegram.org/file/bot%s/%s", b.Token, file.Result.FilePath))
+	if err != nil {
+		return nil, "", err
+	}
+	defer contents.Body.Close()
+
+	if contents.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("telegram file download failed with status %d", contents.StatusCode)
+	}
+
+	data, err := io.ReadAll(contents.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, file.Result.FilePath, nil
+}
+
+// ValidateWebAppData verifies the initData query string the Mini App receives
+// from Telegram (query_id=...&user=%7B...%7D&auth_date=...&hash=...) and
+// rejects it if it is older than maxAge.
+// See https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app
+func (b *Bot) ValidateWebAppData(data string, maxAge time.Duration) (*TelegramWebAppData, error) {
+	values, err := url.ParseQuery(data)
+	if err != nil {
 		return nil, err
 	}
 
+	receivedHash, err := hex.DecodeString(values.Get("hash"))
+	if err != nil || len(receivedHash) == 0 {
+		return nil, fmt.Errorf("init data has no valid hash")
+	}
+	if !hmac.Equal(receivedHash, b.webAppDataHash(values)) {
+		return nil, fmt.Errorf("init data hash mismatch")
+	}
+
+	webAppData := TelegramWebAppData{
+		QueryID: values.Get("query_id"),
+		Hash:    values.Get("hash"),
+	}
+	if err := json.Unmarshal([]byte(values.Get("user")), &webAppData.User); err != nil {
+		return nil, fmt.Errorf("invalid user in init data: %w", err)
+	}
+	if webAppData.User.ID == 0 {
+		return nil, fmt.Errorf("init data has no user")
+	}
+	if webAppData.AuthDate, err = strconv.ParseInt(values.Get("auth_date"), 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid auth_date in init data: %w", err)
+	}
+	if time.Since(time.Unix(webAppData.AuthDate, 0)) > maxAge {
+		return nil, fmt.Errorf("init data has expired")
+	}
+
 	return &webAppData, nil
 }
+
+// webAppDataHash computes the expected hash of the init data: HMAC-SHA256 of
+// the sorted key=value lines, keyed with HMAC-SHA256("WebAppData", bot token)
+func (b *Bot) webAppDataHash(values url.Values) []byte {
+	pairs := make([]string, 0, len(values))
+	for key := range values {
+		if key == "hash" {
+			continue
+		}
+		pairs = append(pairs, key+"="+values.Get(key))
+	}
+	sort.Strings(pairs)
+
+	secretKey := hmac.New(sha256.New, []byte("WebAppData"))
+	secretKey.Write([]byte(b.Token))
+
+	mac := hmac.New(sha256.New, secretKey.Sum(nil))
+	mac.Write([]byte(strings.Join(pairs, "\n")))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,156 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "123456:TEST-token"
+
+// signInitData builds init data the way Telegram does, independently of
+// webAppDataHash so the two can not share a mistake
+func signInitData(token string, fields map[string]string) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+"="+fields[key])
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(token))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(lines, "\n")))
+
+	values := url.Values{}
+	for key, value := range fields {
+		values.Set(key, value)
+	}
+	values.Set("hash", hex.EncodeToString(mac.Sum(nil)))
+	return values.Encode()
+}
+
+func initDataFields(authDate time.Time) map[string]string {
+	return map[string]string{
+		"query_id":  "AAHdF6IQAAAAAN0XohDhrOrc",
+		"user":      `{"id":279058397,"first_name":"Vladislav","username":"vdkfrost"}`,
+		"auth_date": strconv.FormatInt(authDate.Unix(), 10),
+	}
+}
+
+func TestValidateWebAppData(t *testing.T) {
+	bot := &Bot{Token: testToken}
+	maxAge := 24 * time.Hour
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		data    func() string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			data: func() string { return signInitData(testToken, initDataFields(now)) },
+		},
+		{
+			name: "just inside the window",
+			data: func() string { return signInitData(testToken, initDataFields(now.Add(-maxAge+time.Minute))) },
+		},
+		{
+			name:    "expired",
+			data:    func() string { return signInitData(testToken, initDataFields(now.Add(-maxAge-time.Minute))) },
+			wantErr: "init data has expired",
+		},
+		{
+			name:    "signed with another token",
+			data:    func() string { return signInitData("654321:OTHER-token", initDataFields(now)) },
+			wantErr: "init data hash mismatch",
+		},
+		{
+			name: "tampered user",
+			data: func() string {
+				values, _ := url.ParseQuery(signInitData(testToken, initDataFields(now)))
+				values.Set("user", `{"id":1,"first_name":"Mallory"}`)
+				return values.Encode()
+			},
+			wantErr: "init data hash mismatch",
+		},
+		{
+			name: "tampered auth_date",
+			data: func() string {
+				values, _ := url.ParseQuery(signInitData(testToken, initDataFields(now.Add(-2*maxAge))))
+				values.Set("auth_date", strconv.FormatInt(now.Unix(), 10))
+				return values.Encode()
+			},
+			wantErr: "init data hash mismatch",
+		},
+		{
+			name: "no hash",
+			data: func() string {
+				values, _ := url.ParseQuery(signInitData(testToken, initDataFields(now)))
+				values.Del("hash")
+				return values.Encode()
+			},
+			wantErr: "init data has no valid hash",
+		},
+		{
+			name: "hash is not hex",
+			data: func() string {
+				values, _ := url.ParseQuery(signInitData(testToken, initDataFields(now)))
+				values.Set("hash", "not-hex")
+				return values.Encode()
+			},
+			wantErr: "init data has no valid hash",
+		},
+		{
+			name: "no user",
+			data: func() string {
+				fields := initDataFields(now)
+				fields["user"] = `{"first_name":"Nobody"}`
+				return signInitData(testToken, fields)
+			},
+			wantErr: "init data has no user",
+		},
+		{
+			name: "invalid auth_date",
+			data: func() string {
+				fields := initDataFields(now)
+				fields["auth_date"] = "yesterday"
+				return signInitData(testToken, fields)
+			},
+			wantErr: "invalid auth_date in init data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bot.ValidateWebAppData(tt.data(), maxAge)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ValidateWebAppData() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateWebAppData() error = %v", err)
+			}
+			if got.User.ID != 279058397 || got.User.Username != "vdkfrost" {
+				t.Errorf("ValidateWebAppData() user = %+v", got.User)
+			}
+			if got.QueryID != "AAHdF6IQAAAAAN0XohDhrOrc" {
+				t.Errorf("ValidateWebAppData() query_id = %q", got.QueryID)
+			}
+		})
+	}
+}
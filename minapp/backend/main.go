@@ -20,6 +20,13 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	if cfg.JWTSecret == "" {
+		log.Fatal("JWT_SECRET is required to issue session tokens")
+	}
+	if cfg.TelegramWebhookSecret == "" {
+		log.Fatal("TELEGRAM_WEBHOOK_SECRET is required to verify webhook updates")
+	}
+
 	// Initialize Telegram bot
 	bot, err := telegram.InitializeBot(cfg.TelegramBotToken)
 	if err != nil {
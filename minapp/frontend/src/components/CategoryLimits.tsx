@@ -1,6 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { Plus, Edit, Trash2, AlertTriangle } from 'lucide-react';
-import { apiService, CategoryLimit, Category } from '../services/api';
+import { apiService, CategoryLimit, Category, currencySymbol } from '../services/api';
 
 interface CategoryLimitsProps {
   categories: Category[];
@@ -225,7 +225,7 @@ export const CategoryLimits: React.FC<CategoryLimitsProps> = ({ categories }) =>
                 <p className="limit-period">
                   {getMonthName(limit.month)} {limit.year}
                 </p>
-                <p className="limit-amount">{limit.limit.toLocaleString('ru-RU')} ₽</p>
+                <p className="limit-amount">{Number(limit.limit).toLocaleString('ru-RU')} {currencySymbol(limit.currency)}</p>
               </div>
               
               <div className="limit-actions">
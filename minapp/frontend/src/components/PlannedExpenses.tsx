@@ -2,7 +2,7 @@ import React, { useState, useEffect } from 'react';
 import { format, addDays, addWeeks, addMonths } from 'date-fns';
 import { ru } from 'date-fns/locale';
 import { Plus, Calendar, DollarSign, CheckCircle, Circle, Edit, Trash2 } from 'lucide-react';
-import { apiService, Category, PlannedExpense } from '../services/api';
+import { apiService, Category, PlannedExpense, currencySymbol } from '../services/api';
 
 interface PlannedExpensesProps {
   categories: Category[];
@@ -295,7 +295,7 @@ export const PlannedExpenses: React.FC<PlannedExpensesProps> = ({ categories })
               <div className="expense-info">
                 <div className="expense-header">
                   <h3>{getCategoryName(expense.category_id)}</h3>
-                  <span className="expense-amount">{expense.amount.toFixed(2)} ₽</span>
+                  <span className="expense-amount">{expense.amount} {currencySymbol(expense.currency)}</span>
                 </div>
                 {expense.description && (
                   <p className="expense-description">{expense.description}</p>
@@ -1,6 +1,6 @@
 import React, { useState, useEffect } from 'react';
 import { Plus, DollarSign, Edit, Trash2 } from 'lucide-react';
-import { apiService, PlannedIncome as PlannedIncomeType } from '../services/api';
+import { apiService, PlannedIncome as PlannedIncomeType, currencySymbol } from '../services/api';
 
 export const PlannedIncome: React.FC = () => {
   const [incomes, setIncomes] = useState<PlannedIncomeType[]>([]);
@@ -80,7 +80,7 @@ export const PlannedIncome: React.FC = () => {
   };
 
   const getTotalIncome = () => {
-    return incomes.reduce((total, income) => total + income.amount, 0);
+    return incomes.reduce((total, income) => total + Number(income.amount), 0);
   };
 
   const copyFromPreviousMonth = async (month: number) => {
@@ -248,7 +248,7 @@ export const PlannedIncome: React.FC = () => {
                 <div className="month-content">
                   {income ? (
                     <div className="income-details">
-                      <div className="income-amount">{income.amount.toFixed(2)} ₽</div>
+                      <div className="income-amount">{income.amount} {currencySymbol(income.currency)}</div>
                       {income.description && (
                         <div className="income-description">{income.description}</div>
                       )}
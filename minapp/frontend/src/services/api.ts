@@ -1,4 +1,5 @@
 import axios from 'axios';
+import { WebApp } from '../telegram-webapp';
 
 const API_BASE_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080/api';
 
@@ -9,8 +10,62 @@ const api = axios.create({
   },
 });
 
+interface Session {
+  token: string;
+  expires_at: string;
+}
+
+let session: Session | null = null;
+
+// Exchanges Telegram init data for a short-lived session token
+const getSessionToken = async (): Promise<string> => {
+  if (!session || new Date(session.expires_at).getTime() - Date.now() < 60_000) {
+    const response = await axios.post<Session>(`${API_BASE_URL}/auth/session`, null, {
+      headers: { 'X-Telegram-Init-Data': WebApp.initData },
+    });
+    session = response.data;
+  }
+  return session.token;
+};
+
+// The budget requests work with, the personal budget when not set
+let currentBudgetId: string | null = null;
+
+export const setCurrentBudget = (budgetId: string | null) => {
+  currentBudgetId = budgetId;
+};
+
+api.interceptors.request.use(async (config) => {
+  config.headers.Authorization = `Bearer ${await getSessionToken()}`;
+  if (currentBudgetId) {
+    config.headers['X-Budget-ID'] = currentBudgetId;
+  }
+  return config;
+});
+
+// currencySymbol returns the sign shown next to amounts, or the code itself
+export const currencySymbol = (currency?: string): string => {
+  switch (currency) {
+    case undefined:
+    case '':
+    case 'RUB':
+      return '₽';
+    case 'USD':
+      return '$';
+    case 'EUR':
+      return '€';
+    default:
+      return currency;
+  }
+};
+
+// Money is an exact decimal amount like "12.50". Amounts come as strings so
+// they never lose precision; convert with Number() only to show or sum them.
+export type Money = string;
+
 export interface Category {
   id: string;
+  parent_id?: string;
   name: string;
   description?: string;
   created_at: string;
@@ -19,18 +74,33 @@ export interface Category {
 
 export interface Transaction {
   id: string;
-  category_id: string;
-  amount: number;
+  // null on the two transactions of a transfer between accounts
+  category_id: string | null;
+  amount: Money;
+  currency: string;
   description?: string;
+  payee_id?: string;
   date: string;
+  tags?: string[];
+  splits?: TransactionSplit[];
+  transfer_id?: string;
   created_at: string;
   updated_at: string;
 }
 
+export interface TransactionSplit {
+  id?: string;
+  category_id: string;
+  amount: Money;
+  note?: string;
+}
+
 export interface CategoryLimit {
   id: string;
   category_id: string;
-  limit: number;
+  limit: Money;
+  currency: string;
+  rollover?: boolean;
   month: number;
   year: number;
   created_at: string;
@@ -40,32 +110,46 @@ export interface CategoryLimit {
 export interface MonthlySummary {
   month: number;
   year: number;
+  currency: string;
   categories: CategorySummary[];
-  total: number;
+  total: Money;
+  planned_income?: Money;
 }
 
 export interface CategorySummary {
   category_id: string;
+  parent_id?: string;
   category_name: string;
-  amount: number;
-  limit?: number;
+  amount: Money;
+  // effective limit: base_limit plus what carried over from last month
+  limit?: Money;
+  base_limit?: Money;
+  carried?: Money;
   is_exceeded: boolean;
 }
 
 export interface PlannedExpense {
   id: string;
   category_id: string;
-  amount: number;
+  amount: Money;
+  currency: string;
   description?: string;
   planned_date: string;
   is_completed: boolean;
+  tags?: string[];
+  // transaction the expense was completed with
+  transaction_id?: string;
   created_at: string;
   updated_at: string;
 }
 
+// PlannedIncome is one income source of a month, a month can have several
 export interface PlannedIncome {
   id: string;
-  amount: number;
+  name: string;
+  category_id?: string;
+  amount: Money;
+  currency: string;
   description?: string;
   month: number;
   year: number;
@@ -73,6 +157,231 @@ export interface PlannedIncome {
   updated_at: string;
 }
 
+export interface PlannedCopyResult {
+  from_month: number;
+  from_year: number;
+  to_month: number;
+  to_year: number;
+  income: PlannedIncome[];
+  expenses: PlannedExpense[];
+  // copied income per currency
+  income_totals: Record<string, Money>;
+}
+
+export interface Goal {
+  id: string;
+  name: string;
+  target_amount: Money;
+  currency: string;
+  start_date: string;
+  deadline?: string;
+  account_id?: string;
+  category_id?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface GoalProgress {
+  goal_id: string;
+  name: string;
+  currency: string;
+  target_amount: Money;
+  saved: Money;
+  remaining: Money;
+  percent: number;
+  is_reached: boolean;
+  deadline?: string;
+  months_left?: number;
+  // what has to be saved every month to make the deadline
+  required_monthly?: Money;
+  months: number;
+  average_monthly: Money;
+  // when the goal is reached at the average pace of the last months
+  projected_date?: string;
+  on_track?: boolean;
+  as_of: string;
+}
+
+export interface Counterparty {
+  id: string;
+  name: string;
+  note: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export type DebtDirection = 'lent' | 'borrowed';
+export type DebtSchedule = 'none' | 'annuity' | 'differentiated';
+
+export interface Debt {
+  id: string;
+  counterparty_id: string;
+  direction: DebtDirection;
+  name: string;
+  principal: Money;
+  currency: string;
+  // yearly percentage
+  interest_rate: number;
+  schedule: DebtSchedule;
+  term_months?: number;
+  start_date: string;
+  account_id?: string;
+  category_id?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface AmortizationRow {
+  number: number;
+  date: string;
+  payment: Money;
+  principal: Money;
+  interest: Money;
+  balance: Money;
+}
+
+export interface DebtBalance {
+  debt_id: string;
+  counterparty_id: string;
+  name: string;
+  direction: DebtDirection;
+  currency: string;
+  principal: Money;
+  principal_paid: Money;
+  interest_paid: Money;
+  outstanding_principal: Money;
+  accrued_interest: Money;
+  outstanding: Money;
+  last_payment_date?: string;
+  next_payment?: AmortizationRow;
+  is_closed: boolean;
+  as_of: string;
+}
+
+export interface Rule {
+  id: string;
+  name: string;
+  priority: number;
+  is_active: boolean;
+  description_contains?: string;
+  description_regex?: string;
+  min_amount?: Money;
+  max_amount?: Money;
+  payee_id?: string;
+  account_id?: string;
+  category_id?: string;
+  tags: string[];
+  created_at: string;
+  updated_at: string;
+}
+
+export interface RuleMatch {
+  transaction_id: string;
+  description: string;
+  date: string;
+  amount: Money;
+  currency: string;
+  rule_id: string;
+  rule_name: string;
+  old_category_id?: string;
+  new_category_id?: string;
+  added_tags?: string[];
+}
+
+export interface ApplyRulesResult {
+  dry_run: boolean;
+  checked: number;
+  changes: RuleMatch[];
+}
+
+export type SearchKind = 'transaction' | 'planned_expense';
+
+// highlight is escaped HTML with the matches wrapped in <mark> tags
+export interface SearchResult {
+  kind: SearchKind;
+  id: string;
+  date: string;
+  amount: Money;
+  currency: string;
+  description: string;
+  highlight: string;
+  rank: number;
+}
+
+export type TrashKind =
+  | 'category'
+  | 'account'
+  | 'transaction'
+  | 'transfer'
+  | 'planned_expense'
+  | 'recurring_expense'
+  | 'planned_income'
+  | 'category_limit'
+  | 'goal'
+  | 'goal_contribution'
+  | 'debt'
+  | 'debt_payment'
+  | 'payee'
+  | 'rule'
+  | 'tag'
+  | 'attachment';
+
+export interface TrashItem {
+  kind: TrashKind;
+  id: string;
+  name: string;
+  amount?: Money;
+  currency?: string;
+  deleted_at: string;
+  purge_at: string;
+}
+
+export interface Payee {
+  id: string;
+  name: string;
+  aliases: string[];
+  created_at: string;
+  updated_at: string;
+}
+
+export interface PayeeSummary {
+  payee_id: string;
+  payee_name: string;
+  amount: Money;
+  visits: number;
+  average_amount: Money;
+  last_date: string;
+}
+
+export type BudgetRole = 'owner' | 'editor' | 'viewer';
+
+export interface Budget {
+  id: string;
+  name: string;
+  is_personal: boolean;
+  role: BudgetRole;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface BudgetMember {
+  budget_id: string;
+  user_id: string;
+  telegram_id?: number;
+  role: BudgetRole;
+  created_at: string;
+}
+
+export interface BudgetInvite {
+  id: string;
+  budget_id: string;
+  token: string;
+  start_parameter: string;
+  link?: string;
+  role: BudgetRole;
+  expires_at: string;
+}
+
 export interface Notification {
   id: string;
   type: 'daily_reminder' | 'limit_warning' | 'limit_exceeded' | 'income_reminder';
@@ -102,11 +411,15 @@ export const apiService = {
   // Transactions
   getTransactions: async (filters?: {
     category_id?: string;
+    payee_id?: string;
+    q?: string;
     start_date?: string;
     end_date?: string;
   }): Promise<Transaction[]> => {
     const params = new URLSearchParams();
+    if (filters?.q) params.append('q', filters.q);
     if (filters?.category_id) params.append('category_id', filters.category_id);
+    if (filters?.payee_id) params.append('payee_id', filters.payee_id);
     if (filters?.start_date) params.append('start_date', filters.start_date);
     if (filters?.end_date) params.append('end_date', filters.end_date);
     
@@ -114,10 +427,12 @@ export const apiService = {
     return response.data;
   },
 
+  // category_id may be left out for the rules to fill in
   createTransaction: async (data: {
-    category_id: string;
+    category_id?: string;
     amount: number;
     description?: string;
+    payee_id?: string;
     date?: string;
   }): Promise<Transaction> => {
     const response = await api.post('/transactions', data);
@@ -175,8 +490,10 @@ export const apiService = {
     start_date?: string;
     end_date?: string;
     is_completed?: boolean;
+    q?: string;
   }): Promise<PlannedExpense[]> => {
     const params = new URLSearchParams();
+    if (filters?.q) params.append('q', filters.q);
     if (filters?.category_id) params.append('category_id', filters.category_id);
     if (filters?.start_date) params.append('start_date', filters.start_date);
     if (filters?.end_date) params.append('end_date', filters.end_date);
@@ -198,7 +515,7 @@ export const apiService = {
 
   updatePlannedExpense: async (id: string, data: {
     category_id: string;
-    amount: number;
+    amount: number | Money;
     description?: string;
     planned_date: string;
   }): Promise<PlannedExpense> => {
@@ -210,6 +527,21 @@ export const apiService = {
     await api.delete(`/planned-expenses/${id}`);
   },
 
+  completePlannedExpense: async (id: string, data?: {
+    transaction_id?: string;
+    account_id?: string;
+    amount?: number;
+    date?: string;
+  }): Promise<PlannedExpense> => {
+    const response = await api.post(`/planned-expenses/${id}/complete`, data ?? {});
+    return response.data;
+  },
+
+  uncompletePlannedExpense: async (id: string): Promise<PlannedExpense> => {
+    const response = await api.post(`/planned-expenses/${id}/uncomplete`);
+    return response.data;
+  },
+
   // Planned Income
   getPlannedIncome: async (filters?: {
     month?: number;
@@ -224,7 +556,9 @@ export const apiService = {
   },
 
   createPlannedIncome: async (data: {
-    amount: number;
+    name?: string;
+    category_id?: string;
+    amount: number | Money;
     description?: string;
     month: number;
     year: number;
@@ -234,6 +568,8 @@ export const apiService = {
   },
 
   updatePlannedIncome: async (id: string, data: {
+    name?: string;
+    category_id?: string;
     amount: number;
     description?: string;
     month: number;
@@ -247,6 +583,209 @@ export const apiService = {
     await api.delete(`/planned-income/${id}`);
   },
 
+  copyPlannedIncome: async (data: {
+    from_month?: number;
+    from_year?: number;
+    to_month: number;
+    to_year: number;
+    include_expenses?: boolean;
+  }): Promise<PlannedCopyResult> => {
+    const response = await api.post('/planned-income/copy', data);
+    return response.data;
+  },
+
+  // Goals
+  getGoals: async (): Promise<Goal[]> => {
+    const response = await api.get('/goals');
+    return response.data;
+  },
+
+  createGoal: async (data: {
+    name: string;
+    target_amount: number;
+    currency?: string;
+    deadline?: string;
+    account_id?: string;
+    category_id?: string;
+  }): Promise<Goal> => {
+    const response = await api.post('/goals', data);
+    return response.data;
+  },
+
+  getGoalsProgress: async (): Promise<GoalProgress[]> => {
+    const response = await api.get('/goals/progress');
+    return response.data;
+  },
+
+  addGoalContribution: async (goalId: string, data: {
+    amount: number;
+    date?: string;
+    note?: string;
+  }): Promise<void> => {
+    await api.post(`/goals/${goalId}/contributions`, data);
+  },
+
+  // Debts
+  getCounterparties: async (): Promise<Counterparty[]> => {
+    const response = await api.get('/counterparties');
+    return response.data;
+  },
+
+  createCounterparty: async (data: { name: string; note?: string }): Promise<Counterparty> => {
+    const response = await api.post('/counterparties', data);
+    return response.data;
+  },
+
+  getDebts: async (filters?: {
+    counterparty_id?: string;
+    direction?: DebtDirection;
+  }): Promise<Debt[]> => {
+    const params = new URLSearchParams();
+    if (filters?.counterparty_id) params.append('counterparty_id', filters.counterparty_id);
+    if (filters?.direction) params.append('direction', filters.direction);
+
+    const response = await api.get(`/debts?${params.toString()}`);
+    return response.data;
+  },
+
+  createDebt: async (data: {
+    counterparty_id: string;
+    direction: DebtDirection;
+    name: string;
+    principal: number;
+    currency?: string;
+    interest_rate?: number;
+    schedule?: DebtSchedule;
+    term_months?: number;
+    start_date?: string;
+  }): Promise<Debt> => {
+    const response = await api.post('/debts', data);
+    return response.data;
+  },
+
+  getDebtBalances: async (): Promise<DebtBalance[]> => {
+    const response = await api.get('/debts/balances');
+    return response.data;
+  },
+
+  addDebtPayment: async (debtId: string, data: {
+    amount: number;
+    date?: string;
+    note?: string;
+    create_transaction?: boolean;
+  }): Promise<void> => {
+    await api.post(`/debts/${debtId}/payments`, data);
+  },
+
+  // Rules
+  getRules: async (): Promise<Rule[]> => {
+    const response = await api.get('/rules');
+    return response.data;
+  },
+
+  createRule: async (data: Omit<Rule, 'id' | 'created_at' | 'updated_at' | 'is_active' | 'tags'> & {
+    is_active?: boolean;
+    tags?: string[];
+  }): Promise<Rule> => {
+    const response = await api.post('/rules', data);
+    return response.data;
+  },
+
+  dryRunRules: async (data: { rule_ids?: string[]; start_date?: string; end_date?: string } = {}): Promise<ApplyRulesResult> => {
+    const response = await api.post('/rules/dry-run', data);
+    return response.data;
+  },
+
+  applyRules: async (data: { rule_ids?: string[]; start_date?: string; end_date?: string } = {}): Promise<ApplyRulesResult> => {
+    const response = await api.post('/rules/apply', data);
+    return response.data;
+  },
+
+  // Search
+  search: async (q: string, filters?: {
+    kind?: SearchKind[];
+    start_date?: string;
+    end_date?: string;
+    limit?: number;
+  }): Promise<SearchResult[]> => {
+    const params = new URLSearchParams({ q });
+    filters?.kind?.forEach((kind) => params.append('kind', kind));
+    if (filters?.start_date) params.append('start_date', filters.start_date);
+    if (filters?.end_date) params.append('end_date', filters.end_date);
+    if (filters?.limit) params.append('limit', filters.limit.toString());
+
+    const response = await api.get(`/search?${params.toString()}`);
+    return response.data;
+  },
+
+  // Trash
+  getTrash: async (kinds?: TrashKind[]): Promise<TrashItem[]> => {
+    const params = new URLSearchParams();
+    kinds?.forEach((kind) => params.append('kind', kind));
+
+    const response = await api.get(`/trash?${params.toString()}`);
+    return response.data;
+  },
+
+  restoreTrashItem: async (kind: TrashKind, id: string): Promise<void> => {
+    await api.post(`/trash/${kind}/${id}/restore`);
+  },
+
+  purgeTrashItem: async (kind: TrashKind, id: string): Promise<void> => {
+    await api.delete(`/trash/${kind}/${id}`);
+  },
+
+  // Payees
+  getPayees: async (): Promise<Payee[]> => {
+    const response = await api.get('/payees');
+    return response.data;
+  },
+
+  createPayee: async (data: { name: string; aliases?: string[] }): Promise<Payee> => {
+    const response = await api.post('/payees', data);
+    return response.data;
+  },
+
+  getTopPayees: async (filters?: {
+    type?: 'expense' | 'income';
+    start_date?: string;
+    end_date?: string;
+    sort_by?: 'amount' | 'visits';
+    limit?: number;
+    currency?: string;
+  }): Promise<PayeeSummary[]> => {
+    const response = await api.get('/analytics/top-payees', { params: filters });
+    return response.data;
+  },
+
+  // Budgets
+  getBudgets: async (): Promise<Budget[]> => {
+    const response = await api.get('/budgets');
+    return response.data;
+  },
+
+  createBudget: async (name: string): Promise<Budget> => {
+    const response = await api.post('/budgets', { name });
+    return response.data;
+  },
+
+  getBudgetMembers: async (budgetId: string): Promise<BudgetMember[]> => {
+    const response = await api.get(`/budgets/${budgetId}/members`);
+    return response.data;
+  },
+
+  removeBudgetMember: async (budgetId: string, userId: string): Promise<void> => {
+    await api.delete(`/budgets/${budgetId}/members/${userId}`);
+  },
+
+  createBudgetInvite: async (budgetId: string, data: {
+    role?: 'editor' | 'viewer';
+    ttl_days?: number;
+  } = {}): Promise<BudgetInvite> => {
+    const response = await api.post(`/budgets/${budgetId}/invites`, data);
+    return response.data;
+  },
+
   // Notifications
   getNotifications: async (): Promise<Notification[]> => {
     const response = await api.get('/notifications');